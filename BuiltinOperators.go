@@ -1,7 +1,11 @@
 package govaluate
 
 import (
+	"container/list"
 	"math"
+	"regexp"
+	"sync"
+	"time"
 )
 
 func BuiltinOperators() map[string]Operator {
@@ -12,6 +16,8 @@ func BuiltinOperators() map[string]Operator {
 		"<=": builtinLte,
 		">":  builtinGt,
 		">=": builtinGte,
+		"=~": builtinRegexMatch,
+		"!~": builtinRegexNotMatch,
 
 		"&&": builtinLogicalAnd,
 		"||": builtinLogicalOr,
@@ -34,9 +40,12 @@ func BuiltinOperators() map[string]Operator {
 		"?:": builtinTernaryIf,
 		"??": builtinCoalesce,
 
-		"array": builtinArray,
-		"in":    builtinContains,
-		"[]":    builtinIndexer,
+		"array":  builtinArray,
+		"object": builtinObject,
+		"in":     builtinContains,
+		"[]":     builtinIndexer,
+		".":      builtinMember,
+		".()":    builtinMethodCall,
 
 		"floor": builtinFloor,
 		"ceil":  builtinCeil,
@@ -52,37 +61,200 @@ func BuiltinOperators() map[string]Operator {
 		"log":   builtinLog,
 		"log2":  builtinLog2,
 		"log10": builtinLog10,
+
+		"len":        builtinLen,
+		"lower":      builtinLower,
+		"upper":      builtinUpper,
+		"trim":       builtinTrim,
+		"contains":   builtinStringContains,
+		"startsWith": builtinStartsWith,
+		"endsWith":   builtinEndsWith,
+		"substr":     builtinSubstr,
+		"replace":    builtinReplace,
+		"split":      builtinSplit,
+		"join":       builtinJoin,
+		"format":     builtinFormat,
+		"repeat":     builtinRepeat,
+
+		"now":       builtinNow,
+		"date":      builtinDate,
+		"year":      timePart(time.Time.Year),
+		"month":     timePart(func(t time.Time) int { return int(t.Month()) }),
+		"day":       timePart(time.Time.Day),
+		"hour":      timePart(time.Time.Hour),
+		"minute":    timePart(time.Time.Minute),
+		"second":    timePart(time.Time.Second),
+		"dayOfWeek": timePart(func(t time.Time) int { return int(t.Weekday()) }),
+		"addDays":   builtinAddDays,
+		"truncate":  builtinTruncate,
+
+		"=>":       builtinLambda,
+		"any":      builtinAny,
+		"all":      builtinAll,
+		"none":     builtinNone,
+		"filter":   builtinFilter,
+		"map":      builtinMap,
+		"count":    builtinCount,
+		"sum":      builtinSumItems,
+		"avg":      builtinAvgItems,
+		"sort":     builtinSort,
+		"distinct": builtinDistinct,
+		"first":    builtinFirst,
+		"last":     builtinLast,
+		"reduce":   builtinReduce,
 	}
 }
 
 func builtinEq(ctx EvalContext) (interface{}, error) {
 	a, b, err := binaryArgs(ctx)
-	return a == b, err
+	return equalValues(a, b), err
 }
 
 func builtinNeq(ctx EvalContext) (interface{}, error) {
 	a, b, err := binaryArgs(ctx)
-	return a != b, err
+	return !equalValues(a, b), err
 }
 
 func builtinLt(ctx EvalContext) (interface{}, error) {
-	a, b, err := binaryNumericArgs(ctx)
-	return a < b, err
+	return compareArgs(ctx, func(a, b float64) bool { return a < b }, -1)
 }
 
 func builtinLte(ctx EvalContext) (interface{}, error) {
-	a, b, err := binaryNumericArgs(ctx)
-	return a <= b, err
+	return compareArgs(ctx, func(a, b float64) bool { return a <= b }, -1, 0)
 }
 
 func builtinGt(ctx EvalContext) (interface{}, error) {
-	a, b, err := binaryNumericArgs(ctx)
-	return a > b, err
+	return compareArgs(ctx, func(a, b float64) bool { return a > b }, 1)
 }
 
 func builtinGte(ctx EvalContext) (interface{}, error) {
-	a, b, err := binaryNumericArgs(ctx)
-	return a >= b, err
+	return compareArgs(ctx, func(a, b float64) bool { return a >= b }, 1, 0)
+}
+
+// compareArgs compares numbers with compare, or times and durations by checking their order is one of expected.
+func compareArgs(ctx EvalContext, compare func(float64, float64) bool, expected ...int) (interface{}, error) {
+	left, right, err := binaryArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if order, ok := compareTimes(left, right); ok {
+		for _, e := range expected {
+			if order == e {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	a, b, err := numericValues(ctx, left, right)
+	if err != nil {
+		return nil, err
+	}
+	return compare(a, b), nil
+}
+
+func builtinRegexMatch(ctx EvalContext) (interface{}, error) {
+	return regexMatch(ctx)
+}
+
+func builtinRegexNotMatch(ctx EvalContext) (interface{}, error) {
+	matched, err := regexMatch(ctx)
+	return !matched, err
+}
+
+func regexMatch(ctx EvalContext) (bool, error) {
+	if err := ctx.CheckArgCount(2); err != nil {
+		return false, err
+	}
+	str, err := ctx.StringArg(0)
+	if err != nil {
+		return false, err
+	}
+	pattern, err := ctx.Arg(1)
+	if err != nil {
+		return false, err
+	}
+	var re *regexp.Regexp
+	switch p := pattern.(type) {
+	case *regexp.Regexp:
+		// precompiled by Reduce
+		re = p
+	case string:
+		if ctx.expr.Args[1].Type == NodeTypeLiteral {
+			// not cached, so literal patterns don't evict patterns built at runtime,
+			// Compile and Reduce compile literal patterns once
+			re, err = regexp.Compile(p)
+		} else {
+			re, err = compileRegexp(p)
+		}
+		if err != nil {
+			return false, wrapArgError(ctx.expr, 1, err, "is not a valid regular expression: %v", err)
+		}
+	default:
+		return false, formatArgError(ctx.expr, 1, "is not string: %v", pattern)
+	}
+	return re.MatchString(str), nil
+}
+
+// regexpCache keeps recently used patterns built at runtime, e.g. name =~ pattern,
+// so that evaluating the same expression many times doesn't compile them over and over again.
+// Literal patterns are compiled once per node by Compile and Reduce instead.
+var regexpCache = newRegexpLRU(256)
+
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	return regexpCache.compile(pattern)
+}
+
+// regexpLRU is a cache of compiled patterns, the least recently used one is evicted when it's full.
+type regexpLRU struct {
+	mutex sync.Mutex
+	limit int
+	// order has the most recently used entry at the front
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type regexpEntry struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newRegexpLRU(limit int) *regexpLRU {
+	return &regexpLRU{
+		limit:   limit,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func (c *regexpLRU) compile(pattern string) (*regexp.Regexp, error) {
+	c.mutex.Lock()
+	if element, ok := c.entries[pattern]; ok {
+		c.order.MoveToFront(element)
+		c.mutex.Unlock()
+		return element.Value.(regexpEntry).re, nil
+	}
+	c.mutex.Unlock()
+
+	// compile outside of the lock, patterns can be slow to compile
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.entries[pattern]; ok {
+		// compiled concurrently
+		c.order.MoveToFront(element)
+		return element.Value.(regexpEntry).re, nil
+	}
+	c.entries[pattern] = c.order.PushFront(regexpEntry{pattern, re})
+	if c.order.Len() > c.limit {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(regexpEntry).pattern)
+	}
+	return re, nil
 }
 
 func builtinLogicalAnd(ctx EvalContext) (interface{}, error) {
@@ -116,13 +288,27 @@ func builtinLogicalNot(ctx EvalContext) (interface{}, error) {
 }
 
 func builtinSum(ctx EvalContext) (interface{}, error) {
-	a, b, err := binaryNumericArgs(ctx)
+	left, right, err := binaryArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if sum, ok := timeSum(left, right); ok {
+		return sum, nil
+	}
+	a, b, err := numericValues(ctx, left, right)
 	return a + b, err
 }
 
 func builtinMinus(ctx EvalContext) (interface{}, error) {
 	if ctx.ArgCount() == 1 {
-		right, err := ctx.NumericArg(0)
+		arg, err := ctx.Arg(0)
+		if err != nil {
+			return nil, err
+		}
+		if duration, ok := arg.(time.Duration); ok {
+			return -duration, nil
+		}
+		right, err := numericValue(ctx, 0, arg)
 		return -right, err
 	}
 
@@ -130,7 +316,14 @@ func builtinMinus(ctx EvalContext) (interface{}, error) {
 }
 
 func builtinSub(ctx EvalContext) (interface{}, error) {
-	a, b, err := binaryNumericArgs(ctx)
+	left, right, err := binaryArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if difference, ok := timeDifference(left, right); ok {
+		return difference, nil
+	}
+	a, b, err := numericValues(ctx, left, right)
 	return a - b, err
 }
 
@@ -230,6 +423,25 @@ func builtinArray(ctx EvalContext) (interface{}, error) {
 	return items, nil
 }
 
+func builtinObject(ctx EvalContext) (interface{}, error) {
+	if ctx.ArgCount()%2 != 0 {
+		return nil, ctx.FormatError("wrong number of arguments: %d, expected key-value pairs", ctx.ArgCount())
+	}
+	object := make(map[string]interface{}, ctx.ArgCount()/2)
+	for i := 0; i < ctx.ArgCount(); i += 2 {
+		key, err := ctx.StringArg(i)
+		if err != nil {
+			return nil, err
+		}
+		value, err := ctx.Arg(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		object[key] = value
+	}
+	return object, nil
+}
+
 func builtinContains(ctx EvalContext) (interface{}, error) {
 	if err := ctx.CheckArgCount(2); err != nil {
 		return nil, err
@@ -243,7 +455,7 @@ func builtinContains(ctx EvalContext) (interface{}, error) {
 		return nil, err
 	}
 	for _, v := range slice {
-		if item == v {
+		if equalValues(item, v) {
 			return true, nil
 		}
 	}
@@ -254,10 +466,17 @@ func builtinIndexer(ctx EvalContext) (interface{}, error) {
 	if err := ctx.CheckArgCount(2); err != nil {
 		return nil, err
 	}
-	slice, err := ctx.SliceArg(0)
+	receiver, err := ctx.Arg(0)
 	if err != nil {
 		return nil, err
 	}
+	if object, ok := receiver.(map[string]interface{}); ok {
+		return indexObject(ctx, object)
+	}
+	slice, ok := receiver.([]interface{})
+	if !ok {
+		return nil, formatArgError(ctx.expr, 0, "is not array: %v", receiver)
+	}
 	index, err := ctx.IntegerArg(1)
 	if err != nil {
 		return nil, err
@@ -268,6 +487,18 @@ func builtinIndexer(ctx EvalContext) (interface{}, error) {
 	return slice[index], nil
 }
 
+func indexObject(ctx EvalContext, object map[string]interface{}) (interface{}, error) {
+	key, err := ctx.StringArg(1)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := object[key]
+	if !ok {
+		return nil, ctx.FormatError("key not found: %s", key)
+	}
+	return value, nil
+}
+
 func builtinFloor(ctx EvalContext) (interface{}, error) {
 	arg, err := unaryNumericArg(ctx)
 	return math.Floor(arg), err
@@ -368,6 +599,16 @@ func binaryNumericArgs(ctx EvalContext) (float64, float64, error) {
 	return left, right, nil
 }
 
+// numericValues converts evaluated arguments to numbers, like binaryNumericArgs does.
+func numericValues(ctx EvalContext, left interface{}, right interface{}) (float64, float64, error) {
+	a, err := numericValue(ctx, 0, left)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	b, err := numericValue(ctx, 1, right)
+	return a, b, err
+}
+
 func unaryNumericArg(ctx EvalContext) (float64, error) {
 	if err := ctx.CheckArgCount(1); err != nil {
 		return 0.0, err
@@ -1,5 +1,10 @@
 package govaluate
 
+import (
+	"math/big"
+	"regexp"
+)
+
 type Optimizer func(ExprNode) ExprNode
 
 func BuiltinOptimizers() map[string]Optimizer {
@@ -41,11 +46,11 @@ func BuiltinOptimizers() map[string]Optimizer {
 		"+": func(expr ExprNode) ExprNode {
 			left := expr.Args[0]
 			right := expr.Args[1]
-			if left.IsLiteral(0.0) {
+			if isNumberLiteral(left, 0) {
 				// 0 + x -> x
 				return right
 			}
-			if right.IsLiteral(0.0) {
+			if isNumberLiteral(right, 0) {
 				// x + 0 -> x
 				return left
 			}
@@ -57,11 +62,11 @@ func BuiltinOptimizers() map[string]Optimizer {
 			}
 			left := expr.Args[0]
 			right := expr.Args[1]
-			if left.IsLiteral(0.0) {
+			if isNumberLiteral(left, 0) {
 				// 0 - x -> -x
 				return NewExprNodeOperator("-", []ExprNode{right}, expr.SourcePos, expr.SourceLen, OperatorTypePrefix)
 			}
-			if right.IsLiteral(0.0) {
+			if isNumberLiteral(right, 0) {
 				// x - 0 -> x
 				return left
 			}
@@ -72,13 +77,14 @@ func BuiltinOptimizers() map[string]Optimizer {
 			right := expr.Args[1]
 			if left.IsLiteral(0.0) || right.IsLiteral(0.0) {
 				// 0 * x -> 0, x * 0 -> 0
+				// not done for integer zero, the result is a float if x is a float
 				return NewExprNodeLiteral(0.0, expr.SourcePos, expr.SourceLen)
 			}
-			if left.IsLiteral(1.0) {
+			if isNumberLiteral(left, 1) {
 				// 1 * x -> x
 				return right
 			}
-			if right.IsLiteral(1.0) {
+			if isNumberLiteral(right, 1) {
 				// x * 1 -> x
 				return left
 			}
@@ -87,12 +93,14 @@ func BuiltinOptimizers() map[string]Optimizer {
 		"/": func(expr ExprNode) ExprNode {
 			left := expr.Args[0]
 			right := expr.Args[1]
-			if right.IsLiteral(1.0) {
+			if isNumberLiteral(right, 1) {
 				// x / 1 -> x
 				return left
 			}
 			return expr
 		},
+		"=~": precompileRegexp,
+		"!~": precompileRegexp,
 		"?:": func(expr ExprNode) ExprNode {
 			condition := expr.Args[0]
 			if condition.IsLiteral(true) {
@@ -107,3 +115,29 @@ func BuiltinOptimizers() map[string]Optimizer {
 		},
 	}
 }
+
+// isNumberLiteral returns true if the expression is a float64, int64 or *big.Rat literal with the given value.
+func isNumberLiteral(expr ExprNode, value int64) bool {
+	if decimal, ok := expr.Value.(*big.Rat); ok && expr.Type == NodeTypeLiteral {
+		return decimal.Cmp(big.NewRat(value, 1)) == 0
+	}
+	return expr.IsLiteral(float64(value)) || expr.IsLiteral(value)
+}
+
+// precompileRegexp replaces constant string pattern with a compiled one: x =~ "^a"
+func precompileRegexp(expr ExprNode) ExprNode {
+	if len(expr.Args) != 2 {
+		return expr
+	}
+	pattern := expr.Args[1]
+	if value, ok := pattern.Value.(string); ok && pattern.Type == NodeTypeLiteral {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			// leave it as is, evaluation will report the error
+			return expr
+		}
+		args := []ExprNode{expr.Args[0], NewExprNodeLiteral(re, pattern.SourcePos, pattern.SourceLen)}
+		return NewExprNodeOperator(expr.Name, args, expr.SourcePos, expr.SourceLen, expr.OperatorType)
+	}
+	return expr
+}
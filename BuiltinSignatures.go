@@ -0,0 +1,371 @@
+package govaluate
+
+// BuiltinSignatures returns type signatures of BuiltinOperators, for use in TypeEnv.
+func BuiltinSignatures() map[string]OperatorSignature {
+	numberOp := Signature(TypeNumber, TypeNumber, TypeNumber)
+	unaryNumberOp := Signature(TypeNumber, TypeNumber)
+	equality := Signature(TypeBool, TypeAny, TypeAny)
+	logicalOp := Signature(TypeBool, TypeBool, TypeBool)
+	regexOp := Signature(TypeBool, TypeString, TypeString)
+	stringPredicate := Signature(TypeBool, TypeString, TypeString)
+	stringOp := Signature(TypeString, TypeString)
+
+	return map[string]OperatorSignature{
+		"==": equality,
+		"!=": equality,
+		"<":  signatureComparison,
+		"<=": signatureComparison,
+		">":  signatureComparison,
+		">=": signatureComparison,
+		"=~": regexOp,
+		"!~": regexOp,
+
+		"&&": logicalOp,
+		"||": logicalOp,
+		"!":  Signature(TypeBool, TypeBool),
+
+		"+": signatureSum,
+		"-": OverloadedSignature(map[int]OperatorSignature{
+			1: signatureNegate,
+			2: signatureSub,
+		}),
+		"*":  numberOp,
+		"/":  numberOp,
+		"%":  numberOp,
+		"**": numberOp,
+
+		"&":  numberOp,
+		"|":  numberOp,
+		"^":  numberOp,
+		"<<": numberOp,
+		">>": numberOp,
+		"~":  unaryNumberOp,
+
+		"?:": signatureTernaryIf,
+		"??": signatureCoalesce,
+
+		"array":  signatureArray,
+		"object": signatureObject,
+		"in":     signatureContains,
+		"[]":     signatureIndexer,
+		".":      Signature(TypeAny, TypeAny, TypeString),
+		".()":    VariadicSignature(TypeAny, TypeAny, TypeAny, TypeString),
+
+		"floor": unaryNumberOp,
+		"ceil":  unaryNumberOp,
+		"round": unaryNumberOp,
+		"sqrt":  unaryNumberOp,
+		"sin":   unaryNumberOp,
+		"cos":   unaryNumberOp,
+		"tan":   unaryNumberOp,
+		"tanh":  unaryNumberOp,
+		"min":   numberOp,
+		"max":   numberOp,
+		"abs":   unaryNumberOp,
+		"log":   unaryNumberOp,
+		"log2":  unaryNumberOp,
+		"log10": unaryNumberOp,
+
+		"len":   signatureLen,
+		"lower": stringOp,
+		"upper": stringOp,
+		"trim": OverloadedSignature(map[int]OperatorSignature{
+			1: stringOp,
+			2: Signature(TypeString, TypeString, TypeString),
+		}),
+		"contains":   stringPredicate,
+		"startsWith": stringPredicate,
+		"endsWith":   stringPredicate,
+		"substr": OverloadedSignature(map[int]OperatorSignature{
+			2: Signature(TypeString, TypeString, TypeNumber),
+			3: Signature(TypeString, TypeString, TypeNumber, TypeNumber),
+		}),
+		"replace": Signature(TypeString, TypeString, TypeString, TypeString),
+		"split":   Signature(TypeArrayOf(TypeString), TypeString, TypeString),
+		"join":    Signature(TypeString, TypeArrayOf(TypeString), TypeString),
+		"format":  VariadicSignature(TypeString, TypeAny, TypeString),
+		"repeat":  Signature(TypeString, TypeString, TypeNumber),
+
+		"now": Signature(TypeTime),
+		"date": OverloadedSignature(map[int]OperatorSignature{
+			1: Signature(TypeTime, TypeString),
+			2: Signature(TypeTime, TypeString, TypeString),
+			3: Signature(TypeTime, TypeString, TypeString, TypeString),
+		}),
+		"year":      timePartSignature,
+		"month":     timePartSignature,
+		"day":       timePartSignature,
+		"hour":      timePartSignature,
+		"minute":    timePartSignature,
+		"second":    timePartSignature,
+		"dayOfWeek": timePartSignature,
+		"addDays": OverloadedSignature(map[int]OperatorSignature{
+			2: Signature(TypeTime, TypeTime, TypeNumber),
+			3: Signature(TypeTime, TypeTime, TypeNumber, TypeString),
+		}),
+		"truncate": OverloadedSignature(map[int]OperatorSignature{
+			2: signatureTruncate,
+			3: signatureTruncate,
+		}),
+
+		"=>":       signatureLambda,
+		"any":      signatureQuantifier,
+		"all":      signatureQuantifier,
+		"none":     signatureQuantifier,
+		"filter":   collectionSignature(true, true, func(elem, body Type) Type { return TypeArrayOf(elem) }),
+		"map":      collectionSignature(true, false, func(elem, body Type) Type { return TypeArrayOf(body) }),
+		"count":    collectionSignature(false, true, func(elem, body Type) Type { return TypeNumber }),
+		"sum":      collectionSignature(false, false, sumType),
+		"avg":      collectionSignature(false, false, sumType),
+		"sort":     collectionSignature(false, false, func(elem, body Type) Type { return TypeArrayOf(elem) }),
+		"distinct": collectionSignature(false, false, func(elem, body Type) Type { return TypeArrayOf(elem) }),
+		"first":    collectionSignature(false, true, func(elem, body Type) Type { return elem }),
+		"last":     collectionSignature(false, true, func(elem, body Type) Type { return elem }),
+		"reduce":   signatureReduce,
+	}
+}
+
+// signatureLambda returns the type of the lambda body, so signatures of operators taking lambdas can check it.
+func signatureLambda(ctx TypeContext) Type {
+	if !ctx.CheckArgCount(2) {
+		return TypeAny
+	}
+	return ctx.ArgType(1)
+}
+
+// collectionSignature accepts an array, and a lambda, either a value or inline, which is optional unless required.
+// Result is given the item type, and the type of the lambda body, or the item type if there is no lambda.
+func collectionSignature(lambdaRequired, predicate bool, result func(elem, body Type) Type) OperatorSignature {
+	return func(ctx TypeContext) Type {
+		minArgs := 1
+		if lambdaRequired {
+			minArgs = 2
+		}
+		if ctx.ArgCount() < minArgs || ctx.ArgCount() > 3 {
+			ctx.Errorf("wrong number of arguments: %d, expected: %d to 3", ctx.ArgCount(), minArgs)
+			return result(TypeAny, TypeAny)
+		}
+		if !ctx.ExpectArg(0, Type{Kind: TypeKindArray}) {
+			return result(TypeAny, TypeAny)
+		}
+		elem := ctx.ArgType(0).elem()
+		body := elem
+		if ctx.ArgCount() > 1 {
+			body = ctx.ArgType(ctx.ArgCount() - 1)
+			if predicate {
+				ctx.ExpectArg(ctx.ArgCount()-1, TypeBool)
+			}
+		}
+		return result(elem, body)
+	}
+}
+
+var quantifierSignature = collectionSignature(false, true, func(elem, body Type) Type { return TypeBool })
+
+// signatureQuantifier is the signature of any, all and none, items must be bool if there is no predicate.
+func signatureQuantifier(ctx TypeContext) Type {
+	if ctx.ArgCount() == 1 {
+		ctx.ExpectArg(0, TypeArrayOf(TypeBool))
+		return TypeBool
+	}
+	return quantifierSignature(ctx)
+}
+
+// signatureReduce accepts an array, an initial value and an inline lambda of the accumulator and the item.
+// Result has the type of the initial value, if the lambda body has the same type.
+func signatureReduce(ctx TypeContext) Type {
+	if !ctx.CheckArgCount(5) {
+		return TypeAny
+	}
+	ctx.ExpectArg(0, Type{Kind: TypeKindArray})
+	if initial := ctx.ArgType(1); initial.Equal(ctx.ArgType(4)) {
+		return initial
+	}
+	return TypeAny
+}
+
+// sumType is the type of a sum of numbers or durations.
+func sumType(elem, body Type) Type {
+	switch body.Kind {
+	case TypeKindDuration, TypeKindAny:
+		return body
+	}
+	return TypeNumber
+}
+
+var timePartSignature = OverloadedSignature(map[int]OperatorSignature{
+	1: Signature(TypeNumber, TypeTime),
+	2: Signature(TypeNumber, TypeTime, TypeString),
+})
+
+// signatureComparison accepts two numbers, two times or two durations.
+func signatureComparison(ctx TypeContext) Type {
+	if !ctx.CheckArgCount(2) {
+		return TypeBool
+	}
+	operand := TypeNumber
+	for idx := 0; idx < 2; idx++ {
+		if kind := ctx.ArgType(idx).Kind; kind == TypeKindTime || kind == TypeKindDuration {
+			operand = ctx.ArgType(idx)
+			break
+		}
+	}
+	ctx.ExpectArg(0, operand)
+	ctx.ExpectArg(1, operand)
+	return TypeBool
+}
+
+// signatureSum accepts two numbers, two durations, or a time and a duration.
+func signatureSum(ctx TypeContext) Type {
+	if !ctx.CheckArgCount(2) {
+		return TypeNumber
+	}
+	left, right := ctx.ArgType(0), ctx.ArgType(1)
+	switch {
+	case left.Kind == TypeKindTime:
+		ctx.ExpectArg(1, TypeDuration)
+		return TypeTime
+	case right.Kind == TypeKindTime:
+		ctx.ExpectArg(0, TypeDuration)
+		return TypeTime
+	case left.Kind == TypeKindDuration || right.Kind == TypeKindDuration:
+		if left.Kind == TypeKindAny || right.Kind == TypeKindAny {
+			// the other one can be a time too
+			return TypeAny
+		}
+		ctx.ExpectArg(0, TypeDuration)
+		ctx.ExpectArg(1, TypeDuration)
+		return TypeDuration
+	}
+	ctx.ExpectArg(0, TypeNumber)
+	ctx.ExpectArg(1, TypeNumber)
+	return TypeNumber
+}
+
+// signatureSub accepts two numbers, two times, two durations, or a time and a duration.
+func signatureSub(ctx TypeContext) Type {
+	left, right := ctx.ArgType(0), ctx.ArgType(1)
+	switch {
+	case left.Kind == TypeKindTime && right.Kind == TypeKindTime:
+		return TypeDuration
+	case left.Kind == TypeKindTime:
+		if right.Kind == TypeKindAny {
+			return TypeAny
+		}
+		ctx.ExpectArg(1, TypeDuration)
+		return TypeTime
+	case right.Kind == TypeKindTime:
+		ctx.ExpectArg(0, TypeTime)
+		return TypeDuration
+	case left.Kind == TypeKindDuration || right.Kind == TypeKindDuration:
+		if left.Kind == TypeKindAny {
+			return TypeAny
+		}
+		ctx.ExpectArg(0, TypeDuration)
+		ctx.ExpectArg(1, TypeDuration)
+		return TypeDuration
+	}
+	ctx.ExpectArg(0, TypeNumber)
+	ctx.ExpectArg(1, TypeNumber)
+	return TypeNumber
+}
+
+func signatureNegate(ctx TypeContext) Type {
+	if ctx.ArgType(0).Kind == TypeKindDuration {
+		return TypeDuration
+	}
+	ctx.ExpectArg(0, TypeNumber)
+	return TypeNumber
+}
+
+// signatureTruncate accepts a unit name or a duration, and an optional time zone.
+func signatureTruncate(ctx TypeContext) Type {
+	ctx.ExpectArg(0, TypeTime)
+	if kind := ctx.ArgType(1).Kind; kind != TypeKindString && kind != TypeKindDuration && kind != TypeKindAny {
+		ctx.ArgErrorf(1, "is %v, expected string or duration", ctx.ArgType(1))
+	}
+	if ctx.ArgCount() == 3 {
+		ctx.ExpectArg(2, TypeString)
+	}
+	return TypeTime
+}
+
+func signatureTernaryIf(ctx TypeContext) Type {
+	if !ctx.CheckArgCount(3) {
+		return TypeAny
+	}
+	ctx.ExpectArg(0, TypeBool)
+	return unifyTypes(ctx.ArgType(1), ctx.ArgType(2))
+}
+
+func signatureCoalesce(ctx TypeContext) Type {
+	if !ctx.CheckArgCount(2) {
+		return TypeAny
+	}
+	if ctx.ArgType(0).Kind == TypeKindNil {
+		return ctx.ArgType(1)
+	}
+	return unifyTypes(ctx.ArgType(0), ctx.ArgType(1))
+}
+
+func signatureArray(ctx TypeContext) Type {
+	if ctx.ArgCount() == 0 {
+		return Type{Kind: TypeKindArray}
+	}
+	elem := ctx.ArgType(0)
+	for idx := 1; idx < ctx.ArgCount(); idx++ {
+		elem = unifyTypes(elem, ctx.ArgType(idx))
+	}
+	return TypeArrayOf(elem)
+}
+
+func signatureObject(ctx TypeContext) Type {
+	if ctx.ArgCount()%2 != 0 {
+		ctx.Errorf("wrong number of arguments: %d, expected key-value pairs", ctx.ArgCount())
+		return TypeObject
+	}
+	for idx := 0; idx < ctx.ArgCount(); idx += 2 {
+		ctx.ExpectArg(idx, TypeString)
+	}
+	return TypeObject
+}
+
+func signatureContains(ctx TypeContext) Type {
+	if !ctx.CheckArgCount(2) {
+		return TypeBool
+	}
+	if ctx.ExpectArg(1, Type{Kind: TypeKindArray}) {
+		ctx.ExpectArg(0, ctx.ArgType(1).elem())
+	}
+	return TypeBool
+}
+
+func signatureIndexer(ctx TypeContext) Type {
+	if !ctx.CheckArgCount(2) {
+		return TypeAny
+	}
+	switch receiver := ctx.ArgType(0); receiver.Kind {
+	case TypeKindArray:
+		ctx.ExpectArg(1, TypeNumber)
+		return receiver.elem()
+	case TypeKindObject:
+		ctx.ExpectArg(1, TypeString)
+		return TypeAny
+	case TypeKindAny:
+		return TypeAny
+	}
+	ctx.ArgErrorf(0, "is %v, expected array or object", ctx.ArgType(0))
+	return TypeAny
+}
+
+func signatureLen(ctx TypeContext) Type {
+	if !ctx.CheckArgCount(1) {
+		return TypeNumber
+	}
+	switch ctx.ArgType(0).Kind {
+	case TypeKindString, TypeKindArray, TypeKindObject, TypeKindAny:
+		return TypeNumber
+	}
+	ctx.ArgErrorf(0, "is %v, expected string, array or object", ctx.ArgType(0))
+	return TypeNumber
+}
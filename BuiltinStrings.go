@@ -0,0 +1,224 @@
+package govaluate
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+func builtinLen(ctx EvalContext) (interface{}, error) {
+	if err := ctx.CheckArgCount(1); err != nil {
+		return nil, err
+	}
+	arg, err := ctx.Arg(0)
+	if err != nil {
+		return nil, err
+	}
+	switch v := arg.(type) {
+	case string:
+		return float64(utf8.RuneCountInString(v)), nil
+	case []interface{}:
+		return float64(len(v)), nil
+	case map[string]interface{}:
+		return float64(len(v)), nil
+	}
+	return nil, formatArgError(ctx.expr, 0, "has no length: %v", arg)
+}
+
+func builtinLower(ctx EvalContext) (interface{}, error) {
+	arg, err := unaryStringArg(ctx)
+	return strings.ToLower(arg), err
+}
+
+func builtinUpper(ctx EvalContext) (interface{}, error) {
+	arg, err := unaryStringArg(ctx)
+	return strings.ToUpper(arg), err
+}
+
+func builtinTrim(ctx EvalContext) (interface{}, error) {
+	if ctx.ArgCount() == 2 {
+		// trim(s, cutset)
+		str, cutset, err := binaryStringArgs(ctx)
+		return strings.Trim(str, cutset), err
+	}
+	arg, err := unaryStringArg(ctx)
+	return strings.TrimSpace(arg), err
+}
+
+func builtinStringContains(ctx EvalContext) (interface{}, error) {
+	str, substr, err := binaryStringArgs(ctx)
+	return strings.Contains(str, substr), err
+}
+
+func builtinStartsWith(ctx EvalContext) (interface{}, error) {
+	str, prefix, err := binaryStringArgs(ctx)
+	return strings.HasPrefix(str, prefix), err
+}
+
+func builtinEndsWith(ctx EvalContext) (interface{}, error) {
+	str, suffix, err := binaryStringArgs(ctx)
+	return strings.HasSuffix(str, suffix), err
+}
+
+// builtinSubstr returns a part of string: substr(s, start[, length]).
+// Start and length are counted in characters (runes), not bytes.
+func builtinSubstr(ctx EvalContext) (interface{}, error) {
+	if ctx.ArgCount() != 2 && ctx.ArgCount() != 3 {
+		return nil, ctx.FormatError("wrong number of arguments: %d, expected: 2 or 3", ctx.ArgCount())
+	}
+	str, err := ctx.StringArg(0)
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(str)
+	start, err := ctx.IntegerArg(1)
+	if err != nil {
+		return nil, err
+	}
+	if start < 0 || start > len(runes) {
+		return nil, formatArgError(ctx.expr, 1, "is out of range: %d, len: %d", start, len(runes))
+	}
+	end := len(runes)
+	if ctx.ArgCount() == 3 {
+		length, err := ctx.IntegerArg(2)
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, formatArgError(ctx.expr, 2, "is negative: %d", length)
+		}
+		if start+length < end {
+			end = start + length
+		}
+	}
+	return string(runes[start:end]), nil
+}
+
+func builtinReplace(ctx EvalContext) (interface{}, error) {
+	if err := ctx.CheckArgCount(3); err != nil {
+		return nil, err
+	}
+	str, err := ctx.StringArg(0)
+	if err != nil {
+		return nil, err
+	}
+	old, err := ctx.StringArg(1)
+	if err != nil {
+		return nil, err
+	}
+	new, err := ctx.StringArg(2)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Replace(str, old, new, -1), nil
+}
+
+func builtinSplit(ctx EvalContext) (interface{}, error) {
+	str, sep, err := binaryStringArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(str, sep)
+	items := make([]interface{}, len(parts))
+	for i, part := range parts {
+		items[i] = part
+	}
+	return items, nil
+}
+
+func builtinJoin(ctx EvalContext) (interface{}, error) {
+	if err := ctx.CheckArgCount(2); err != nil {
+		return nil, err
+	}
+	items, err := ctx.SliceArg(0)
+	if err != nil {
+		return nil, err
+	}
+	sep, err := ctx.StringArg(1)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		part, ok := item.(string)
+		if !ok {
+			return nil, formatArgError(ctx.expr, 0, "item #%d is not string: %v", i+1, item)
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// builtinFormat formats arguments with fmt.Sprintf: format("%s: %v", name, value).
+// Note that all numbers are float64, so %v or %g should be used instead of %d.
+func builtinFormat(ctx EvalContext) (interface{}, error) {
+	return formatArgs(ctx, nil)
+}
+
+// formatArgs formats arguments with fmt.Sprintf, converting them first with convert, unless it's nil.
+func formatArgs(ctx EvalContext, convert func(interface{}) interface{}) (interface{}, error) {
+	if ctx.ArgCount() < 1 {
+		return nil, ctx.FormatError("wrong number of arguments: %d, expected at least: 1", ctx.ArgCount())
+	}
+	format, err := ctx.StringArg(0)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]interface{}, ctx.ArgCount()-1)
+	for i := range args {
+		args[i], err = ctx.Arg(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		if convert != nil {
+			args[i] = convert(args[i])
+		}
+	}
+	return fmt.Sprintf(format, args...), nil
+}
+
+func builtinRepeat(ctx EvalContext) (interface{}, error) {
+	if err := ctx.CheckArgCount(2); err != nil {
+		return nil, err
+	}
+	str, err := ctx.StringArg(0)
+	if err != nil {
+		return nil, err
+	}
+	count, err := ctx.IntegerArg(1)
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 {
+		return nil, formatArgError(ctx.expr, 1, "is negative: %d", count)
+	}
+	// the length is checked by division, so that it can't overflow
+	limit := ctx.params.Limits.maxStringLength()
+	if len(str) > 0 && count > limit/len(str) {
+		cause := BudgetExceededError{Limit: "string length", Max: limit}
+		return nil, wrapArgError(ctx.expr, 1, cause, "is too large: %d, string length limit: %d", count, limit)
+	}
+	return strings.Repeat(str, count), nil
+}
+
+func unaryStringArg(ctx EvalContext) (string, error) {
+	if err := ctx.CheckArgCount(1); err != nil {
+		return "", err
+	}
+	return ctx.StringArg(0)
+}
+
+func binaryStringArgs(ctx EvalContext) (string, string, error) {
+	if err := ctx.CheckArgCount(2); err != nil {
+		return "", "", err
+	}
+	left, err := ctx.StringArg(0)
+	if err != nil {
+		return "", "", err
+	}
+	right, err := ctx.StringArg(1)
+	if err != nil {
+		return "", "", err
+	}
+	return left, right, nil
+}
@@ -0,0 +1,62 @@
+package govaluate
+
+import (
+	"time"
+)
+
+// Clock tells the current time to time-aware operators, like now().
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock, it's used when no clock is set.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock always tells the same time, so evaluation of time-aware expressions is deterministic, e.g. in tests.
+// Use a pointer to change the time between evaluations.
+type FixedClock struct {
+	Time time.Time
+}
+
+func (c FixedClock) Now() time.Time {
+	return c.Time
+}
+
+// clockRecorder notes whether the time was asked for, so Reduce doesn't fold clock-dependent operators into constants.
+type clockRecorder struct {
+	clock Clock
+	used  bool
+}
+
+func (r *clockRecorder) Now() time.Time {
+	r.used = true
+	return r.clock.Now()
+}
+
+// clockOrDefault returns the clock, or SystemClock if it's nil.
+func clockOrDefault(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock
+	}
+	return clock
+}
+
+// clockParameters passes the clock of EvaluableExpression to function stages.
+type clockParameters struct {
+	Parameters
+	clock Clock
+}
+
+// parametersClock returns the clock of legacy evaluation.
+func parametersClock(parameters Parameters) Clock {
+	if p, ok := parameters.(clockParameters); ok {
+		return p.clock
+	}
+	return SystemClock
+}
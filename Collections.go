@@ -0,0 +1,354 @@
+package govaluate
+
+import (
+	"math/big"
+	"sort"
+	"time"
+)
+
+// collectionArgs returns the array argument and the lambda after it, nil if there is none.
+// The lambda is either a value, like filter(items, x => x > 1), or inline, like filter(items, x, x > 1).
+func collectionArgs(ctx EvalContext, lambdaRequired bool) ([]interface{}, *Lambda, error) {
+	minArgs := 1
+	if lambdaRequired {
+		minArgs = 2
+	}
+	if ctx.ArgCount() < minArgs || ctx.ArgCount() > 3 {
+		return nil, nil, ctx.FormatError("wrong number of arguments: %d, expected: %d to 3", ctx.ArgCount(), minArgs)
+	}
+	items, err := ctx.SliceArg(0)
+	if err != nil || ctx.ArgCount() == 1 {
+		return items, nil, err
+	}
+	lambda, err := ctx.LambdaArg(1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, &lambda, nil
+}
+
+// mapItem returns the result of lambda for an item, or the item itself if there is no lambda.
+func mapItem(lambda *Lambda, item interface{}) (interface{}, error) {
+	if lambda == nil {
+		return item, nil
+	}
+	return lambda.Call(item)
+}
+
+// testItem returns the result of a predicate for an item, or the item itself if there is no predicate.
+func testItem(ctx EvalContext, lambda *Lambda, item interface{}) (bool, error) {
+	result, err := mapItem(lambda, item)
+	if err != nil {
+		return false, err
+	}
+	if boolVal, ok := result.(bool); ok {
+		return boolVal, nil
+	}
+	if lambda == nil {
+		return false, formatArgError(ctx.expr, 0, "has non-boolean item: %v", result)
+	}
+	return false, formatArgError(ctx.expr, ctx.ArgCount()-1, "returned non-boolean: %v", result)
+}
+
+// applyOperator evaluates an operator with values as arguments, so collection functions
+// work like the operators in use, e.g. sums are exact with IntegerOperators.
+func (ctx EvalContext) applyOperator(name string, values ...interface{}) (interface{}, error) {
+	args := make([]ExprNode, len(values))
+	for idx, value := range values {
+		args[idx] = NewExprNodeLiteral(value, ctx.expr.SourcePos, ctx.expr.SourceLen)
+	}
+	return NewExprNodeOperator(name, args, ctx.expr.SourcePos, ctx.expr.SourceLen, OperatorTypeInfix).Eval(ctx.params)
+}
+
+// anyItem returns true if the predicate returns expected for any item.
+func anyItem(ctx EvalContext, expected bool) (bool, error) {
+	items, lambda, err := collectionArgs(ctx, false)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range items {
+		result, err := testItem(ctx, lambda, item)
+		if err != nil {
+			return false, err
+		}
+		if result == expected {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// builtinAny returns true if the predicate is true for any item: any(items, x => x > 1).
+func builtinAny(ctx EvalContext) (interface{}, error) {
+	return anyItem(ctx, true)
+}
+
+// builtinAll returns true if the predicate is true for all items, including none: all(items, x => x > 1).
+func builtinAll(ctx EvalContext) (interface{}, error) {
+	found, err := anyItem(ctx, false)
+	return !found, err
+}
+
+// builtinNone returns true if the predicate is false for all items: none(items, x => x > 1).
+func builtinNone(ctx EvalContext) (interface{}, error) {
+	found, err := anyItem(ctx, true)
+	return !found, err
+}
+
+// builtinFilter returns items the predicate is true for: filter(items, x => x > 1).
+func builtinFilter(ctx EvalContext) (interface{}, error) {
+	items, lambda, err := collectionArgs(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	result := []interface{}{}
+	for _, item := range items {
+		ok, err := testItem(ctx, lambda, item)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// builtinMap returns results of the lambda for all items: map(items, x => x * 2).
+func builtinMap(ctx EvalContext) (interface{}, error) {
+	items, lambda, err := collectionArgs(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, len(items))
+	for idx, item := range items {
+		if result[idx], err = mapItem(lambda, item); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// builtinCount returns the number of items, or the number of items the predicate is true for: count(items, x => x > 1).
+func builtinCount(ctx EvalContext) (interface{}, error) {
+	items, lambda, err := collectionArgs(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if lambda == nil {
+		return float64(len(items)), nil
+	}
+	count := 0
+	for _, item := range items {
+		ok, err := testItem(ctx, lambda, item)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			count++
+		}
+	}
+	return float64(count), nil
+}
+
+// sumItems adds items, or results of the lambda, with the + operator.
+func sumItems(ctx EvalContext, items []interface{}, lambda *Lambda) (interface{}, error) {
+	var sum interface{}
+	for _, item := range items {
+		value, err := mapItem(lambda, item)
+		if err != nil {
+			return nil, err
+		}
+		if sum == nil {
+			sum = zeroNumber(ctx, value)
+		}
+		if sum, err = ctx.applyOperator("+", sum, value); err != nil {
+			return nil, err
+		}
+	}
+	if sum == nil {
+		return zeroNumber(ctx, nil), nil
+	}
+	return sum, nil
+}
+
+// zeroNumber returns zero of the same type as value, if it's a duration, or zero of the number mode.
+func zeroNumber(ctx EvalContext, value interface{}) interface{} {
+	if _, ok := value.(time.Duration); ok {
+		return time.Duration(0)
+	}
+	switch ctx.numbers {
+	case NumberModeInteger:
+		return int64(0)
+	case NumberModeDecimal:
+		return new(big.Rat)
+	}
+	return 0.0
+}
+
+// builtinSumItems returns the sum of items, or of results of the lambda: sum(items, x => x.price).
+func builtinSumItems(ctx EvalContext) (interface{}, error) {
+	items, lambda, err := collectionArgs(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	return sumItems(ctx, items, lambda)
+}
+
+// builtinAvgItems returns the average of items, or of results of the lambda, nil if there are no items: avg(items, x => x.price).
+func builtinAvgItems(ctx EvalContext) (interface{}, error) {
+	items, lambda, err := collectionArgs(ctx, false)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	sum, err := sumItems(ctx, items, lambda)
+	if err != nil {
+		return nil, err
+	}
+	if duration, ok := sum.(time.Duration); ok {
+		return duration / time.Duration(len(items)), nil
+	}
+	// the count is float64, so integers are not truncated
+	return ctx.applyOperator("/", sum, float64(len(items)))
+}
+
+// builtinSort returns items in ascending order, of the items themselves, or of results of the lambda:
+// sort(items, x => x.price). Strings are compared with each other, other values with the < operator.
+func builtinSort(ctx EvalContext) (interface{}, error) {
+	items, lambda, err := collectionArgs(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]interface{}, len(items))
+	for idx, item := range items {
+		if keys[idx], err = mapItem(lambda, item); err != nil {
+			return nil, err
+		}
+	}
+	order := make([]int, len(items))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		a, b := keys[order[i]], keys[order[j]]
+		if x, ok := a.(string); ok {
+			if y, ok := b.(string); ok {
+				return x < y
+			}
+		}
+		var less interface{}
+		less, err = ctx.applyOperator("<", a, b)
+		return less == true
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, len(items))
+	for idx, itemIdx := range order {
+		result[idx] = items[itemIdx]
+	}
+	return result, nil
+}
+
+// builtinDistinct returns items without duplicates, the first one is kept. Items, or results of the lambda,
+// are compared with the == operator: distinct(items, x => x.id).
+func builtinDistinct(ctx EvalContext) (interface{}, error) {
+	items, lambda, err := collectionArgs(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	result := []interface{}{}
+	var keys []interface{}
+	for _, item := range items {
+		key, err := mapItem(lambda, item)
+		if err != nil {
+			return nil, err
+		}
+		duplicate := false
+		for _, other := range keys {
+			equal, err := ctx.applyOperator("==", other, key)
+			if err != nil {
+				return nil, err
+			}
+			if equal == true {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			keys = append(keys, key)
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// findItem returns the first item the predicate is true for, starting from the end if reverse is set,
+// or nil if there is none.
+func findItem(ctx EvalContext, reverse bool) (interface{}, error) {
+	items, lambda, err := collectionArgs(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range items {
+		if reverse {
+			idx = len(items) - 1 - idx
+		}
+		if lambda == nil {
+			return items[idx], nil
+		}
+		ok, err := testItem(ctx, lambda, items[idx])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return items[idx], nil
+		}
+	}
+	return nil, nil
+}
+
+// builtinFirst returns the first item, or the first item the predicate is true for: first(items, x => x > 1).
+func builtinFirst(ctx EvalContext) (interface{}, error) {
+	return findItem(ctx, false)
+}
+
+// builtinLast returns the last item, or the last item the predicate is true for: last(items, x => x > 1).
+func builtinLast(ctx EvalContext) (interface{}, error) {
+	return findItem(ctx, true)
+}
+
+// builtinReduce folds items into an accumulator, which starts with the initial value, and is replaced by results
+// of an inline lambda of the accumulator and the item: reduce(items, 0, acc, x, acc + x.price).
+func builtinReduce(ctx EvalContext) (interface{}, error) {
+	if err := ctx.CheckArgCount(5); err != nil {
+		return nil, err
+	}
+	items, err := ctx.SliceArg(0)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := ctx.Arg(1)
+	if err != nil {
+		return nil, err
+	}
+	accParam := ctx.expr.Args[2]
+	if accParam.Type != NodeTypeVariable {
+		return nil, formatArgError(ctx.expr, 2, "is not an identifier")
+	}
+	step, err := ctx.lambdaAt(3)
+	if err != nil {
+		return nil, err
+	}
+	params := step.params
+	for _, item := range items {
+		step.params.scope = &scope{name: accParam.Name, value: acc, parent: params.scope}
+		if acc, err = step.Call(item); err != nil {
+			return nil, err
+		}
+	}
+	return acc, nil
+}
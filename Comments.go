@@ -0,0 +1,83 @@
+package govaluate
+
+// Comment is a // line comment or a /* block */ comment in expression source.
+// Parser attaches comments to the nearest nodes, so they are printed back with the expression.
+type Comment struct {
+	// Text is the comment without delimiters.
+	Text string
+
+	// Block is true for /* block */ comments, and false for // line comments.
+	Block bool
+
+	// Trailing is true if the comment follows the node, and false if it precedes it.
+	Trailing bool
+
+	SourcePos, SourceLen int
+}
+
+// sourceComment is a comment read by TokenStream, with positions of the surrounding tokens.
+type sourceComment struct {
+	Comment
+
+	// prevEnd is the end of the previous token, nextPos is the start of the next one
+	prevEnd, nextPos int
+}
+
+// attachComments attaches comments to the nodes of expression.
+// A comment precedes the outermost node starting right after it. Otherwise, it follows the outermost node
+// ending right before it, or the innermost node around it, e.g. in "f(/* nothing */)".
+func attachComments(expr ExprNode, comments []sourceComment) ExprNode {
+	for _, comment := range comments {
+		target, found := findNode(expr, nil, func(node ExprNode) bool {
+			return node.SourcePos == comment.nextPos
+		})
+		if !found {
+			comment.Trailing = true
+			target, found = findNode(expr, nil, func(node ExprNode) bool {
+				return node.SourcePos+node.SourceLen == comment.prevEnd && comment.prevEnd > node.SourcePos
+			})
+		}
+		if !found {
+			target = findInnermostNode(expr, nil, comment.SourcePos)
+			comment.Trailing = target != nil || comment.SourcePos >= expr.SourcePos
+		}
+		expr = attachComment(expr, comment.Comment, target)
+	}
+	return expr
+}
+
+// findNode returns the path to the first node matching the condition, looking up outer nodes first.
+func findNode(expr ExprNode, path []int, condition func(ExprNode) bool) ([]int, bool) {
+	if condition(expr) {
+		return path, true
+	}
+	for idx, arg := range expr.Args {
+		if found, ok := findNode(arg, append(path, idx), condition); ok {
+			return found, true
+		}
+	}
+	return nil, false
+}
+
+// findInnermostNode returns the path to the innermost node containing the position, nil if there is none.
+func findInnermostNode(expr ExprNode, path []int, pos int) []int {
+	for idx, arg := range expr.Args {
+		if arg.SourcePos <= pos && pos < arg.SourcePos+arg.SourceLen {
+			return findInnermostNode(arg, append(path, idx), pos)
+		}
+	}
+	return path
+}
+
+// attachComment adds a comment to the node at path, rebuilding its ancestors.
+func attachComment(expr ExprNode, comment Comment, path []int) ExprNode {
+	if len(path) == 0 {
+		expr.Comments = append(expr.Comments[:len(expr.Comments):len(expr.Comments)], comment)
+		return expr
+	}
+	args := make([]ExprNode, len(expr.Args))
+	copy(args, expr.Args)
+	args[path[0]] = attachComment(args[path[0]], comment, path[1:])
+	expr.Args = args
+	return expr
+}
@@ -0,0 +1,164 @@
+package govaluate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// CompileOptions configures how an expression is compiled into a Program.
+type CompileOptions struct {
+	// Operators are resolved by name at compile time.
+	// If nil, builtin operators are used.
+	Operators map[string]Operator
+
+	// Limits restricts every run of the program, there are no limits by default.
+	Limits EvalLimits
+
+	// Clock tells the time to time-aware operators, SystemClock is used if nil.
+	Clock Clock
+}
+
+// Program is an expression compiled into a tree of closures.
+// Operators are looked up once, at compile time, so running a program
+// skips the per-node map lookups and type switches done by ExprNode.Eval.
+// Program is immutable and can be run concurrently.
+type Program struct {
+	expr      ExprNode
+	operators map[string]Operator
+	limits    EvalLimits
+	clock     Clock
+	root      evalFunc
+}
+
+// evalFunc evaluates a single compiled node.
+type evalFunc func(params EvalParams) (interface{}, error)
+
+// Compile converts an expression to a Program, which can be run many times with different variables.
+func Compile(expr ExprNode, options CompileOptions) (*Program, error) {
+	operators := options.Operators
+	if operators == nil {
+		operators = builtinOperators
+	}
+	root, err := compileNode(expr, operators)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{
+		expr:      expr,
+		operators: operators,
+		limits:    options.Limits,
+		clock:     options.Clock,
+		root:      root,
+	}, nil
+}
+
+// Expr returns the expression the program was compiled from.
+func (p *Program) Expr() ExprNode {
+	return p.expr
+}
+
+// Run evaluates the program with the given variables.
+// Results and errors are the same as of ExprNode.Eval with the same operators.
+func (p *Program) Run(variables map[string]interface{}) (interface{}, error) {
+	if p.limits != (EvalLimits{}) {
+		return p.RunWithContext(context.Background(), variables)
+	}
+	return p.run(nil, variables)
+}
+
+// RunWithContext evaluates the program like Run does, but checks ctx before evaluating each node,
+// see ExprNode.EvalWithContext.
+func (p *Program) RunWithContext(ctx context.Context, variables map[string]interface{}) (interface{}, error) {
+	return p.run(newEvalState(ctx, p.limits), variables)
+}
+
+// RunWithParams evaluates the program like RunWithContext does, with variables and the resolver of params,
+// so variables can be looked up lazily. The program is run with the operators it was compiled with,
+// and with its limits and clock, unless they are set in params.
+func (p *Program) RunWithParams(ctx context.Context, params EvalParams) (interface{}, error) {
+	params.Operators = p.operators
+	if params.Limits == (EvalLimits{}) {
+		params.Limits = p.limits
+	}
+	if params.Clock == nil {
+		params.Clock = p.clock
+	}
+	params.state = newEvalState(ctx, params.Limits)
+	return runCompiled(p.root, params, p.expr)
+}
+
+func (p *Program) run(state *evalState, variables map[string]interface{}) (interface{}, error) {
+	params := EvalParams{
+		Variables: variables,
+		Operators: p.operators,
+		Limits:    p.limits,
+		Clock:     p.clock,
+		state:     state,
+	}
+	return runCompiled(p.root, params, p.expr)
+}
+
+// runCompiled runs a compiled node, checking evaluation state like ExprNode.Eval does.
+func runCompiled(node evalFunc, params EvalParams, expr ExprNode) (interface{}, error) {
+	if params.state != nil {
+		if err := params.state.enter(expr.SourcePos, expr.SourceLen); err != nil {
+			return nil, err
+		}
+		defer params.state.leave()
+	}
+	return node(params)
+}
+
+func compileNode(expr ExprNode, operators map[string]Operator) (evalFunc, error) {
+	switch expr.Type {
+	case NodeTypeLiteral:
+		value := expr.Value
+		return func(EvalParams) (interface{}, error) {
+			return value, nil
+		}, nil
+
+	case NodeTypeVariable:
+		return expr.evalVariable, nil
+
+	case NodeTypeOperator:
+		operator, ok := operators[expr.Name]
+		if !ok {
+			// report at run time, like Eval does, so unused branches
+			// with unknown operators (e.g. false && f()) still work
+			err := undefinedOperatorError(expr)
+			return func(EvalParams) (interface{}, error) {
+				return nil, err
+			}, nil
+		}
+		if isRegexOperator(operator) {
+			// literal patterns are compiled once, like Reduce does
+			expr = precompileRegexp(expr)
+		}
+		args := make([]evalFunc, len(expr.Args))
+		for idx, arg := range expr.Args {
+			compiledArg, err := compileNode(arg, operators)
+			if err != nil {
+				return nil, err
+			}
+			args[idx] = compiledArg
+		}
+		return func(params EvalParams) (interface{}, error) {
+			return operator(EvalContext{params: params, expr: expr, args: args})
+		}, nil
+
+	case NodeTypeError:
+		// like undefined operators, so unused invalid branches still work
+		err := expr.Value.(ParseError)
+		return func(EvalParams) (interface{}, error) {
+			return nil, err
+		}, nil
+	}
+	return nil, fmt.Errorf("bad expr type: %v", expr)
+}
+
+// isRegexOperator returns true for the builtin =~ and !~ operators, also if they are mapped to other names.
+func isRegexOperator(operator Operator) bool {
+	pointer := reflect.ValueOf(operator).Pointer()
+	return pointer == reflect.ValueOf(builtinRegexMatch).Pointer() || pointer == reflect.ValueOf(builtinRegexNotMatch).Pointer()
+}
@@ -0,0 +1,565 @@
+package govaluate
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxDecimalExponent limits integer exponents of ** in decimal mode, so a small expression can't take forever.
+const maxDecimalExponent = 1 << 12
+
+// DecimalOperators returns builtin operators for NumberModeDecimal, which do exact decimal arithmetic with *big.Rat.
+// Results of +, -, * and % are exact, results of / and of ** with a negative or fractional exponent are rounded
+// to scale fractional digits. round(x) rounds to an integer, and round(x, digits) to the given number of
+// fractional digits, both using the rounding mode; floor and ceil round to an integer.
+// Numbers of all Go types, and *big.Int, *big.Float and *big.Rat values are converted to *big.Rat.
+// float64 values are converted by their shortest decimal representation, so 0.1 is exactly 1/10.
+// Other numeric functions, like sqrt or sin, are evaluated with float64 numbers.
+// len, count and functions returning parts of time, like year, return *big.Rat,
+// and format prints decimals with their fractional digits, e.g. 1.5 instead of 3/2.
+func DecimalOperators(scale int, rounding big.RoundingMode) map[string]Operator {
+	d := decimalOperators{scale: scale, rounding: rounding}
+	operators := BuiltinOperators()
+	for _, name := range wholeNumberOperators {
+		operators[name] = decimalResult(operators[name])
+	}
+	overrides := map[string]Operator{
+		"==": decimalEq,
+		"!=": decimalNeq,
+		"<":  decimalLt,
+		"<=": decimalLte,
+		">":  decimalGt,
+		">=": decimalGte,
+
+		"+":  decimalSum,
+		"-":  decimalMinus,
+		"*":  decimalMul,
+		"/":  d.div,
+		"%":  decimalMod,
+		"**": d.pow,
+
+		"in": decimalContains,
+
+		"round": d.round,
+		"floor": decimalRounding(big.ToNegativeInf),
+		"ceil":  decimalRounding(big.ToPositiveInf),
+		"abs":   decimalAbs,
+		"min":   decimalMin,
+		"max":   decimalMax,
+
+		"format": d.format,
+	}
+	for name, operator := range overrides {
+		operators[name] = operator
+	}
+	for name, operator := range operators {
+		operators[name] = DecimalOperator(operator)
+	}
+	return operators
+}
+
+// DecimalOperator makes EvalContext.Arg of the operator return numbers as *big.Rat.
+// It can be used to add custom operators to DecimalOperators.
+func DecimalOperator(operator Operator) Operator {
+	return func(ctx EvalContext) (interface{}, error) {
+		ctx.numbers = NumberModeDecimal
+		return operator(ctx)
+	}
+}
+
+type decimalOperators struct {
+	scale    int
+	rounding big.RoundingMode
+}
+
+// decimalValue converts a number to *big.Rat, false is returned for other values, and for NaN and infinities.
+func decimalValue(value interface{}) (*big.Rat, bool) {
+	if v, ok := integerValue(value); ok {
+		return new(big.Rat).SetInt64(v), true
+	}
+	switch v := value.(type) {
+	case *big.Rat:
+		return v, true
+	case *big.Int:
+		return new(big.Rat).SetInt(v), true
+	case *big.Float:
+		if v.IsInf() {
+			return nil, false
+		}
+		r, _ := v.Rat(nil)
+		return r, true
+	case uint:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint64:
+		return new(big.Rat).SetUint64(v), true
+	case float32:
+		return decimalFromFloat(float64(v), 32)
+	case float64:
+		return decimalFromFloat(v, 64)
+	}
+	return nil, false
+}
+
+func decimalFromFloat(value float64, bitSize int) (*big.Rat, bool) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(strconv.FormatFloat(value, 'g', -1, bitSize))
+}
+
+// decimalLiteral converts a number token text to *big.Rat.
+func decimalLiteral(text string, value float64) interface{} {
+	if r, ok := new(big.Rat).SetString(text); ok {
+		return r
+	}
+	return value
+}
+
+// decimalString formats a decimal with all its fractional digits, false is returned
+// if the number has infinite decimal representation, like 1/3.
+func decimalString(value *big.Rat) (string, bool) {
+	denom := new(big.Int).Set(value.Denom())
+	digits := 0
+	ten, two, five := big.NewInt(10), big.NewInt(2), big.NewInt(5)
+	remainder := new(big.Int)
+	for denom.Cmp(big.NewInt(1)) != 0 {
+		switch {
+		case remainder.Rem(denom, ten).Sign() == 0:
+			denom.Quo(denom, ten)
+		case remainder.Rem(denom, two).Sign() == 0:
+			denom.Quo(denom, two)
+		case remainder.Rem(denom, five).Sign() == 0:
+			denom.Quo(denom, five)
+		default:
+			return "", false
+		}
+		digits++
+	}
+	return value.FloatString(digits), true
+}
+
+// roundDecimal rounds a number to the given number of fractional digits.
+func roundDecimal(value *big.Rat, scale int, rounding big.RoundingMode) *big.Rat {
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(factor))
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if remainder.Sign() != 0 {
+		sign := scaled.Sign()
+		// compare the dropped fraction with a half
+		half := new(big.Int).Abs(remainder)
+		half.Lsh(half, 1)
+		cmp := half.Cmp(scaled.Denom())
+		var away bool
+		switch rounding {
+		case big.ToNearestEven:
+			away = cmp > 0 || cmp == 0 && quotient.Bit(0) == 1
+		case big.ToNearestAway:
+			away = cmp >= 0
+		case big.AwayFromZero:
+			away = true
+		case big.ToNegativeInf:
+			away = sign < 0
+		case big.ToPositiveInf:
+			away = sign > 0
+		}
+		if away {
+			quotient.Add(quotient, big.NewInt(int64(sign)))
+		}
+	}
+	return new(big.Rat).SetFrac(quotient, factor)
+}
+
+// binaryDecimalArgs returns both arguments as *big.Rat.
+func binaryDecimalArgs(ctx EvalContext) (*big.Rat, *big.Rat, error) {
+	left, right, err := binaryArgs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return decimalValues(ctx, left, right)
+}
+
+// decimalValues converts evaluated arguments like binaryDecimalArgs does.
+func decimalValues(ctx EvalContext, left interface{}, right interface{}) (*big.Rat, *big.Rat, error) {
+	a, err := decimalArg(ctx, 0, left)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := decimalArg(ctx, 1, right)
+	return a, b, err
+}
+
+func unaryDecimalArg(ctx EvalContext) (*big.Rat, error) {
+	if err := ctx.CheckArgCount(1); err != nil {
+		return nil, err
+	}
+	arg, err := ctx.Arg(0)
+	if err != nil {
+		return nil, err
+	}
+	return decimalArg(ctx, 0, arg)
+}
+
+func decimalArg(ctx EvalContext, idx int, value interface{}) (*big.Rat, error) {
+	if v, ok := value.(*big.Rat); ok {
+		return v, nil
+	}
+	return nil, formatArgError(ctx.expr, idx, "is not numeric: %v", value)
+}
+
+// decimalsEqual compares values like ==, but numbers are compared by value.
+func decimalsEqual(a, b interface{}) bool {
+	if equal, ok := equalCollections(a, b, decimalsEqual); ok {
+		return equal
+	}
+	x, ok := decimalValue(a)
+	if !ok {
+		return equalValues(a, b)
+	}
+	y, ok := decimalValue(b)
+	return ok && x.Cmp(y) == 0
+}
+
+func decimalEq(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryArgs(ctx)
+	return decimalsEqual(a, b), err
+}
+
+func decimalNeq(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryArgs(ctx)
+	return !decimalsEqual(a, b), err
+}
+
+func decimalLt(ctx EvalContext) (interface{}, error) {
+	order, err := decimalCompare(ctx)
+	return err == nil && order < 0, err
+}
+
+func decimalLte(ctx EvalContext) (interface{}, error) {
+	order, err := decimalCompare(ctx)
+	return err == nil && order <= 0, err
+}
+
+func decimalGt(ctx EvalContext) (interface{}, error) {
+	order, err := decimalCompare(ctx)
+	return err == nil && order > 0, err
+}
+
+func decimalGte(ctx EvalContext) (interface{}, error) {
+	order, err := decimalCompare(ctx)
+	return err == nil && order >= 0, err
+}
+
+// decimalCompare returns -1, 0 or 1, comparing numbers, times or durations.
+func decimalCompare(ctx EvalContext) (int, error) {
+	left, right, err := binaryArgs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if order, ok := compareTimes(left, right); ok {
+		return order, nil
+	}
+	a, b, err := decimalValues(ctx, left, right)
+	if err != nil {
+		return 0, err
+	}
+	return a.Cmp(b), nil
+}
+
+func decimalSum(ctx EvalContext) (interface{}, error) {
+	left, right, err := binaryArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if sum, ok := timeSum(left, right); ok {
+		return sum, nil
+	}
+	a, b, err := decimalValues(ctx, left, right)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Add(a, b), nil
+}
+
+func decimalMinus(ctx EvalContext) (interface{}, error) {
+	if ctx.ArgCount() != 1 {
+		return decimalSub(ctx)
+	}
+	arg, err := ctx.Arg(0)
+	if err != nil {
+		return nil, err
+	}
+	if duration, ok := arg.(time.Duration); ok {
+		return -duration, nil
+	}
+	value, err := decimalArg(ctx, 0, arg)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Neg(value), nil
+}
+
+func decimalSub(ctx EvalContext) (interface{}, error) {
+	left, right, err := binaryArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if difference, ok := timeDifference(left, right); ok {
+		return difference, nil
+	}
+	a, b, err := decimalValues(ctx, left, right)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Sub(a, b), nil
+}
+
+func decimalMul(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryDecimalArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Mul(a, b), nil
+}
+
+func (d decimalOperators) div(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryDecimalArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if b.Sign() == 0 {
+		return nil, ctx.FormatError("division by zero")
+	}
+	return roundDecimal(new(big.Rat).Quo(a, b), d.scale, d.rounding), nil
+}
+
+// decimalMod returns the remainder of truncated division, with the sign of the dividend like in Go.
+func decimalMod(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryDecimalArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if b.Sign() == 0 {
+		return nil, ctx.FormatError("division by zero")
+	}
+	quotient := roundDecimal(new(big.Rat).Quo(a, b), 0, big.ToZero)
+	return new(big.Rat).Sub(a, quotient.Mul(quotient, b)), nil
+}
+
+func (d decimalOperators) pow(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryDecimalArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !b.IsInt() {
+		// fractional exponent, there is no exact result
+		x, _ := a.Float64()
+		y, _ := b.Float64()
+		result, ok := decimalFromFloat(math.Pow(x, y), 64)
+		if !ok {
+			return nil, ctx.FormatError("result is not a number: %s ** %s", formatDecimal(a), formatDecimal(b))
+		}
+		return roundDecimal(result, d.scale, d.rounding), nil
+	}
+	exp := b.Num()
+	if !exp.IsInt64() || exp.Int64() > maxDecimalExponent || exp.Int64() < -maxDecimalExponent {
+		return nil, formatArgError(ctx.expr, 1, "is too large: %v", exp)
+	}
+	num := new(big.Int).Exp(a.Num(), new(big.Int).Abs(exp), nil)
+	denom := new(big.Int).Exp(a.Denom(), new(big.Int).Abs(exp), nil)
+	if exp.Sign() >= 0 {
+		return new(big.Rat).SetFrac(num, denom), nil
+	}
+	if num.Sign() == 0 {
+		return nil, ctx.FormatError("division by zero")
+	}
+	return roundDecimal(new(big.Rat).SetFrac(denom, num), d.scale, d.rounding), nil
+}
+
+func decimalContains(ctx EvalContext) (interface{}, error) {
+	if err := ctx.CheckArgCount(2); err != nil {
+		return nil, err
+	}
+	item, err := ctx.Arg(0)
+	if err != nil {
+		return nil, err
+	}
+	slice, err := ctx.SliceArg(1)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range slice {
+		if decimalsEqual(item, v) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (d decimalOperators) round(ctx EvalContext) (interface{}, error) {
+	if ctx.ArgCount() != 2 {
+		arg, err := unaryDecimalArg(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return roundDecimal(arg, 0, d.rounding), nil
+	}
+	arg, digits, err := binaryArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	value, err := decimalArg(ctx, 0, arg)
+	if err != nil {
+		return nil, err
+	}
+	scale, ok := digits.(*big.Rat)
+	if !ok || !scale.IsInt() || scale.Sign() < 0 || !scale.Num().IsInt64() || scale.Num().Int64() > maxDecimalExponent {
+		return nil, formatArgError(ctx.expr, 1, "is not a valid number of digits: %v", digits)
+	}
+	return roundDecimal(value, int(scale.Num().Int64()), d.rounding), nil
+}
+
+// decimalRounding rounds to an integer in the given direction.
+func decimalRounding(rounding big.RoundingMode) Operator {
+	return func(ctx EvalContext) (interface{}, error) {
+		arg, err := unaryDecimalArg(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return roundDecimal(arg, 0, rounding), nil
+	}
+}
+
+func decimalAbs(ctx EvalContext) (interface{}, error) {
+	arg, err := unaryDecimalArg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Abs(arg), nil
+}
+
+func decimalMin(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryDecimalArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if b.Cmp(a) < 0 {
+		return b, nil
+	}
+	return a, nil
+}
+
+func decimalMax(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryDecimalArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if b.Cmp(a) > 0 {
+		return b, nil
+	}
+	return a, nil
+}
+
+// formatDecimal formats a decimal for error messages and printing, fractions with infinite
+// decimal representation are printed as a/b.
+func formatDecimal(value *big.Rat) string {
+	if text, ok := decimalString(value); ok {
+		return text
+	}
+	return strings.TrimSuffix(value.RatString(), "/1")
+}
+
+// decimalResult converts the float64 result of an operator, which is always a whole number, to *big.Rat.
+func decimalResult(operator Operator) Operator {
+	return func(ctx EvalContext) (interface{}, error) {
+		result, err := operator(ctx)
+		if v, ok := result.(float64); ok && err == nil {
+			return new(big.Rat).SetInt64(int64(v)), nil
+		}
+		return result, err
+	}
+}
+
+// format is like builtinFormat, but decimals are printed with their fractional digits.
+func (d decimalOperators) format(ctx EvalContext) (interface{}, error) {
+	return formatArgs(ctx, d.formatted)
+}
+
+// formatted wraps decimals, including items of arrays and objects, with formattedDecimal.
+func (d decimalOperators) formatted(arg interface{}) interface{} {
+	switch v := arg.(type) {
+	case *big.Rat:
+		return formattedDecimal{value: v, scale: d.scale}
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for idx, item := range v {
+			items[idx] = d.formatted(item)
+		}
+		return items
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[key] = d.formatted(item)
+		}
+		return object
+	}
+	return arg
+}
+
+// formattedDecimal prints a decimal with fmt: %v and %s print all fractional digits, or scale digits
+// if there are infinitely many, %f prints the given precision exactly, %d prints whole numbers,
+// and other verbs print the number as float64.
+type formattedDecimal struct {
+	value *big.Rat
+	scale int
+}
+
+func (d formattedDecimal) String() string {
+	if text, ok := decimalString(d.value); ok {
+		return text
+	}
+	return d.value.FloatString(d.scale)
+}
+
+func (d formattedDecimal) Format(state fmt.State, verb rune) {
+	switch {
+	case verb == 'v' || verb == 's':
+		fmt.Fprintf(state, formatDirective(state, 's'), d.String())
+	case verb == 'f' || verb == 'F':
+		precision, ok := state.Precision()
+		if !ok {
+			precision = 6
+		}
+		text := d.value.FloatString(precision)
+		if state.Flag('+') && d.value.Sign() >= 0 {
+			text = "+" + text
+		}
+		fmt.Fprintf(state, formatDirective(state, 's'), text)
+	case verb == 'd' && d.value.IsInt():
+		fmt.Fprintf(state, formatDirective(state, 'd'), d.value.Num())
+	default:
+		value, _ := d.value.Float64()
+		fmt.Fprintf(state, formatDirective(state, verb), value)
+	}
+}
+
+// formatDirective rebuilds the directive being formatted, with flags and width, and precision
+// unless the verb is 's', so it can be applied to another value.
+func formatDirective(state fmt.State, verb rune) string {
+	directive := "%"
+	for _, flag := range "+-# 0" {
+		if state.Flag(int(flag)) {
+			directive += string(flag)
+		}
+	}
+	if width, ok := state.Width(); ok {
+		directive += strconv.Itoa(width)
+	}
+	if precision, ok := state.Precision(); ok && verb != 's' {
+		directive += "." + strconv.Itoa(precision)
+	}
+	return directive + string(verb)
+}
@@ -0,0 +1,213 @@
+package govaluate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError is returned when an expression can not be parsed.
+type ParseError struct {
+	// Message describes the problem, without position.
+	Message string
+
+	// Token is the token that caused the error, it's an EOF token when input ended unexpectedly.
+	Token ExprToken
+
+	// Expected lists what was expected instead of Token, if known.
+	Expected []string
+
+	SourcePos, SourceLen int
+}
+
+func (err ParseError) Error() string {
+	if err.Token.Is(TokenKindEOF, nil) {
+		return err.Message
+	}
+	return fmt.Sprintf("%s, pos: %d", err.Message, err.SourcePos)
+}
+
+// EvalError is returned when an operator fails during evaluation.
+// When the failure is in one of the operator arguments, ArgIndex and ArgName are set,
+// and Cause is the error of the argument.
+type EvalError struct {
+	Message string
+
+	// Operator is the name of failed operator, empty if the error is not related to an operator.
+	Operator string
+
+	// ArgIndex is the index of failed argument, or -1 if the operator itself failed.
+	ArgIndex int
+
+	// ArgName is a human readable argument name, e.g. "lhs of +", "ternary condition".
+	ArgName string
+
+	SourcePos, SourceLen int
+
+	Cause error
+}
+
+func (err EvalError) Error() string {
+	if err.ArgIndex >= 0 && err.Cause != nil {
+		return fmt.Sprintf("%s / %s", err.ArgName, err.Cause.Error())
+	}
+	if err.Operator == "" {
+		return fmt.Sprintf("%s [pos=%d; len=%d]", err.Message, err.SourcePos, err.SourceLen)
+	}
+	return fmt.Sprintf("%s [op=%s; pos=%d; len=%d]", err.Message, err.Operator, err.SourcePos, err.SourceLen)
+}
+
+func (err EvalError) Unwrap() error {
+	return err.Cause
+}
+
+// UndefinedVariableError is returned when an expression refers to a variable that is not defined.
+type UndefinedVariableError struct {
+	Name                 string
+	SourcePos, SourceLen int
+}
+
+func (err UndefinedVariableError) Error() string {
+	return fmt.Sprintf("variable undefined: %v [pos=%d; len=%d]", err.Name, err.SourcePos, err.SourceLen)
+}
+
+// UndefinedOperatorError is returned when an expression uses an operator that is not defined.
+type UndefinedOperatorError struct {
+	Name                 string
+	SourcePos, SourceLen int
+}
+
+func (err UndefinedOperatorError) Error() string {
+	return fmt.Sprintf("operator undefined: %v [pos=%d; len=%d]", err.Name, err.SourcePos, err.SourceLen)
+}
+
+// BudgetExceededError is returned when evaluation exceeds EvalLimits.
+// Position is the position of the node that was about to be evaluated, it's not set by EvaluableExpression.
+type BudgetExceededError struct {
+	// Limit is the name of the exceeded limit, "step", "depth" or "string length".
+	Limit string
+	Max   int
+
+	SourcePos, SourceLen int
+}
+
+func (err BudgetExceededError) Error() string {
+	if err.SourceLen == 0 {
+		return fmt.Sprintf("%s limit exceeded: %d", err.Limit, err.Max)
+	}
+	return fmt.Sprintf("%s limit exceeded: %d [pos=%d; len=%d]", err.Limit, err.Max, err.SourcePos, err.SourceLen)
+}
+
+// ArgTypeError is returned when an operator argument has a wrong type or value.
+// Position is the position of the argument.
+type ArgTypeError struct {
+	// Message describes the problem, e.g. "is not numeric: true".
+	Message string
+
+	Operator string
+	ArgIndex int
+
+	// ArgName is a human readable argument name, e.g. "lhs of +", "ternary condition".
+	ArgName string
+
+	SourcePos, SourceLen int
+
+	// Cause is an underlying error, if any, e.g. an invalid regular expression.
+	Cause error
+}
+
+func (err ArgTypeError) Error() string {
+	return fmt.Sprintf("%s %s [pos=%d; len=%d]", err.ArgName, err.Message, err.SourcePos, err.SourceLen)
+}
+
+func (err ArgTypeError) Unwrap() error {
+	return err.Cause
+}
+
+func unexpectedToken(token ExprToken, expected ...string) error {
+	if token.Is(TokenKindEOF, nil) {
+		return ParseError{
+			Message:   fmt.Sprintf("unexpected eof, expecting %s", strings.Join(expected, ", ")),
+			Token:     token,
+			Expected:  expected,
+			SourcePos: token.SourcePos,
+		}
+	}
+	return ParseError{
+		Message:   fmt.Sprintf("unexpected token %v, expecting %s", token, strings.Join(expected, ", ")),
+		Token:     token,
+		Expected:  expected,
+		SourcePos: token.SourcePos,
+		SourceLen: token.SourceLen,
+	}
+}
+
+func duplicateKey(key ExprToken) error {
+	return ParseError{
+		Message:   fmt.Sprintf("duplicate key: %v", key.Value),
+		Token:     key,
+		Expected:  []string{"key"},
+		SourcePos: key.SourcePos,
+		SourceLen: key.SourceLen,
+	}
+}
+
+func invalidLambdaParam(arrow ExprToken, param ExprNode) error {
+	return ParseError{
+		Message:   "invalid lambda parameter, expecting identifier",
+		Token:     arrow,
+		Expected:  []string{"identifier"},
+		SourcePos: param.SourcePos,
+		SourceLen: param.SourceLen,
+	}
+}
+
+func unmatchedBracket(token ExprToken, bracket rune) error {
+	return ParseError{
+		Message:   fmt.Sprintf("unmatched bracket: '%v', expecting '%v'", string(token.Value.(rune)), string(bracket)),
+		Token:     token,
+		Expected:  []string{"'" + string(bracket) + "'"},
+		SourcePos: token.SourcePos,
+		SourceLen: token.SourceLen,
+	}
+}
+
+func undefinedVariableError(expr ExprNode) error {
+	return UndefinedVariableError{
+		Name:      expr.Name,
+		SourcePos: expr.SourcePos,
+		SourceLen: expr.SourceLen,
+	}
+}
+
+func undefinedOperatorError(expr ExprNode) error {
+	return UndefinedOperatorError{
+		Name:      expr.Name,
+		SourcePos: expr.SourcePos,
+		SourceLen: expr.SourceLen,
+	}
+}
+
+func selfReferenceError(expr ExprNode) error {
+	return EvalError{
+		Message:   fmt.Sprintf("variable can not refer to itself: %v", expr.Name),
+		ArgIndex:  -1,
+		SourcePos: expr.SourcePos,
+		SourceLen: expr.SourceLen,
+	}
+}
+
+func formatArgError(expr ExprNode, idx int, msg string, msgArgs ...interface{}) error {
+	return wrapArgError(expr, idx, nil, msg, msgArgs...)
+}
+
+func wrapArgError(expr ExprNode, idx int, cause error, msg string, msgArgs ...interface{}) error {
+	return ArgTypeError{
+		Message:   fmt.Sprintf(msg, msgArgs...),
+		Operator:  expr.Name,
+		ArgIndex:  idx,
+		ArgName:   formatArgName(expr, idx),
+		SourcePos: expr.Args[idx].SourcePos,
+		SourceLen: expr.Args[idx].SourceLen,
+		Cause:     cause,
+	}
+}
@@ -1,44 +1,91 @@
 package govaluate
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 type EvalParams struct {
 	Variables map[string]interface{}
 	Operators map[string]Operator
+
+	// Resolver is used for variables not found in Variables, optional.
+	Resolver VariableResolver
+
+	// Limits restricts evaluation, there are no limits by default.
+	Limits EvalLimits
+
+	// Clock tells the time to time-aware operators, SystemClock is used if nil.
+	Clock Clock
+
+	// state is set for the evaluation with context or limits
+	state *evalState
+
+	// scope binds parameters of lambdas being evaluated
+	scope *scope
+
+	// lambdaArg is set while a lambda argument is evaluated, see LambdaArg, lambdas are not values otherwise
+	lambdaArg bool
 }
 
 func (expr ExprNode) Eval(params EvalParams) (interface{}, error) {
+	if params.state == nil && params.Limits != (EvalLimits{}) {
+		return expr.EvalWithContext(context.Background(), params)
+	}
+	if params.state != nil {
+		if err := params.state.enter(expr.SourcePos, expr.SourceLen); err != nil {
+			return nil, err
+		}
+		defer params.state.leave()
+	}
 	switch expr.Type {
 	case NodeTypeLiteral:
 		return expr.Value, nil
 	case NodeTypeVariable:
-		value, ok := params.Variables[expr.Name]
-		if !ok {
-			return nil, fmt.Errorf("variable undefined: %v [pos=%d; len=%d]", expr.Name, expr.SourcePos, expr.SourceLen)
-		}
-
-		// Check if var is a node that can be Eval'd
-		node, nodeType := value.(ExprNode)
-		if !nodeType {
-			return value, nil
-		}
-
-		for _, v := range node.Vars() {
-			if v == expr.Name {
-				return nil, fmt.Errorf("variable can not refer to itself: %v [pos=%d; len=%d]", expr.Name, expr.SourcePos, expr.SourceLen)
-			}
-		}
-		return node.Eval(params)
+		return expr.evalVariable(params)
 	case NodeTypeOperator:
 		operator, ok := params.Operators[expr.Name]
 		if !ok {
-			return nil, fmt.Errorf("operator undefined: %v [pos=%d; len=%d]", expr.Name, expr.SourcePos, expr.SourceLen)
+			return nil, undefinedOperatorError(expr)
 		}
 		return operator(EvalContext{params: params, expr: expr})
+	case NodeTypeError:
+		return nil, expr.Value.(ParseError)
 	}
 	return nil, fmt.Errorf("bad expr type: %v", expr)
 }
 
+// EvalWithContext evaluates the expression like Eval does, but checks ctx before evaluating each node,
+// and returns ctx.Err() when it's done. When params.Limits are exceeded, BudgetExceededError is returned.
+// Operators can get ctx from EvalContext.Context.
+func (expr ExprNode) EvalWithContext(ctx context.Context, params EvalParams) (interface{}, error) {
+	params.state = newEvalState(ctx, params.Limits)
+	return expr.Eval(params)
+}
+
+func (expr ExprNode) evalVariable(params EvalParams) (interface{}, error) {
+	value, ok, err := params.resolveVariable(expr)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, undefinedVariableError(expr)
+	}
+
+	// Check if var is a node that can be Eval'd
+	node, nodeType := value.(ExprNode)
+	if !nodeType {
+		return value, nil
+	}
+
+	for _, v := range node.Vars() {
+		if v == expr.Name {
+			return nil, selfReferenceError(expr)
+		}
+	}
+	return node.Eval(params)
+}
+
 var builtinOperators = BuiltinOperators()
 
 func NewEvalParams(variables map[string]interface{}) EvalParams {
@@ -1,12 +1,43 @@
 package govaluate
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+)
 
 type Operator func(ctx EvalContext) (interface{}, error)
 
 type EvalContext struct {
 	params EvalParams
 	expr   ExprNode
+
+	// args are compiled arguments, set when running a Program
+	args []evalFunc
+
+	// numbers is set by IntegerOperator and DecimalOperator, so Arg keeps integers as int64,
+	// or converts numbers to *big.Rat
+	numbers NumberMode
+}
+
+// Context returns the context of evaluation, long running operators should respect it.
+func (ctx EvalContext) Context() context.Context {
+	if ctx.params.state == nil {
+		return context.Background()
+	}
+	return ctx.params.state.ctx
+}
+
+// Now returns the current time of the evaluation clock, see EvalParams.Clock.
+// Time-aware operators should use it instead of time.Now, so they are deterministic with FixedClock,
+// and Reduce doesn't fold them into constants.
+func (ctx EvalContext) Now() time.Time {
+	return ctx.clock().Now()
+}
+
+func (ctx EvalContext) clock() Clock {
+	return clockOrDefault(ctx.params.Clock)
 }
 
 func (ctx EvalContext) ArgCount() int {
@@ -26,11 +57,36 @@ func (ctx EvalContext) Arg(idx int) (interface{}, error) {
 		return nil, ctx.FormatError("requested argument #%d, but argument count is %d", idx+1, len(args))
 	}
 
-	val, err := args[idx].Eval(ctx.params)
+	var val interface{}
+	var err error
+	if ctx.args != nil && ctx.params.state == nil {
+		val, err = ctx.args[idx](ctx.params)
+	} else if ctx.args != nil {
+		val, err = runCompiled(ctx.args[idx], ctx.params, args[idx])
+	} else {
+		val, err = args[idx].Eval(ctx.params)
+	}
 	if err != nil {
-		return val, fmt.Errorf("%s / %s", formatArgName(ctx.expr, idx), err.Error())
+		return val, EvalError{
+			Operator:  ctx.expr.Name,
+			ArgIndex:  idx,
+			ArgName:   formatArgName(ctx.expr, idx),
+			SourcePos: ctx.expr.SourcePos,
+			SourceLen: ctx.expr.SourceLen,
+			Cause:     err,
+		}
 	}
 
+	switch ctx.numbers {
+	case NumberModeInteger:
+		if intVal, ok := integerValue(val); ok {
+			return intVal, nil
+		}
+	case NumberModeDecimal:
+		if decVal, ok := decimalValue(val); ok {
+			return decVal, nil
+		}
+	}
 	switch v := val.(type) {
 	case int:
 		return float64(v), nil
@@ -75,21 +131,64 @@ func (ctx EvalContext) NumericArg(idx int) (float64, error) {
 	if err != nil {
 		return 0.0, err
 	}
+	return numericValue(ctx, idx, val)
+}
 
-	if numVal, ok := val.(float64); ok {
+// numericValue converts an evaluated argument to float64.
+func numericValue(ctx EvalContext, idx int, val interface{}) (float64, error) {
+	switch numVal := val.(type) {
+	case float64:
 		return numVal, nil
+	case int64:
+		return float64(numVal), nil
+	case *big.Rat:
+		floatVal, _ := numVal.Float64()
+		return floatVal, nil
 	}
 
 	return 0.0, formatArgError(ctx.expr, idx, "is not numeric: %v", val)
 }
 
+func (ctx EvalContext) StringArg(idx int) (string, error) {
+	val, err := ctx.Arg(idx)
+	if err != nil {
+		return "", err
+	}
+	if strVal, ok := val.(string); ok {
+		return strVal, nil
+	}
+	return "", formatArgError(ctx.expr, idx, "is not string: %v", val)
+}
+
 func (ctx EvalContext) IntegerArg(idx int) (int, error) {
-	val, err := ctx.NumericArg(idx)
+	val, err := ctx.Arg(idx)
 	if err != nil {
 		return 0, err
 	}
-	intVal := int(val)
-	if float64(intVal) != val {
+	if int64Val, ok := val.(int64); ok {
+		// kept by IntegerOperator
+		intVal := int(int64Val)
+		if int64(intVal) != int64Val {
+			return 0, formatArgError(ctx.expr, idx, "is out of range: %v", val)
+		}
+		return intVal, nil
+	}
+	if decVal, ok := val.(*big.Rat); ok {
+		// kept by DecimalOperator
+		if !decVal.IsInt() {
+			return 0, formatArgError(ctx.expr, idx, "is not integer: %s", formatDecimal(decVal))
+		}
+		if !decVal.Num().IsInt64() || int64(int(decVal.Num().Int64())) != decVal.Num().Int64() {
+			return 0, formatArgError(ctx.expr, idx, "is out of range: %s", formatDecimal(decVal))
+		}
+		return int(decVal.Num().Int64()), nil
+	}
+	numVal, ok := val.(float64)
+	if !ok {
+		return 0, formatArgError(ctx.expr, idx, "is not numeric: %v", val)
+	}
+	intVal := int(numVal)
+	if float64(intVal) != numVal {
 		return 0.0, formatArgError(ctx.expr, idx, "is not integer: %v", val)
 	}
 	return intVal, nil
@@ -106,23 +205,23 @@ func (ctx EvalContext) SliceArg(idx int) ([]interface{}, error) {
 	return []interface{}{}, formatArgError(ctx.expr, idx, "is not array: %v", val)
 }
 
+// FormatError returns an EvalError at the operator position.
 func (ctx EvalContext) FormatError(msg string, msgArgs ...interface{}) error {
-	return fmt.Errorf("%s [op=%s; pos=%d; len=%d]",
-		fmt.Sprintf(msg, msgArgs...),
-		ctx.expr.Name, ctx.expr.SourcePos, ctx.expr.SourceLen,
-	)
-}
-
-func formatArgError(expr ExprNode, idx int, msg string, msgArgs ...interface{}) error {
-	return fmt.Errorf("%s %s [%s]",
-		formatArgName(expr, idx),
-		fmt.Sprintf(msg, msgArgs...),
-		formatDebugInfo(expr.Args[idx]),
-	)
+	return EvalError{
+		Message:   fmt.Sprintf(msg, msgArgs...),
+		Operator:  ctx.expr.Name,
+		ArgIndex:  -1,
+		SourcePos: ctx.expr.SourcePos,
+		SourceLen: ctx.expr.SourceLen,
+	}
 }
 
-func formatDebugInfo(expr ExprNode) string {
-	return fmt.Sprintf("pos=%d; len=%d", expr.SourcePos, expr.SourceLen)
+// WrapError returns an EvalError at the operator position, with the same message as cause.
+// It can be used to report errors from external code, keeping them available for errors.Is and errors.As.
+func (ctx EvalContext) WrapError(cause error) error {
+	err := ctx.FormatError("%v", cause).(EvalError)
+	err.Cause = cause
+	return err
 }
 
 func formatArgName(expr ExprNode, idx int) string {
@@ -153,6 +252,35 @@ func formatArgName(expr ExprNode, idx int) string {
 		} else if idx == 1 {
 			return "index"
 		}
+	case OperatorTypeObject:
+		if idx%2 == 0 {
+			return fmt.Sprintf("object key #%d", idx/2+1)
+		}
+		if key, ok := expr.Args[idx-1].Value.(string); ok && expr.Args[idx-1].Type == NodeTypeLiteral {
+			return fmt.Sprintf("object item %q", key)
+		}
+		return fmt.Sprintf("object value #%d", idx/2+1)
+	case OperatorTypeMember:
+		if idx == 0 {
+			return "member receiver"
+		} else if idx == 1 {
+			return "member name"
+		}
+	case OperatorTypeMethodCall:
+		if idx == 0 {
+			return "method receiver"
+		} else if idx == 1 {
+			return "method name"
+		} else if name, ok := expr.Args[1].Value.(string); ok && expr.Args[1].Type == NodeTypeLiteral {
+			return fmt.Sprintf("argument #%d of %s()", idx-1, name)
+		}
+		return fmt.Sprintf("argument #%d of method", idx-1)
+	case OperatorTypeLambda:
+		if idx == 0 {
+			return "lambda parameter"
+		} else if idx == 1 {
+			return "lambda body"
+		}
 	}
 	return fmt.Sprintf("argument #%d of %s", idx+1, expr.Name)
 }
@@ -1,6 +1,7 @@
 package govaluate
 
 import (
+	"context"
 	"errors"
 	"fmt"
 )
@@ -32,9 +33,23 @@ type EvaluableExpression struct {
 	*/
 	ChecksTypes bool
 
+	/*
+		Restricts evaluation of untrusted expressions, see EvalLimits.
+		There are no limits by default.
+	*/
+	Limits EvalLimits
+
+	/*
+		Tells the time to clock functions, see `NewEvaluableExpressionWithClockFunctions`. `SystemClock` is used if nil.
+		Set it to a `FixedClock` to make evaluation of time-aware expressions deterministic.
+	*/
+	Clock Clock
+
 	tokens           []ExpressionToken
 	evaluationStages *evaluationStage
 	inputExpression  string
+	functions        map[string]ExpressionFunction
+	clockFunctions   map[string]ClockExpressionFunction
 }
 
 /*
@@ -89,14 +104,26 @@ func NewEvaluableExpressionFromTokens(tokens []ExpressionToken) (*EvaluableExpre
 */
 func NewEvaluableExpressionWithFunctions(expression string, functions map[string]ExpressionFunction) (*EvaluableExpression, error) {
 
+	return NewEvaluableExpressionWithClockFunctions(expression, functions, nil)
+}
+
+/*
+	Similar to [NewEvaluableExpressionWithFunctions], except that [clockFunctions] are also available to the expression.
+	Clock functions are given the clock of the evaluation before their arguments, see `EvaluableExpression.Clock`.
+	If a name is used in both maps, the clock function is called.
+*/
+func NewEvaluableExpressionWithClockFunctions(expression string, functions map[string]ExpressionFunction, clockFunctions map[string]ClockExpressionFunction) (*EvaluableExpression, error) {
+
 	var ret *EvaluableExpression
 	var err error
 
 	ret = new(EvaluableExpression)
 	ret.QueryDateFormat = isoDateFormat
 	ret.inputExpression = expression
+	ret.functions = functions
+	ret.clockFunctions = clockFunctions
 
-	ret.tokens, err = parseTokens(expression, functions)
+	ret.tokens, err = parseTokens(expression, functions, clockFunctions)
 	if err != nil {
 		return nil, err
 	}
@@ -150,6 +177,23 @@ func (this EvaluableExpression) Evaluate(parameters map[string]interface{}) (int
 */
 func (this EvaluableExpression) Eval(parameters Parameters) (interface{}, error) {
 
+	if this.Limits != (EvalLimits{}) {
+		return this.EvalWithContext(context.Background(), parameters)
+	}
+	return this.eval(nil, parameters)
+}
+
+/*
+	Same as `Eval`, but checks [ctx] before evaluating each stage, and returns `ctx.Err()` when it's done.
+	If evaluation exceeds `Limits`, a `BudgetExceededError` is returned.
+*/
+func (this EvaluableExpression) EvalWithContext(ctx context.Context, parameters Parameters) (interface{}, error) {
+
+	return this.eval(newEvalState(ctx, this.Limits), parameters)
+}
+
+func (this EvaluableExpression) eval(state *evalState, parameters Parameters) (interface{}, error) {
+
 	if this.evaluationStages == nil {
 		return nil, nil
 	}
@@ -160,16 +204,28 @@ func (this EvaluableExpression) Eval(parameters Parameters) (interface{}, error)
 		parameters = DUMMY_PARAMETERS
 	}
 
-	return this.evaluateStage(this.evaluationStages, parameters)
+	if this.Clock != nil {
+		parameters = clockParameters{parameters, this.Clock}
+	}
+
+	return this.evaluateStage(this.evaluationStages, parameters, state)
 }
 
-func (this EvaluableExpression) evaluateStage(stage *evaluationStage, parameters Parameters) (interface{}, error) {
+func (this EvaluableExpression) evaluateStage(stage *evaluationStage, parameters Parameters, state *evalState) (interface{}, error) {
 
 	var left, right interface{}
 	var err error
 
+	if state != nil {
+		err = state.enter(0, 0)
+		if err != nil {
+			return nil, err
+		}
+		defer state.leave()
+	}
+
 	if stage.leftStage != nil {
-		left, err = this.evaluateStage(stage.leftStage, parameters)
+		left, err = this.evaluateStage(stage.leftStage, parameters, state)
 		if err != nil {
 			return nil, err
 		}
@@ -202,7 +258,7 @@ func (this EvaluableExpression) evaluateStage(stage *evaluationStage, parameters
 	}
 
 	if right != shortCircuitHolder && stage.rightStage != nil {
-		right, err = this.evaluateStage(stage.rightStage, parameters)
+		right, err = this.evaluateStage(stage.rightStage, parameters, state)
 		if err != nil {
 			return nil, err
 		}
@@ -20,6 +20,25 @@ import (
 */
 func (this EvaluableExpression) ToSQLQuery() (string, error) {
 
+	return this.toSQLQuery(nil)
+}
+
+/*
+	Same as `ToSQLQuery`, but string, number, boolean, pattern and time literals are replaced with "?" placeholders,
+	and their values are returned as bind arguments, in order. The result can be passed to `database/sql` as is.
+*/
+func (this EvaluableExpression) ToSQLQueryWithArgs() (string, []interface{}, error) {
+
+	args := []interface{}{}
+	query, err := this.toSQLQuery(&args)
+	if err != nil {
+		return "", nil, err
+	}
+	return query, args, nil
+}
+
+func (this EvaluableExpression) toSQLQuery(args *[]interface{}) (string, error) {
+
 	var stream *tokenStream
 	var transactions *expressionOutputStream
 	var transaction string
@@ -30,7 +49,7 @@ func (this EvaluableExpression) ToSQLQuery() (string, error) {
 
 	for stream.hasNext() {
 
-		transaction, err = this.findNextSQLString(stream, transactions)
+		transaction, err = this.findNextSQLString(stream, transactions, args)
 		if err != nil {
 			return "", err
 		}
@@ -41,13 +60,25 @@ func (this EvaluableExpression) ToSQLQuery() (string, error) {
 	return transactions.createString(" "), nil
 }
 
-func (this EvaluableExpression) findNextSQLString(stream *tokenStream, transactions *expressionOutputStream) (string, error) {
+func (this EvaluableExpression) findNextSQLString(stream *tokenStream, transactions *expressionOutputStream, args *[]interface{}) (string, error) {
 
 	var token ExpressionToken
 	var ret string
 
 	token = stream.next()
 
+	// literals are bound as arguments, if requested
+	if args != nil {
+		switch token.Kind {
+		case STRING, NUMERIC, BOOLEAN, TIME:
+			*args = append(*args, token.Value)
+			return "?", nil
+		case PATTERN:
+			*args = append(*args, token.Value.(*regexp.Regexp).String())
+			return "?", nil
+		}
+	}
+
 	switch token.Kind {
 
 	case STRING:
@@ -101,7 +132,7 @@ func (this EvaluableExpression) findNextSQLString(stream *tokenStream, transacti
 		case COALESCE:
 
 			left := transactions.rollback()
-			right, err := this.findNextSQLString(stream, transactions)
+			right, err := this.findNextSQLString(stream, transactions, args)
 			if err != nil {
 				return "", err
 			}
@@ -119,7 +150,7 @@ func (this EvaluableExpression) findNextSQLString(stream *tokenStream, transacti
 			ret = fmt.Sprintf("NOT")
 		default:
 
-			right, err := this.findNextSQLString(stream, transactions)
+			right, err := this.findNextSQLString(stream, transactions, args)
 			if err != nil {
 				return "", err
 			}
@@ -133,7 +164,7 @@ func (this EvaluableExpression) findNextSQLString(stream *tokenStream, transacti
 		case EXPONENT:
 
 			left := transactions.rollback()
-			right, err := this.findNextSQLString(stream, transactions)
+			right, err := this.findNextSQLString(stream, transactions, args)
 			if err != nil {
 				return "", err
 			}
@@ -142,7 +173,7 @@ func (this EvaluableExpression) findNextSQLString(stream *tokenStream, transacti
 		case MODULUS:
 
 			left := transactions.rollback()
-			right, err := this.findNextSQLString(stream, transactions)
+			right, err := this.findNextSQLString(stream, transactions, args)
 			if err != nil {
 				return "", err
 			}
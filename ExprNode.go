@@ -11,6 +11,9 @@ type ExprNode struct {
 
 	SourcePos, SourceLen int
 	OperatorType         OperatorType
+
+	// Comments are the source comments attached to this node, nil if there are none.
+	Comments []Comment
 }
 
 // ExprNodeType is a type of ExprNode.
@@ -32,6 +35,10 @@ const (
 	// ExprNode.Name is the name of the operation.
 	// ExprNode.Args are the arguments.
 	NodeTypeOperator
+
+	// NodeTypeError is a placeholder for the invalid part of an expression, produced by ParseWithRecovery.
+	// ExprNode.Value contains the ParseError.
+	NodeTypeError
 )
 
 type OperatorType int
@@ -43,6 +50,10 @@ const (
 	OperatorTypeTernary
 	OperatorTypeArray
 	OperatorTypeIndexer
+	OperatorTypeObject
+	OperatorTypeMember
+	OperatorTypeMethodCall
+	OperatorTypeLambda
 )
 
 // NewExprNodeLiteral constructs a literal node.
@@ -77,6 +88,15 @@ func NewExprNodeOperator(name string, args []ExprNode, sourcePos, sourceLen int,
 	}
 }
 
+func newExprNodeError(err ParseError, sourcePos, sourceLen int) ExprNode {
+	return ExprNode{
+		Type:      NodeTypeError,
+		Value:     err,
+		SourcePos: sourcePos,
+		SourceLen: sourceLen,
+	}
+}
+
 // IsOperator returns true if this expression is an operator with matching name.
 func (expr ExprNode) IsOperator(name string) bool {
 	return expr.Type == NodeTypeOperator && expr.Name == name
@@ -99,7 +119,7 @@ func (expr ExprNode) GetValue() (interface{}, bool) {
 // and values are how many times they are referenced.
 func (expr ExprNode) VarsCount() map[string]int {
 	vars := map[string]int{}
-	collectVars(expr, vars)
+	collectVars(expr, nil, vars)
 	return vars
 }
 
@@ -113,13 +133,24 @@ func (expr ExprNode) Vars() []string {
 	return res
 }
 
-func collectVars(expr ExprNode, output map[string]int) {
+// collectVars counts variables, except lambda parameters, which are bound in scope.
+func collectVars(expr ExprNode, bound *scope, output map[string]int) {
 	switch expr.Type {
 	case NodeTypeVariable:
-		output[expr.Name]++
+		if _, _, found := bound.lookup(expr.Name); !found {
+			output[expr.Name]++
+		}
 	case NodeTypeOperator:
-		for _, arg := range expr.Args {
-			collectVars(arg, output)
+		paramIdx, bodyIdx, isLambda := lambdaParams(expr)
+		for idx, arg := range expr.Args {
+			switch {
+			case isLambda && idx >= paramIdx && idx < bodyIdx:
+				// parameters are not references
+			case isLambda && idx == bodyIdx:
+				collectVars(arg, paramScope(expr.Args[paramIdx:bodyIdx], bound), output)
+			default:
+				collectVars(arg, bound, output)
+			}
 		}
 	}
 }
@@ -19,6 +19,7 @@ const (
 	TokenKindString
 	TokenKindOperator
 	TokenKindBracket
+	TokenKindComment
 )
 
 func NewExprToken(kind ExprTokenKind, value interface{}, sourceLen int) ExprToken {
@@ -49,6 +50,8 @@ func (token ExprToken) String() string {
 		return fmt.Sprintf("Operator{%v}", token.Value)
 	case TokenKindBracket:
 		return fmt.Sprintf("Bracket{'%v'}", string(token.Value.(rune)))
+	case TokenKindComment:
+		return fmt.Sprintf("Comment{%v}", token.Value)
 	}
 	return fmt.Sprintf("Unknown{%v, %v}", token.Kind, token.Value)
 }
@@ -0,0 +1,164 @@
+package govaluate
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Filter evaluates the expression for each item of a slice, and returns a slice of the same type
+// with the items for which the expression is true.
+// Items can be structs, pointers to structs, or maps with string keys; exported fields, named like in Go
+// or like in StructParameters, and map keys are available as variables. Variables not found in an item are looked up in params.
+// If params.Operators is nil, builtin operators are used.
+func Filter(expr ExprNode, items interface{}, params EvalParams) (interface{}, error) {
+	slice, err := filterSlice(items)
+	if err != nil {
+		return nil, err
+	}
+	output := reflect.MakeSlice(slice.Type(), 0, 0)
+	err = forEachItem(expr, slice, params, func(idx int, value interface{}) error {
+		match, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("item #%d: expression result is not boolean: %v", idx, value)
+		}
+		if match {
+			output = reflect.Append(output, slice.Index(idx))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Interface(), nil
+}
+
+// Select evaluates the expression for each item of a slice, and returns the results.
+// Items are handled like in Filter.
+func Select(expr ExprNode, items interface{}, params EvalParams) ([]interface{}, error) {
+	slice, err := filterSlice(items)
+	if err != nil {
+		return nil, err
+	}
+	output := make([]interface{}, 0, slice.Len())
+	err = forEachItem(expr, slice, params, func(idx int, value interface{}) error {
+		output = append(output, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func filterSlice(items interface{}) (reflect.Value, error) {
+	slice := reflect.ValueOf(items)
+	if slice.Kind() != reflect.Slice && slice.Kind() != reflect.Array {
+		return reflect.Value{}, fmt.Errorf("items must be a slice: %T", items)
+	}
+	return slice, nil
+}
+
+// forEachItem evaluates the expression for each item, reusing a single resolver,
+// so no variables map is allocated per item.
+func forEachItem(expr ExprNode, slice reflect.Value, params EvalParams, handler func(int, interface{}) error) error {
+	if params.Operators == nil {
+		params.Operators = builtinOperators
+	}
+	resolver := &itemResolver{
+		variables: params.Variables,
+		fallback:  params.Resolver,
+	}
+	params.Variables = nil
+	params.Resolver = resolver
+
+	for idx := 0; idx < slice.Len(); idx++ {
+		if err := resolver.reset(slice.Index(idx)); err != nil {
+			return fmt.Errorf("item #%d: %w", idx, err)
+		}
+		value, err := expr.Eval(params)
+		if err != nil {
+			return fmt.Errorf("item #%d: %w", idx, err)
+		}
+		if err := handler(idx, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// itemResolver resolves variables from fields of the current item, then from shared variables.
+type itemResolver struct {
+	// current item, either object or a struct or map value, with the layout of a struct
+	object map[string]interface{}
+	value  reflect.Value
+	layout *structLayout
+
+	variables map[string]interface{}
+	fallback  VariableResolver
+}
+
+func (r *itemResolver) reset(item reflect.Value) error {
+	r.object, r.value, r.layout = nil, reflect.Value{}, nil
+	for item.Kind() == reflect.Ptr || item.Kind() == reflect.Interface {
+		if item.IsNil() {
+			return fmt.Errorf("item is nil")
+		}
+		item = item.Elem()
+	}
+
+	switch item.Kind() {
+	case reflect.Struct:
+		r.value, r.layout = item, structLayoutOf(item.Type())
+	case reflect.Map:
+		if item.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("map key must be string: %v", item.Type())
+		}
+		if object, ok := item.Interface().(map[string]interface{}); ok {
+			r.object = object
+		} else {
+			r.value = item
+		}
+	default:
+		return fmt.Errorf("item must be a struct or map: %v", item.Type())
+	}
+	return nil
+}
+
+func (r *itemResolver) Resolve(name string) (interface{}, bool, error) {
+	if value, ok := r.resolveItem(name); ok {
+		return value, true, nil
+	}
+	if value, ok := r.variables[name]; ok {
+		return value, true, nil
+	}
+	if r.fallback == nil {
+		return nil, false, nil
+	}
+	return r.fallback.Resolve(name)
+}
+
+func (r *itemResolver) resolveItem(name string) (interface{}, bool) {
+	switch {
+	case r.object != nil:
+		value, ok := r.object[name]
+		return value, ok
+	case r.layout != nil:
+		index, ok := r.layout.member(name)
+		if !ok {
+			return nil, false
+		}
+		value, ok := fieldByIndex(r.value, index)
+		if !ok {
+			return nil, false
+		}
+		return value.Interface(), true
+	case r.value.IsValid():
+		key := reflect.ValueOf(name).Convert(r.value.Type().Key())
+		value := r.value.MapIndex(key)
+		if !value.IsValid() {
+			return nil, false
+		}
+		return value.Interface(), true
+	}
+	return nil, false
+}
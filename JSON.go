@@ -0,0 +1,406 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ExprNodeJSONVersion is the version of the JSON schema of ExprNode.
+// It's written to the root node, and checked when the JSON is read back.
+const ExprNodeJSONVersion = 1
+
+// exprNodeJSON is the JSON schema of ExprNode. Literal values are stored with their types,
+// so e.g. a number and a string with the same text are restored as they were.
+type exprNodeJSON struct {
+	Version      int             `json:"version,omitempty"`
+	Type         string          `json:"type"`
+	Name         string          `json:"name,omitempty"`
+	ValueType    string          `json:"valueType,omitempty"`
+	Value        json.RawMessage `json:"value,omitempty"`
+	Error        *parseErrorJSON `json:"error,omitempty"`
+	OperatorType string          `json:"operatorType,omitempty"`
+	Args         []exprNodeJSON  `json:"args,omitempty"`
+	SourcePos    int             `json:"sourcePos"`
+	SourceLen    int             `json:"sourceLen"`
+	Comments     []commentJSON   `json:"comments,omitempty"`
+}
+
+type commentJSON struct {
+	Text      string `json:"text"`
+	Block     bool   `json:"block,omitempty"`
+	Trailing  bool   `json:"trailing,omitempty"`
+	SourcePos int    `json:"sourcePos"`
+	SourceLen int    `json:"sourceLen"`
+}
+
+// typedValueJSON is an item of array or object literal.
+type typedValueJSON struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type parseErrorJSON struct {
+	Message   string         `json:"message"`
+	Token     *exprTokenJSON `json:"token,omitempty"`
+	Expected  []string       `json:"expected,omitempty"`
+	SourcePos int            `json:"sourcePos"`
+	SourceLen int            `json:"sourceLen"`
+}
+
+type exprTokenJSON struct {
+	Kind      string      `json:"kind"`
+	Value     interface{} `json:"value,omitempty"`
+	SourcePos int         `json:"sourcePos"`
+	SourceLen int         `json:"sourceLen"`
+}
+
+var nodeTypeNames = []string{
+	NodeTypeLiteral:  "literal",
+	NodeTypeVariable: "variable",
+	NodeTypeOperator: "operator",
+	NodeTypeError:    "error",
+}
+
+var operatorTypeNames = []string{
+	OperatorTypeCall:       "call",
+	OperatorTypeInfix:      "infix",
+	OperatorTypePrefix:     "prefix",
+	OperatorTypeTernary:    "ternary",
+	OperatorTypeArray:      "array",
+	OperatorTypeIndexer:    "indexer",
+	OperatorTypeObject:     "object",
+	OperatorTypeMember:     "member",
+	OperatorTypeMethodCall: "methodCall",
+	OperatorTypeLambda:     "lambda",
+}
+
+var tokenKindNames = []string{
+	TokenKindEOF:        "eof",
+	TokenKindWhitespace: "whitespace",
+	TokenKindIdentifier: "identifier",
+	TokenKindNumber:     "number",
+	TokenKindString:     "string",
+	TokenKindOperator:   "operator",
+	TokenKindBracket:    "bracket",
+	TokenKindComment:    "comment",
+}
+
+// MarshalJSON implements json.Marshaler.
+// Literals can be nil, booleans, float64 numbers, int64 integers, *big.Rat decimals, durations, times, strings, regular expressions,
+// and arrays and objects of them; an error is returned for other values.
+func (expr ExprNode) MarshalJSON() ([]byte, error) {
+	node, err := exprNodeToJSON(expr)
+	if err != nil {
+		return nil, err
+	}
+	node.Version = ExprNodeJSONVersion
+	return json.Marshal(node)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (expr *ExprNode) UnmarshalJSON(data []byte) error {
+	var node exprNodeJSON
+	if err := json.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	if node.Version != ExprNodeJSONVersion {
+		return fmt.Errorf("unsupported ExprNode JSON version: %d", node.Version)
+	}
+	result, err := exprNodeFromJSON(node)
+	if err != nil {
+		return err
+	}
+	*expr = result
+	return nil
+}
+
+func exprNodeToJSON(expr ExprNode) (exprNodeJSON, error) {
+	node := exprNodeJSON{
+		SourcePos: expr.SourcePos,
+		SourceLen: expr.SourceLen,
+	}
+	if int(expr.Type) >= len(nodeTypeNames) || expr.Type < 0 {
+		return node, fmt.Errorf("unsupported node type: %d", expr.Type)
+	}
+	node.Type = nodeTypeNames[expr.Type]
+	for _, comment := range expr.Comments {
+		node.Comments = append(node.Comments, commentJSON(comment))
+	}
+
+	switch expr.Type {
+	case NodeTypeLiteral:
+		value, err := typedValueToJSON(expr.Value)
+		if err != nil {
+			return node, fmt.Errorf("%v [pos=%d; len=%d]", err, expr.SourcePos, expr.SourceLen)
+		}
+		node.ValueType, node.Value = value.Type, value.Value
+	case NodeTypeVariable:
+		node.Name = expr.Name
+	case NodeTypeOperator:
+		if int(expr.OperatorType) >= len(operatorTypeNames) || expr.OperatorType < 0 {
+			return node, fmt.Errorf("unsupported operator type: %d", expr.OperatorType)
+		}
+		node.Name = expr.Name
+		node.OperatorType = operatorTypeNames[expr.OperatorType]
+		node.Args = make([]exprNodeJSON, len(expr.Args))
+		for idx, arg := range expr.Args {
+			var err error
+			if node.Args[idx], err = exprNodeToJSON(arg); err != nil {
+				return node, err
+			}
+		}
+	case NodeTypeError:
+		parseError, ok := expr.Value.(ParseError)
+		if !ok {
+			return node, fmt.Errorf("error node value is not ParseError: %T", expr.Value)
+		}
+		node.Error = parseErrorToJSON(parseError)
+	}
+	return node, nil
+}
+
+func exprNodeFromJSON(node exprNodeJSON) (ExprNode, error) {
+	expr, err := exprNodeBodyFromJSON(node)
+	if err != nil {
+		return ExprNode{}, err
+	}
+	for _, comment := range node.Comments {
+		expr.Comments = append(expr.Comments, Comment(comment))
+	}
+	return expr, nil
+}
+
+// exprNodeBodyFromJSON converts a node without comments.
+func exprNodeBodyFromJSON(node exprNodeJSON) (ExprNode, error) {
+	switch node.Type {
+	case "literal":
+		value, err := typedValueFromJSON(typedValueJSON{Type: node.ValueType, Value: node.Value})
+		if err != nil {
+			return ExprNode{}, fmt.Errorf("%v [pos=%d; len=%d]", err, node.SourcePos, node.SourceLen)
+		}
+		return NewExprNodeLiteral(value, node.SourcePos, node.SourceLen), nil
+	case "variable":
+		return NewExprNodeVariable(node.Name, node.SourcePos, node.SourceLen), nil
+	case "operator":
+		operatorType, ok := lookupName(operatorTypeNames, node.OperatorType)
+		if !ok {
+			return ExprNode{}, fmt.Errorf("unsupported operator type: %q", node.OperatorType)
+		}
+		args := make([]ExprNode, len(node.Args))
+		for idx, arg := range node.Args {
+			var err error
+			if args[idx], err = exprNodeFromJSON(arg); err != nil {
+				return ExprNode{}, err
+			}
+		}
+		return NewExprNodeOperator(node.Name, args, node.SourcePos, node.SourceLen, OperatorType(operatorType)), nil
+	case "error":
+		if node.Error == nil {
+			return ExprNode{}, fmt.Errorf("error node without error [pos=%d; len=%d]", node.SourcePos, node.SourceLen)
+		}
+		parseError, err := parseErrorFromJSON(*node.Error)
+		if err != nil {
+			return ExprNode{}, err
+		}
+		return newExprNodeError(parseError, node.SourcePos, node.SourceLen), nil
+	}
+	return ExprNode{}, fmt.Errorf("unsupported node type: %q", node.Type)
+}
+
+func typedValueToJSON(value interface{}) (typedValueJSON, error) {
+	var data interface{}
+	var valueType string
+	switch v := value.(type) {
+	case nil:
+		return typedValueJSON{Type: "null"}, nil
+	case bool:
+		valueType, data = "bool", v
+	case float64:
+		valueType, data = "number", v
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			// not representable as JSON number
+			data = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+	case int64:
+		valueType, data = "integer", v
+	case *big.Rat:
+		// exact fraction, like 1/10
+		valueType, data = "decimal", v.RatString()
+	case time.Duration:
+		valueType, data = "duration", v.String()
+	case time.Time:
+		valueType, data = "time", v.Format(time.RFC3339Nano)
+	case string:
+		valueType, data = "string", v
+	case *regexp.Regexp:
+		valueType, data = "regexp", v.String()
+	case []interface{}:
+		items := make([]typedValueJSON, len(v))
+		for idx, item := range v {
+			var err error
+			if items[idx], err = typedValueToJSON(item); err != nil {
+				return typedValueJSON{}, err
+			}
+		}
+		valueType, data = "array", items
+	case map[string]interface{}:
+		items := make(map[string]typedValueJSON, len(v))
+		for key, item := range v {
+			var err error
+			if items[key], err = typedValueToJSON(item); err != nil {
+				return typedValueJSON{}, err
+			}
+		}
+		valueType, data = "object", items
+	default:
+		return typedValueJSON{}, fmt.Errorf("unsupported literal type: %T", value)
+	}
+	raw, err := json.Marshal(data)
+	return typedValueJSON{Type: valueType, Value: raw}, err
+}
+
+func typedValueFromJSON(value typedValueJSON) (interface{}, error) {
+	switch value.Type {
+	case "null":
+		return nil, nil
+	case "bool":
+		var v bool
+		err := json.Unmarshal(value.Value, &v)
+		return v, err
+	case "number":
+		var v float64
+		if err := json.Unmarshal(value.Value, &v); err != nil {
+			var text string
+			if json.Unmarshal(value.Value, &text) != nil {
+				return nil, err
+			}
+			return strconv.ParseFloat(text, 64)
+		}
+		return v, nil
+	case "integer":
+		var v int64
+		err := json.Unmarshal(value.Value, &v)
+		return v, err
+	case "decimal":
+		var text string
+		if err := json.Unmarshal(value.Value, &text); err != nil {
+			return nil, err
+		}
+		v, ok := new(big.Rat).SetString(text)
+		if !ok {
+			return nil, fmt.Errorf("invalid decimal: %q", text)
+		}
+		return v, nil
+	case "duration":
+		var text string
+		if err := json.Unmarshal(value.Value, &text); err != nil {
+			return nil, err
+		}
+		return time.ParseDuration(text)
+	case "time":
+		var text string
+		if err := json.Unmarshal(value.Value, &text); err != nil {
+			return nil, err
+		}
+		return time.Parse(time.RFC3339Nano, text)
+	case "string":
+		var v string
+		err := json.Unmarshal(value.Value, &v)
+		return v, err
+	case "regexp":
+		var pattern string
+		if err := json.Unmarshal(value.Value, &pattern); err != nil {
+			return nil, err
+		}
+		return regexp.Compile(pattern)
+	case "array":
+		var items []typedValueJSON
+		if err := json.Unmarshal(value.Value, &items); err != nil {
+			return nil, err
+		}
+		result := make([]interface{}, len(items))
+		for idx, item := range items {
+			var err error
+			if result[idx], err = typedValueFromJSON(item); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case "object":
+		var items map[string]typedValueJSON
+		if err := json.Unmarshal(value.Value, &items); err != nil {
+			return nil, err
+		}
+		result := make(map[string]interface{}, len(items))
+		for key, item := range items {
+			var err error
+			if result[key], err = typedValueFromJSON(item); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("unsupported literal type: %q", value.Type)
+}
+
+func parseErrorToJSON(err ParseError) *parseErrorJSON {
+	token := &exprTokenJSON{
+		Kind:      "unknown",
+		Value:     err.Token.Value,
+		SourcePos: err.Token.SourcePos,
+		SourceLen: err.Token.SourceLen,
+	}
+	if int(err.Token.Kind) < len(tokenKindNames) && err.Token.Kind >= 0 {
+		token.Kind = tokenKindNames[err.Token.Kind]
+	}
+	if ch, ok := err.Token.Value.(rune); ok {
+		token.Value = string(ch)
+	}
+	return &parseErrorJSON{
+		Message:   err.Message,
+		Token:     token,
+		Expected:  err.Expected,
+		SourcePos: err.SourcePos,
+		SourceLen: err.SourceLen,
+	}
+}
+
+func parseErrorFromJSON(err parseErrorJSON) (ParseError, error) {
+	result := ParseError{
+		Message:   err.Message,
+		Expected:  err.Expected,
+		SourcePos: err.SourcePos,
+		SourceLen: err.SourceLen,
+	}
+	if err.Token == nil {
+		return result, nil
+	}
+	kind, ok := lookupName(tokenKindNames, err.Token.Kind)
+	if !ok {
+		return result, fmt.Errorf("unsupported token kind: %q", err.Token.Kind)
+	}
+	result.Token = ExprToken{
+		Kind:      ExprTokenKind(kind),
+		Value:     err.Token.Value,
+		SourcePos: err.Token.SourcePos,
+		SourceLen: err.Token.SourceLen,
+	}
+	if text, ok := err.Token.Value.(string); ok && result.Token.Kind == TokenKindBracket && len(text) == 1 {
+		result.Token.Value = rune(text[0])
+	}
+	return result, nil
+}
+
+func lookupName(names []string, name string) (int, bool) {
+	for idx, n := range names {
+		if n == name {
+			return idx, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,166 @@
+package govaluate
+
+// scope binds a lambda parameter to a value, it shadows variables of outer scopes and EvalParams.Variables.
+type scope struct {
+	name   string
+	value  interface{}
+	parent *scope
+
+	// unknown is set when reducing a lambda body, so its parameter is not replaced with a variable of the same name
+	unknown bool
+}
+
+// lookup returns the value of the innermost binding of name, found is false if there is none.
+func (s *scope) lookup(name string) (value interface{}, known bool, found bool) {
+	for ; s != nil; s = s.parent {
+		if s.name == name {
+			return s.value, !s.unknown, true
+		}
+	}
+	return nil, false, false
+}
+
+// Lambda is a function defined in an expression, like x => x.price > 10.
+// Operators taking lambdas, like filter, get it with EvalContext.LambdaArg, lambdas are not values otherwise.
+type Lambda struct {
+	param    string
+	body     ExprNode
+	compiled evalFunc
+	params   EvalParams
+}
+
+// Param returns the name of the lambda parameter.
+func (l Lambda) Param() string {
+	return l.param
+}
+
+// Body returns the expression the lambda evaluates.
+func (l Lambda) Body() ExprNode {
+	return l.body
+}
+
+// Call evaluates the lambda body with the parameter bound to value.
+func (l Lambda) Call(value interface{}) (interface{}, error) {
+	params := l.params
+	params.scope = &scope{name: l.param, value: value, parent: params.scope}
+	if l.compiled != nil {
+		return runCompiled(l.compiled, params, l.body)
+	}
+	return l.body.Eval(params)
+}
+
+// builtinLambda returns a Lambda, which captures variables in scope: x => x * 2.
+// Lambdas can only be arguments of operators taking them, like filter(items, x => x > 1).
+func builtinLambda(ctx EvalContext) (interface{}, error) {
+	if !ctx.params.lambdaArg {
+		return nil, ctx.FormatError("lambda can only be an argument of a collection function")
+	}
+	if err := ctx.CheckArgCount(2); err != nil {
+		return nil, err
+	}
+	ctx.params.lambdaArg = false
+	return ctx.lambdaAt(0)
+}
+
+// lambdaAt returns a lambda, which parameter is the variable argument at idx, and the body is the next argument.
+func (ctx EvalContext) lambdaAt(idx int) (Lambda, error) {
+	param := ctx.expr.Args[idx]
+	if param.Type != NodeTypeVariable {
+		return Lambda{}, formatArgError(ctx.expr, idx, "is not an identifier")
+	}
+	lambda := Lambda{param: param.Name, body: ctx.expr.Args[idx+1], params: ctx.params}
+	if ctx.args != nil {
+		lambda.compiled = ctx.args[idx+1]
+	}
+	return lambda, nil
+}
+
+// LambdaArg returns a lambda argument, like x => x.price. If it's followed by exactly one more argument,
+// they are the parameter and the body of an inline lambda, like in filter(items, x, x.price > 10).
+func (ctx EvalContext) LambdaArg(idx int) (Lambda, error) {
+	if ctx.ArgCount() == idx+2 {
+		return ctx.lambdaAt(idx)
+	}
+	argCtx := ctx
+	argCtx.params.lambdaArg = idx < ctx.ArgCount() && ctx.expr.Args[idx].IsOperator("=>")
+	val, err := argCtx.Arg(idx)
+	if err != nil {
+		return Lambda{}, err
+	}
+	if lambda, ok := val.(Lambda); ok {
+		return lambda, nil
+	}
+	return Lambda{}, formatArgError(ctx.expr, idx, "is not a lambda: %v", val)
+}
+
+// inlineLambda tells where a builtin expects its lambda: the index of the lambda argument, the array is
+// the first argument, and the number of parameters of the inline form.
+type inlineLambda struct {
+	argIdx int
+	params int
+}
+
+// lambdaFunctions are builtins, which take an inline lambda after the array: filter(items, x, x.price > 10),
+// or after the initial value: reduce(items, 0, acc, x, acc + x.price).
+var lambdaFunctions = map[string]inlineLambda{
+	"any":      {1, 1},
+	"all":      {1, 1},
+	"none":     {1, 1},
+	"filter":   {1, 1},
+	"map":      {1, 1},
+	"count":    {1, 1},
+	"sum":      {1, 1},
+	"avg":      {1, 1},
+	"sort":     {1, 1},
+	"distinct": {1, 1},
+	"first":    {1, 1},
+	"last":     {1, 1},
+	"reduce":   {2, 2},
+}
+
+// lambdaParams returns the indexes of the first lambda parameter and of the body, the parameters are the arguments
+// between them. It finds the parameters of x => body, of inline lambdas like filter(items, x, body), and of methods
+// like items.filter(x, body).
+func lambdaParams(expr ExprNode) (int, int, bool) {
+	if expr.Type != NodeTypeOperator {
+		return 0, 0, false
+	}
+	first, count := -1, 1
+	if expr.Name == "=>" && len(expr.Args) == 2 {
+		first = 0
+	} else if argIdx, params, ok := lambdaArgIndex(expr); ok && len(expr.Args) == argIdx+params+1 {
+		first, count = argIdx, params
+	}
+	if first < 0 {
+		return 0, 0, false
+	}
+	for _, param := range expr.Args[first : first+count] {
+		if param.Type != NodeTypeVariable {
+			return 0, 0, false
+		}
+	}
+	return first, first + count, true
+}
+
+// lambdaArgIndex returns the index of the lambda argument of lambdaFunctions, called as functions or methods,
+// and the number of parameters of the inline form.
+func lambdaArgIndex(expr ExprNode) (int, int, bool) {
+	if expr.Type != NodeTypeOperator {
+		return 0, 0, false
+	}
+	if expr.Name == ".()" && len(expr.Args) >= 2 {
+		name, ok := expr.Args[1].Value.(string)
+		lambda, found := lambdaFunctions[name]
+		return lambda.argIdx + 1, lambda.params, ok && found && expr.Args[1].Type == NodeTypeLiteral
+	}
+	lambda, found := lambdaFunctions[expr.Name]
+	return lambda.argIdx, lambda.params, found
+}
+
+// paramScope binds lambda parameters to unknown values, so they shadow variables of the same name in the body.
+func paramScope(params []ExprNode, parent *scope) *scope {
+	for _, param := range params {
+		parent = &scope{name: param.Name, parent: parent, unknown: true}
+	}
+	return parent
+}
@@ -0,0 +1,547 @@
+package govaluate
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// legacyPrecedences lists precedence levels of binary operators of EvaluableExpression,
+// from the lowest to the highest, the way stage planner nests them.
+var legacyPrecedences = [...]operatorPrecedence{
+	ternaryPrecedence,
+	logicalOrPrecedence,
+	logicalAndPrecedence,
+	comparatorPrecedence,
+	bitwisePrecedence,
+	bitwiseShiftPrecedence,
+	additivePrecedence,
+	multiplicativePrecedence,
+	exponentialPrecedence,
+}
+
+/*
+	Converts the expression to an AST, which can be evaluated with `ExprNode.Eval`, reduced, printed, etc.
+	Operators keep their names. Some of them work differently than builtin operators of the same name,
+	e.g. `+` concatenates strings, so evaluate the AST with `LegacyOperators` to get the same results.
+	Functions become calls with the names they were registered with.
+	Date literals are converted to unix timestamps, like the stage planner does.
+	Nodes keep their positions in the expression, so evaluation errors point to it.
+*/
+func (this EvaluableExpression) ToExprNode() (ExprNode, error) {
+
+	if this.inputExpression == "" {
+		return TokensToExprNode(this.tokens, this.functions)
+	}
+
+	// function tokens hold function values only, so names are found by tokenizing
+	// the input again with functions returning their own names
+	markers := make(map[string]ExpressionFunction, len(this.functions)+len(this.clockFunctions))
+	for name := range this.functions {
+		markers[name] = nameMarker(name)
+	}
+	for name := range this.clockFunctions {
+		markers[name] = nameMarker(name)
+	}
+	markerTokens, spans, err := parseTokenSpans(this.inputExpression, markers, nil)
+	if err != nil {
+		return ExprNode{}, err
+	}
+	var names []string
+	for _, token := range markerTokens {
+		if token.Kind == FUNCTION {
+			name, _ := token.Value.(ExpressionFunction)()
+			names = append(names, name.(string))
+		}
+	}
+
+	converter := &tokenConverter{tokens: this.tokens}
+	if len(spans) == len(this.tokens) {
+		converter.spans = byteSpans(this.inputExpression, spans)
+	}
+	converter.functionName = func(ExpressionToken) (string, error) {
+		if len(names) == 0 {
+			return "", fmt.Errorf("unknown function")
+		}
+		name := names[0]
+		names = names[1:]
+		return name, nil
+	}
+	return converter.convert()
+}
+
+// byteSpans converts rune offsets of tokens in the expression to byte offsets, like in ExprNode.
+func byteSpans(expression string, spans []tokenSpan) []tokenSpan {
+	offsets := make([]int, 0, len(expression)+1)
+	for offset := range expression {
+		offsets = append(offsets, offset)
+	}
+	offsets = append(offsets, len(expression))
+	converted := make([]tokenSpan, len(spans))
+	for idx, span := range spans {
+		converted[idx] = tokenSpan{start: offsets[span.start], end: offsets[span.end]}
+	}
+	return converted
+}
+
+// nameMarker returns a function returning the given name, to find function names in tokens.
+func nameMarker(name string) ExpressionFunction {
+	return func(arguments ...interface{}) (interface{}, error) {
+		return name, nil
+	}
+}
+
+/*
+	Converts legacy tokens to an AST, see `EvaluableExpression.ToExprNode`.
+	As function tokens hold function values only, their names are looked up in [functions].
+	Functions are compared by code pointers, so closures created by the same function literal can't be told apart,
+	and converting them returns an error. Tokens have no positions, so nodes are at position 0.
+*/
+func TokensToExprNode(tokens []ExpressionToken, functions map[string]ExpressionFunction) (ExprNode, error) {
+
+	converter := &tokenConverter{tokens: tokens}
+	converter.functionName = func(token ExpressionToken) (string, error) {
+		pointer := reflect.ValueOf(token.Value).Pointer()
+		found := ""
+		for name, function := range functions {
+			if reflect.ValueOf(function).Pointer() != pointer {
+				continue
+			}
+			if found != "" {
+				return "", fmt.Errorf("ambiguous function: %s or %s", found, name)
+			}
+			found = name
+		}
+		if found == "" {
+			return "", fmt.Errorf("unknown function")
+		}
+		return found, nil
+	}
+	return converter.convert()
+}
+
+/*
+	Returns builtin operators, with the given legacy [functions] added as operators.
+	Use `LegacyOperators` to evaluate expressions converted with `ToExprNode`.
+*/
+func FunctionOperators(functions map[string]ExpressionFunction) map[string]Operator {
+
+	operators := BuiltinOperators()
+	for name, function := range functions {
+		operators[name] = FunctionOperator(function)
+	}
+	return operators
+}
+
+/*
+	Returns the operators of `FunctionOperators`, with the operators which work differently in `EvaluableExpression`
+	replaced by legacy-compatible ones, so expressions converted with `ToExprNode` are evaluated to the same results.
+	`+` concatenates values if either of them is a string, comparators compare strings,
+	and `==`, `!=` and `in` compare values deeply, with numbers of any type compared as float64.
+*/
+func LegacyOperators(functions map[string]ExpressionFunction) map[string]Operator {
+
+	operators := FunctionOperators(functions)
+	operators["+"] = legacyOperator(operators["+"], legacyPlus)
+	for _, name := range []string{"<", "<=", ">", ">="} {
+		operators[name] = legacyOperator(operators[name], legacyCompare(name))
+	}
+	operators["=="] = legacyOperator(operators["=="], legacyEqual)
+	operators["!="] = legacyOperator(operators["!="], func(a, b interface{}) (interface{}, bool) {
+		equal, _ := legacyEqual(a, b)
+		return !equal.(bool), true
+	})
+	operators["in"] = legacyOperator(operators["in"], legacyIn)
+	return operators
+}
+
+// legacyOperator evaluates both arguments, and returns the result of legacy, if it handles them,
+// or the result of the builtin operator otherwise.
+func legacyOperator(operator Operator, legacy func(a, b interface{}) (interface{}, bool)) Operator {
+	return func(ctx EvalContext) (interface{}, error) {
+		a, b, err := binaryArgs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if result, ok := legacy(a, b); ok {
+			return result, nil
+		}
+		// arguments are passed as literals, so they aren't evaluated again
+		args := []ExprNode{
+			NewExprNodeLiteral(a, ctx.expr.Args[0].SourcePos, ctx.expr.Args[0].SourceLen),
+			NewExprNodeLiteral(b, ctx.expr.Args[1].SourcePos, ctx.expr.Args[1].SourceLen),
+		}
+		return operator(EvalContext{
+			params: ctx.params,
+			expr:   NewExprNodeOperator(ctx.expr.Name, args, ctx.expr.SourcePos, ctx.expr.SourceLen, ctx.expr.OperatorType),
+		})
+	}
+}
+
+// legacyPlus concatenates values if either of them is a string, like addStage does.
+func legacyPlus(a, b interface{}) (interface{}, bool) {
+	if !isString(a) && !isString(b) {
+		return nil, false
+	}
+	return fmt.Sprintf("%v%v", castToFloat64(a), castToFloat64(b)), true
+}
+
+// legacyCompare compares strings, like comparator stages do.
+func legacyCompare(name string) func(a, b interface{}) (interface{}, bool) {
+	return func(a, b interface{}) (interface{}, bool) {
+		x, ok := a.(string)
+		if !ok {
+			return nil, false
+		}
+		y, ok := b.(string)
+		if !ok {
+			return nil, false
+		}
+		switch name {
+		case "<":
+			return x < y, true
+		case "<=":
+			return x <= y, true
+		case ">":
+			return x > y, true
+		}
+		return x >= y, true
+	}
+}
+
+// legacyEqual compares values deeply like equalStage does, numbers are float64 in legacy parameters.
+func legacyEqual(a, b interface{}) (interface{}, bool) {
+	return reflect.DeepEqual(castToFloat64(a), castToFloat64(b)), true
+}
+
+// legacyIn looks up a value in an array like inStage does, but without panics on uncomparable values.
+func legacyIn(a, b interface{}) (interface{}, bool) {
+	items, ok := b.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	for _, item := range items {
+		if equal, _ := legacyEqual(a, item); equal == true {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+/*
+	Adapts a legacy function to an operator. All arguments are evaluated before the function is called.
+*/
+func FunctionOperator(function ExpressionFunction) Operator {
+
+	return func(ctx EvalContext) (interface{}, error) {
+		args, err := functionArgs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		value, err := function(args...)
+		if err != nil {
+			return nil, ctx.WrapError(err)
+		}
+		return value, nil
+	}
+}
+
+/*
+	Adapts a legacy clock function to an operator, the function is given the clock of `EvalParams`.
+	All arguments are evaluated before the function is called.
+*/
+func ClockFunctionOperator(function ClockExpressionFunction) Operator {
+
+	return func(ctx EvalContext) (interface{}, error) {
+		args, err := functionArgs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		value, err := function(ctx.clock(), args...)
+		if err != nil {
+			return nil, ctx.WrapError(err)
+		}
+		return value, nil
+	}
+}
+
+// functionArgs evaluates all arguments of a legacy function call.
+func functionArgs(ctx EvalContext) ([]interface{}, error) {
+	args := make([]interface{}, ctx.ArgCount())
+	for idx := range args {
+		arg, err := ctx.Arg(idx)
+		if err != nil {
+			return nil, err
+		}
+		args[idx] = arg
+	}
+	return args, nil
+}
+
+// tokenConverter is a recursive descent parser of legacy tokens, producing ExprNode.
+type tokenConverter struct {
+	tokens       []ExpressionToken
+	pos          int
+	functionName func(token ExpressionToken) (string, error)
+
+	// byte offsets of tokens in the expression, nodes are at position 0 if there are none
+	spans []tokenSpan
+}
+
+func (c *tokenConverter) convert() (ExprNode, error) {
+	if len(c.tokens) == 0 {
+		return NewExprNodeLiteral(nil, 0, 0), nil
+	}
+	expr, err := c.convertList()
+	if err != nil {
+		return ExprNode{}, err
+	}
+	if c.pos < len(c.tokens) {
+		return ExprNode{}, c.unexpectedToken()
+	}
+	return expr, nil
+}
+
+func (c *tokenConverter) peek() (ExpressionToken, bool) {
+	if c.pos >= len(c.tokens) {
+		return ExpressionToken{}, false
+	}
+	return c.tokens[c.pos], true
+}
+
+// span returns the position and the length of tokens from first to last.
+func (c *tokenConverter) span(first, last int) (int, int) {
+	if last >= len(c.spans) {
+		return 0, 0
+	}
+	return c.spans[first].start, c.spans[last].end - c.spans[first].start
+}
+
+// spanning returns the position and the length of source from the start of first to the end of last.
+func spanning(first, last ExprNode) (int, int) {
+	return first.SourcePos, last.SourcePos + last.SourceLen - first.SourcePos
+}
+
+func (c *tokenConverter) unexpectedToken() error {
+	if token, ok := c.peek(); ok {
+		return fmt.Errorf("unexpected token %v: %v", token.Kind, token.Value)
+	}
+	return fmt.Errorf("unexpected end of tokens")
+}
+
+// convertList converts values separated by ',', which are evaluated to an array.
+func (c *tokenConverter) convertList() (ExprNode, error) {
+	items, err := c.convertItems()
+	if err != nil {
+		return ExprNode{}, err
+	}
+	if len(items) == 1 {
+		return items[0], nil
+	}
+	pos, length := spanning(items[0], items[len(items)-1])
+	return NewExprNodeOperator("array", items, pos, length, OperatorTypeArray), nil
+}
+
+func (c *tokenConverter) convertItems() ([]ExprNode, error) {
+	items := []ExprNode{}
+	for {
+		item, err := c.convertBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if token, ok := c.peek(); !ok || token.Kind != SEPARATOR {
+			return items, nil
+		}
+		c.pos++
+	}
+}
+
+// convertClause converts items in brackets, including the brackets, which span is returned too.
+func (c *tokenConverter) convertClause() ([]ExprNode, int, int, error) {
+	open := c.pos
+	c.pos++
+	if token, ok := c.peek(); ok && token.Kind == CLAUSE_CLOSE {
+		c.pos++
+		pos, length := c.span(open, c.pos-1)
+		return []ExprNode{}, pos, length, nil
+	}
+	items, err := c.convertItems()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if token, ok := c.peek(); !ok || token.Kind != CLAUSE_CLOSE {
+		return nil, 0, 0, c.unexpectedToken()
+	}
+	c.pos++
+	pos, length := c.span(open, c.pos-1)
+	return items, pos, length, nil
+}
+
+func (c *tokenConverter) convertBinary(level int) (ExprNode, error) {
+	if level == len(legacyPrecedences) {
+		return c.convertPrefix()
+	}
+	lhs, err := c.convertBinary(level + 1)
+	if err != nil {
+		return ExprNode{}, err
+	}
+	// "a ? b : c" is parsed as "(a ? b) : c", the else part completes the ternary
+	pendingTernary := false
+	for {
+		token, ok := c.peek()
+		if !ok {
+			return lhs, nil
+		}
+		symbol, ok := legacyBinarySymbol(token)
+		if !ok || findOperatorPrecedenceForSymbol(symbol) != legacyPrecedences[level] {
+			return lhs, nil
+		}
+		c.pos++
+		rhs, err := c.convertBinary(level + 1)
+		if err != nil {
+			return ExprNode{}, err
+		}
+		pos, length := spanning(lhs, rhs)
+		switch {
+		case symbol == TERNARY_TRUE:
+			// without else part the result is nil
+			args := []ExprNode{lhs, rhs, NewExprNodeLiteral(nil, pos+length, 0)}
+			lhs = NewExprNodeOperator("?:", args, pos, length, OperatorTypeTernary)
+			pendingTernary = true
+		case symbol == TERNARY_FALSE && pendingTernary:
+			lhs.Args[2] = rhs
+			lhs.SourceLen = length
+			pendingTernary = false
+		case symbol == TERNARY_FALSE:
+			// else part alone works as coalesce
+			lhs = NewExprNodeOperator("??", []ExprNode{lhs, rhs}, pos, length, OperatorTypeInfix)
+		default:
+			lhs = NewExprNodeOperator(token.Value.(string), []ExprNode{lhs, rhs}, pos, length, OperatorTypeInfix)
+			pendingTernary = false
+		}
+	}
+}
+
+func legacyBinarySymbol(token ExpressionToken) (OperatorSymbol, bool) {
+	name, ok := token.Value.(string)
+	if !ok {
+		return 0, false
+	}
+	var symbol OperatorSymbol
+	switch token.Kind {
+	case MODIFIER:
+		symbol, ok = modifierSymbols[name]
+	case COMPARATOR:
+		symbol, ok = comparatorSymbols[name]
+	case LOGICALOP:
+		symbol, ok = logicalSymbols[name]
+	case TERNARY:
+		symbol, ok = ternarySymbols[name]
+	default:
+		ok = false
+	}
+	return symbol, ok
+}
+
+func (c *tokenConverter) convertPrefix() (ExprNode, error) {
+	token, ok := c.peek()
+	if !ok || token.Kind != PREFIX {
+		return c.convertValue()
+	}
+	pos, _ := c.span(c.pos, c.pos)
+	c.pos++
+	arg, err := c.convertPrefix()
+	if err != nil {
+		return ExprNode{}, err
+	}
+	return NewExprNodeOperator(token.Value.(string), []ExprNode{arg}, pos, arg.SourcePos+arg.SourceLen-pos, OperatorTypePrefix), nil
+}
+
+func (c *tokenConverter) convertValue() (ExprNode, error) {
+	token, ok := c.peek()
+	if !ok {
+		return ExprNode{}, c.unexpectedToken()
+	}
+	pos, length := c.span(c.pos, c.pos)
+
+	switch token.Kind {
+	case NUMERIC, STRING, BOOLEAN, PATTERN:
+		c.pos++
+		return NewExprNodeLiteral(token.Value, pos, length), nil
+
+	case TIME:
+		// stage planner converts dates to unix timestamps
+		c.pos++
+		return NewExprNodeLiteral(float64(token.Value.(time.Time).Unix()), pos, length), nil
+
+	case VARIABLE:
+		c.pos++
+		return NewExprNodeVariable(token.Value.(string), pos, length), nil
+
+	case CLAUSE:
+		items, pos, length, err := c.convertClause()
+		if err != nil {
+			return ExprNode{}, err
+		}
+		if len(items) == 1 {
+			// like in Parse, the brackets are a part of the item
+			items[0].SourcePos, items[0].SourceLen = pos, length
+			return items[0], nil
+		}
+		return NewExprNodeOperator("array", items, pos, length, OperatorTypeArray), nil
+
+	case FUNCTION:
+		name, err := c.functionName(token)
+		if err != nil {
+			return ExprNode{}, err
+		}
+		c.pos++
+		if next, ok := c.peek(); !ok || next.Kind != CLAUSE {
+			return ExprNode{}, c.unexpectedToken()
+		}
+		args, argsPos, argsLen, err := c.convertClause()
+		if err != nil {
+			return ExprNode{}, err
+		}
+		return NewExprNodeOperator(name, args, pos, argsPos+argsLen-pos, OperatorTypeCall), nil
+
+	case ACCESSOR:
+		c.pos++
+		return c.convertAccessor(token.Value.([]string), pos, length)
+	}
+
+	return ExprNode{}, c.unexpectedToken()
+}
+
+// convertAccessor converts "a.B.C" to member access, or to a method call if it's followed by arguments.
+// The accessor is at the given position, if its length is 0 positions are unknown.
+func (c *tokenConverter) convertAccessor(path []string, pos int, length int) (ExprNode, error) {
+	known := length > 0
+	end := pos
+	if known {
+		end += len(path[0])
+	}
+	res := NewExprNodeVariable(path[0], pos, end-pos)
+	for idx, name := range path[1:] {
+		nameNode := NewExprNodeLiteral(name, 0, 0)
+		if known {
+			nameNode.SourcePos, nameNode.SourceLen = end+1, len(name)
+			end = nameNode.SourcePos + nameNode.SourceLen
+		}
+		next, ok := c.peek()
+		if idx == len(path)-2 && ok && next.Kind == CLAUSE {
+			args, argsPos, argsLen, err := c.convertClause()
+			if err != nil {
+				return ExprNode{}, err
+			}
+			if known {
+				end = argsPos + argsLen
+			}
+			args = append([]ExprNode{res, nameNode}, args...)
+			return NewExprNodeOperator(".()", args, pos, end-pos, OperatorTypeMethodCall), nil
+		}
+		res = NewExprNodeOperator(".", []ExprNode{res, nameNode}, pos, end-pos, OperatorTypeMember)
+	}
+	return res, nil
+}
@@ -0,0 +1,62 @@
+package govaluate
+
+import "context"
+
+// EvalLimits restricts evaluation of untrusted expressions.
+// Zero values mean there is no limit.
+type EvalLimits struct {
+	// MaxSteps is the maximum number of nodes evaluated, in total.
+	MaxSteps int
+
+	// MaxDepth is the maximum nesting of node evaluations.
+	MaxDepth int
+
+	// MaxStringLength is the maximum length of strings built by operators, like repeat().
+	// If it's zero, DefaultMaxStringLength is used, so that evaluation can't allocate without bound.
+	MaxStringLength int
+}
+
+// DefaultMaxStringLength is the maximum length of strings built by operators, if EvalLimits.MaxStringLength is not set.
+const DefaultMaxStringLength = 16 << 20
+
+// maxStringLength returns the length limit of built strings.
+func (limits EvalLimits) maxStringLength() int {
+	if limits.MaxStringLength > 0 {
+		return limits.MaxStringLength
+	}
+	return DefaultMaxStringLength
+}
+
+// evalState tracks a single evaluation, which can be cancelled or limited.
+// It's shared by all nodes evaluated, so it's not safe for concurrent use.
+type evalState struct {
+	ctx    context.Context
+	limits EvalLimits
+	steps  int
+	depth  int
+}
+
+func newEvalState(ctx context.Context, limits EvalLimits) *evalState {
+	return &evalState{ctx: ctx, limits: limits}
+}
+
+// enter is called before evaluating a node, it returns an error if evaluation
+// is cancelled or over budget. If there is no error, leave must be called after.
+func (s *evalState) enter(sourcePos, sourceLen int) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	s.steps++
+	if s.limits.MaxSteps > 0 && s.steps > s.limits.MaxSteps {
+		return BudgetExceededError{Limit: "step", Max: s.limits.MaxSteps, SourcePos: sourcePos, SourceLen: sourceLen}
+	}
+	if s.limits.MaxDepth > 0 && s.depth >= s.limits.MaxDepth {
+		return BudgetExceededError{Limit: "depth", Max: s.limits.MaxDepth, SourcePos: sourcePos, SourceLen: sourceLen}
+	}
+	s.depth++
+	return nil
+}
+
+func (s *evalState) leave() {
+	s.depth--
+}
@@ -0,0 +1,298 @@
+package govaluate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// MemberAccessor can be implemented by values to expose fields to expressions (x.field)
+// without reflection. If a value does not implement it, fields are looked up with reflection.
+type MemberAccessor interface {
+	// GetMember returns the value of a named member.
+	// If there is no such member, found must be false.
+	GetMember(name string) (value interface{}, found bool, err error)
+}
+
+// MethodCaller can be implemented by values to expose methods to expressions (x.method(a, b))
+// without reflection. If a value does not implement it, methods are looked up with reflection.
+type MethodCaller interface {
+	// CallMethod calls a named method with given arguments.
+	// If there is no such method, found must be false.
+	CallMethod(name string, args []interface{}) (value interface{}, found bool, err error)
+}
+
+func builtinMember(ctx EvalContext) (interface{}, error) {
+	if err := ctx.CheckArgCount(2); err != nil {
+		return nil, err
+	}
+	receiver, name, err := memberReceiverAndName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if accessor, ok := receiver.(MemberAccessor); ok {
+		value, found, err := accessor.GetMember(name)
+		if err != nil {
+			return nil, ctx.WrapError(err)
+		}
+		if found {
+			return value, nil
+		}
+	}
+
+	value, found, err := reflectMember(receiver, name)
+	if err != nil {
+		return nil, ctx.WrapError(err)
+	}
+	if !found {
+		return nil, ctx.FormatError("%T has no field or method %s", receiver, name)
+	}
+	return value, nil
+}
+
+func builtinMethodCall(ctx EvalContext) (interface{}, error) {
+	if ctx.ArgCount() < 2 {
+		return nil, ctx.FormatError("wrong number of arguments: %d, expected at least: 2", ctx.ArgCount())
+	}
+	receiver, name, err := memberReceiverAndName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := receiver.(MethodCaller); !ok {
+		if _, found := reflectMethod(receiver, name); !found {
+			if operator, ok := ctx.params.Operators[name]; ok {
+				// x.f(a) is f(x, a), if x has no method f, e.g. items.filter(x => x > 1)
+				return callOperatorWithReceiver(ctx, name, operator, receiver)
+			}
+		}
+	}
+	args := make([]interface{}, ctx.ArgCount()-2)
+	for i := range args {
+		arg, err := ctx.Arg(i + 2)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = arg
+	}
+
+	if caller, ok := receiver.(MethodCaller); ok {
+		value, found, err := caller.CallMethod(name, args)
+		if err != nil {
+			return nil, ctx.WrapError(err)
+		}
+		if found {
+			return value, nil
+		}
+	}
+
+	method, found := reflectMethod(receiver, name)
+	if !found {
+		return nil, ctx.FormatError("%T has no method %s", receiver, name)
+	}
+	params, err := methodParams(ctx, method.Type(), args)
+	if err != nil {
+		return nil, err
+	}
+	value, err := callMethod(method, params)
+	if err != nil {
+		return nil, ctx.WrapError(err)
+	}
+	return value, nil
+}
+
+// methodParams converts arguments of a method call to parameter types.
+func methodParams(ctx EvalContext, methodType reflect.Type, args []interface{}) ([]reflect.Value, error) {
+	if methodType.NumIn() != len(args) {
+		return nil, ctx.FormatError("wrong number of arguments: %d, expected: %d", len(args), methodType.NumIn())
+	}
+	params := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		param, ok := convertArg(arg, methodType.In(i))
+		if !ok {
+			return nil, formatArgError(ctx.expr, i+2, "can not be converted to %v: %v", methodType.In(i), arg)
+		}
+		params[i] = param
+	}
+	return params, nil
+}
+
+// convertArg converts an argument to a parameter type without loss:
+// numbers are converted to integers only if they are whole and in range, e.g. 2.0 but not 1.9,
+// and other values only to types of the same kind, e.g. not 1 to string.
+func convertArg(arg interface{}, paramType reflect.Type) (reflect.Value, bool) {
+	if arg == nil {
+		return reflect.Zero(paramType), true
+	}
+	value := reflect.ValueOf(arg)
+	if value.Type().AssignableTo(paramType) {
+		return value, true
+	}
+
+	param := reflect.New(paramType).Elem()
+	switch paramType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, ok := decimalValue(arg)
+		if !ok || !number.IsInt() || !number.Num().IsInt64() || param.OverflowInt(number.Num().Int64()) {
+			return reflect.Value{}, false
+		}
+		param.SetInt(number.Num().Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		number, ok := decimalValue(arg)
+		if !ok || !number.IsInt() || !number.Num().IsUint64() || param.OverflowUint(number.Num().Uint64()) {
+			return reflect.Value{}, false
+		}
+		param.SetUint(number.Num().Uint64())
+	case reflect.Float32, reflect.Float64:
+		number, ok := decimalValue(arg)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		// like in NumericArg, the nearest float is used
+		floatVal, _ := number.Float64()
+		if param.OverflowFloat(floatVal) {
+			return reflect.Value{}, false
+		}
+		param.SetFloat(floatVal)
+	default:
+		if value.Kind() != paramType.Kind() || !value.Type().ConvertibleTo(paramType) {
+			return reflect.Value{}, false
+		}
+		return value.Convert(paramType), true
+	}
+	return param, true
+}
+
+// callOperatorWithReceiver calls an operator with the receiver of a method call as the first argument,
+// followed by the method arguments, which are not evaluated in advance, so lambdas can be inline.
+func callOperatorWithReceiver(ctx EvalContext, name string, operator Operator, receiver interface{}) (interface{}, error) {
+	receiverNode := ctx.expr.Args[0]
+	args := append([]ExprNode{NewExprNodeLiteral(receiver, receiverNode.SourcePos, receiverNode.SourceLen)}, ctx.expr.Args[2:]...)
+	call := EvalContext{
+		params: ctx.params,
+		expr:   NewExprNodeOperator(name, args, ctx.expr.SourcePos, ctx.expr.SourceLen, OperatorTypeCall),
+	}
+	if ctx.args != nil {
+		receiverFunc := func(EvalParams) (interface{}, error) {
+			return receiver, nil
+		}
+		call.args = append([]evalFunc{receiverFunc}, ctx.args[2:]...)
+	}
+	return operator(call)
+}
+
+func memberReceiverAndName(ctx EvalContext) (interface{}, string, error) {
+	receiver, err := ctx.Arg(0)
+	if err != nil {
+		return nil, "", err
+	}
+	name, err := ctx.StringArg(1)
+	if err != nil {
+		return nil, "", err
+	}
+	return receiver, name, nil
+}
+
+// reflectMember resolves a struct field, a map key, or a method without arguments.
+func reflectMember(receiver interface{}, name string) (interface{}, bool, error) {
+	if object, ok := receiver.(map[string]interface{}); ok {
+		value, found := object[name]
+		return value, found, nil
+	}
+
+	value := reflect.ValueOf(receiver)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil, false, nil
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		// fields are named like in Go, or like in StructParameters
+		if index, ok := structLayoutOf(value.Type()).member(name); ok {
+			if fieldValue, ok := fieldByIndex(value, index); ok {
+				return fieldValue.Interface(), true, nil
+			}
+			return nil, false, nil
+		}
+	case reflect.Map:
+		keyType := value.Type().Key()
+		if keyType.Kind() == reflect.String {
+			item := value.MapIndex(reflect.ValueOf(name).Convert(keyType))
+			if item.IsValid() {
+				return item.Interface(), true, nil
+			}
+			return nil, false, nil
+		}
+	}
+
+	// like in EvaluableExpression, methods can be called without brackets
+	if method, found := reflectMethod(receiver, name); found {
+		result, err := callMethod(method, []reflect.Value{})
+		return result, true, err
+	}
+	return nil, false, nil
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but false is returned
+// instead of a panic if the field is promoted through a nil embedded pointer.
+func fieldByIndex(value reflect.Value, index []int) (reflect.Value, bool) {
+	for _, idx := range index {
+		for value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return reflect.Value{}, false
+			}
+			value = value.Elem()
+		}
+		value = value.Field(idx)
+	}
+	return value, true
+}
+
+// reflectMethod looks up an exported method.
+// Like in Go, methods with pointer receivers are only available on pointers.
+func reflectMethod(receiver interface{}, name string) (reflect.Value, bool) {
+	value := reflect.ValueOf(receiver)
+	if !value.IsValid() {
+		return reflect.Value{}, false
+	}
+	method := value.MethodByName(name)
+	return method, method.IsValid()
+}
+
+// callMethod calls a method with arguments converted by methodParams,
+// and interprets either (value) or (value, error) results.
+func callMethod(method reflect.Value, params []reflect.Value) (result interface{}, err error) {
+	// calls into user code are sticky, convert panics to errors
+	defer func() {
+		if r := recover(); r != nil {
+			result = nil
+			err = fmt.Errorf("method call failed: %v", r)
+		}
+	}()
+
+	if methodType := method.Type(); methodType.NumIn() != len(params) {
+		return nil, fmt.Errorf("wrong number of arguments: %d, expected: %d", len(params), methodType.NumIn())
+	}
+
+	returned := method.Call(params)
+	switch len(returned) {
+	case 0:
+		return nil, errors.New("method did not return any values")
+	case 1:
+		return returned[0].Interface(), nil
+	case 2:
+		if !returned[1].Type().Implements(errorType) {
+			break
+		}
+		if callErr, _ := returned[1].Interface().(error); callErr != nil {
+			return nil, callErr
+		}
+		return returned[0].Interface(), nil
+	}
+	return nil, errors.New("method did not return either one value, or a value and an error")
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
@@ -0,0 +1,357 @@
+package govaluate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// mongoComparisons maps comparison operators to MongoDB query operators.
+var mongoComparisons = map[string]string{
+	"==": "$eq",
+	"!=": "$ne",
+	"<":  "$lt",
+	"<=": "$lte",
+	">":  "$gt",
+	">=": "$gte",
+}
+
+// mongoFlippedComparisons is used when a literal is compared to a field, e.g. 1 < a -> a > 1.
+var mongoFlippedComparisons = map[string]string{
+	"==": "$eq",
+	"!=": "$ne",
+	"<":  "$gt",
+	"<=": "$gte",
+	">":  "$lt",
+	">=": "$lte",
+}
+
+// mongoOperators maps operators and functions to MongoDB aggregation operators, used in $expr.
+var mongoOperators = map[string]string{
+	"==":    "$eq",
+	"!=":    "$ne",
+	"<":     "$lt",
+	"<=":    "$lte",
+	">":     "$gt",
+	">=":    "$gte",
+	"&&":    "$and",
+	"||":    "$or",
+	"+":     "$add",
+	"-":     "$subtract",
+	"*":     "$multiply",
+	"/":     "$divide",
+	"%":     "$mod",
+	"**":    "$pow",
+	"??":    "$ifNull",
+	"abs":   "$abs",
+	"ceil":  "$ceil",
+	"floor": "$floor",
+	"round": "$round",
+	"sqrt":  "$sqrt",
+	"lower": "$toLower",
+	"upper": "$toUpper",
+	"len":   "$strLenCP",
+	"min":   "$min",
+	"max":   "$max",
+}
+
+// ToMongoFilter converts the expression to a MongoDB query filter, variables are used as field names,
+// and member access like a.b is used as a dotted field path.
+// Comparisons of a field with a literal are converted to query operators like $eq, $gt, $in and $regex,
+// other conditions, e.g. arithmetic, fall back to $expr.
+// An error is returned for operators which can't be expressed, e.g. custom functions.
+func (expr ExprNode) ToMongoFilter() (map[string]interface{}, error) {
+	return mongoFilter(expr)
+}
+
+func mongoFilter(node ExprNode) (map[string]interface{}, error) {
+	if node.Type == NodeTypeVariable || node.IsOperator(".") {
+		if field, ok := mongoField(node); ok {
+			return map[string]interface{}{field: map[string]interface{}{"$eq": true}}, nil
+		}
+	}
+	if node.Type != NodeTypeOperator {
+		return mongoExprFilter(node)
+	}
+
+	args := node.Args
+	arity := len(args)
+	switch {
+	case (node.Name == "&&" || node.Name == "||") && arity == 2:
+		items := []interface{}{}
+		for _, arg := range mongoFlatten(node, nil) {
+			filter, err := mongoFilter(arg)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, filter)
+		}
+		if node.Name == "&&" {
+			return map[string]interface{}{"$and": items}, nil
+		}
+		return map[string]interface{}{"$or": items}, nil
+
+	case node.Name == "!" && arity == 1:
+		filter, err := mongoFilter(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return mongoNot(filter), nil
+
+	case mongoComparisons[node.Name] != "" && arity == 2:
+		if field, ok := mongoField(args[0]); ok {
+			if value, ok := mongoValue(args[1]); ok {
+				return map[string]interface{}{field: map[string]interface{}{mongoComparisons[node.Name]: value}}, nil
+			}
+		}
+		if field, ok := mongoField(args[1]); ok {
+			if value, ok := mongoValue(args[0]); ok {
+				return map[string]interface{}{field: map[string]interface{}{mongoFlippedComparisons[node.Name]: value}}, nil
+			}
+		}
+
+	case node.Name == "in" && arity == 2:
+		if field, ok := mongoField(args[0]); ok {
+			if values, ok := mongoArray(args[1]); ok {
+				return map[string]interface{}{field: map[string]interface{}{"$in": values}}, nil
+			}
+		}
+
+	case (node.Name == "=~" || node.Name == "!~") && arity == 2:
+		if field, ok := mongoField(args[0]); ok {
+			if pattern, ok := mongoPattern(args[1]); ok {
+				filter := map[string]interface{}{"$regex": pattern}
+				if node.Name == "!~" {
+					filter = map[string]interface{}{"$not": filter}
+				}
+				return map[string]interface{}{field: filter}, nil
+			}
+		}
+	}
+
+	return mongoExprFilter(node)
+}
+
+// mongoExprFilter converts a condition to $expr filter.
+func mongoExprFilter(node ExprNode) (map[string]interface{}, error) {
+	value, err := mongoExpr(node)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"$expr": value}, nil
+}
+
+// mongoNot negates a filter, $not is used for a single field, and $nor otherwise.
+func mongoNot(filter map[string]interface{}) map[string]interface{} {
+	if len(filter) == 1 {
+		for field, value := range filter {
+			operators, ok := value.(map[string]interface{})
+			if !strings.HasPrefix(field, "$") && ok {
+				return map[string]interface{}{field: map[string]interface{}{"$not": operators}}
+			}
+		}
+	}
+	return map[string]interface{}{"$nor": []interface{}{filter}}
+}
+
+// mongoFlatten collects operands of chained operators with the same name, e.g. a && b && c.
+func mongoFlatten(node ExprNode, output []ExprNode) []ExprNode {
+	for _, arg := range node.Args {
+		if arg.IsOperator(node.Name) && len(arg.Args) == 2 {
+			output = mongoFlatten(arg, output)
+		} else {
+			output = append(output, arg)
+		}
+	}
+	return output
+}
+
+// mongoField returns a field path of a variable or a member access, like a.b.c.
+func mongoField(node ExprNode) (string, bool) {
+	switch {
+	case node.Type == NodeTypeVariable:
+		return node.Name, !strings.HasPrefix(node.Name, "$")
+	case node.IsOperator(".") && len(node.Args) == 2:
+		receiver, ok := mongoField(node.Args[0])
+		if !ok {
+			return "", false
+		}
+		name, ok := node.Args[1].Value.(string)
+		return receiver + "." + name, ok && node.Args[1].Type == NodeTypeLiteral
+	}
+	return "", false
+}
+
+// mongoValue returns a value of a literal, which can be used in a query filter.
+func mongoValue(node ExprNode) (interface{}, bool) {
+	if node.Type != NodeTypeLiteral {
+		return nil, false
+	}
+	switch node.Value.(type) {
+	case *regexp.Regexp, ExprNode:
+		return nil, false
+	}
+	return node.Value, true
+}
+
+// mongoArray returns values of an array, if all its items are literals.
+func mongoArray(node ExprNode) ([]interface{}, bool) {
+	if values, ok := node.Value.([]interface{}); ok && node.Type == NodeTypeLiteral {
+		return values, true
+	}
+	if !node.IsOperator("array") {
+		return nil, false
+	}
+	values := []interface{}{}
+	for _, item := range node.Args {
+		value, ok := mongoValue(item)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, value)
+	}
+	return values, true
+}
+
+// mongoPattern returns a regular expression of a literal string or pattern.
+func mongoPattern(node ExprNode) (string, bool) {
+	if node.Type != NodeTypeLiteral {
+		return "", false
+	}
+	switch v := node.Value.(type) {
+	case string:
+		return v, true
+	case *regexp.Regexp:
+		return v.String(), true
+	}
+	return "", false
+}
+
+// mongoExpr converts the expression to an aggregation expression, which can be used in $expr.
+func mongoExpr(node ExprNode) (interface{}, error) {
+	switch node.Type {
+	case NodeTypeLiteral:
+		return mongoExprLiteral(node)
+	case NodeTypeVariable:
+		if field, ok := mongoField(node); ok {
+			return "$" + field, nil
+		}
+	case NodeTypeOperator:
+		return mongoExprOperator(node)
+	}
+	return nil, mongoUnsupported(node)
+}
+
+func mongoExprLiteral(node ExprNode) (interface{}, error) {
+	switch v := node.Value.(type) {
+	case nil, bool, float64, int64:
+		return v, nil
+	case string:
+		if strings.HasPrefix(v, "$") {
+			// would be a field path otherwise
+			return map[string]interface{}{"$literal": v}, nil
+		}
+		return v, nil
+	case []interface{}:
+		return map[string]interface{}{"$literal": v}, nil
+	}
+	return nil, fmt.Errorf("unsupported literal type in MongoDB: %v [pos=%d; len=%d]", node.Value, node.SourcePos, node.SourceLen)
+}
+
+func mongoExprOperator(node ExprNode) (interface{}, error) {
+	args := node.Args
+	arity := len(args)
+
+	if field, ok := mongoField(node); ok {
+		return "$" + field, nil
+	}
+
+	switch {
+	case node.Name == "array":
+		return mongoExprArgs(args)
+
+	case node.Name == "-" && arity == 1:
+		operand, err := mongoExpr(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"$multiply": []interface{}{-1.0, operand}}, nil
+
+	case node.Name == "!" && arity == 1:
+		operands, err := mongoExprArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"$not": operands}, nil
+
+	case node.Name == "?:" && arity == 3:
+		operands, err := mongoExprArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"$cond": operands}, nil
+
+	case node.Name == "in" && arity == 2:
+		operands, err := mongoExprArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"$in": operands}, nil
+
+	case (node.Name == "=~" || node.Name == "!~") && arity == 2:
+		input, err := mongoExpr(args[0])
+		if err != nil {
+			return nil, err
+		}
+		var regex interface{}
+		if pattern, ok := mongoPattern(args[1]); ok {
+			regex = pattern
+		} else if regex, err = mongoExpr(args[1]); err != nil {
+			return nil, err
+		}
+		match := map[string]interface{}{"$regexMatch": map[string]interface{}{"input": input, "regex": regex}}
+		if node.Name == "!~" {
+			return map[string]interface{}{"$not": []interface{}{match}}, nil
+		}
+		return match, nil
+
+	case node.Name == "trim" && arity == 1:
+		input, err := mongoExpr(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"$trim": map[string]interface{}{"input": input}}, nil
+	}
+
+	if mongoName, ok := mongoOperators[node.Name]; ok && (arity == 2 || !isSpecial(node.Name)) {
+		operands, err := mongoExprArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{mongoName: operands}, nil
+	}
+
+	return nil, mongoUnsupported(node)
+}
+
+func mongoExprArgs(args []ExprNode) ([]interface{}, error) {
+	operands := []interface{}{}
+	for _, arg := range args {
+		operand, err := mongoExpr(arg)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	return operands, nil
+}
+
+func mongoUnsupported(node ExprNode) error {
+	switch node.Type {
+	case NodeTypeVariable:
+		return fmt.Errorf("variable is not supported in MongoDB: %s [pos=%d; len=%d]", node.Name, node.SourcePos, node.SourceLen)
+	case NodeTypeError:
+		return node.Value.(ParseError)
+	}
+	return fmt.Errorf("operator is not supported in MongoDB: %s [pos=%d; len=%d]", node.Name, node.SourcePos, node.SourceLen)
+}
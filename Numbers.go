@@ -0,0 +1,617 @@
+package govaluate
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NumberMode selects how numbers are represented in expressions.
+type NumberMode int
+
+const (
+	// NumberModeFloat is the default mode: all numbers are float64.
+	NumberModeFloat NumberMode = iota
+
+	// NumberModeInteger keeps integers as int64: integer literals are parsed as int64, and operators
+	// of IntegerOperators do exact integer math, reporting an error on overflow.
+	// Floats are still float64, an operation with a float operand converts the other one to float64.
+	NumberModeInteger
+
+	// NumberModeDecimal keeps numbers as exact decimals: number literals are parsed as *big.Rat,
+	// and operators of DecimalOperators do exact decimal arithmetic.
+	NumberModeDecimal
+)
+
+// IntegerOperators returns builtin operators for NumberModeInteger.
+// Arithmetic, bitwise and comparison operators, and abs, min, max, floor, ceil and round return int64
+// for integer arguments, and len and functions returning parts of time, like year, return int64.
+// Integer division truncates like in Go: 7 / 2 is 3, but 7 / 2.0 is 3.5.
+// Integer variables of any Go type are converted to int64, except uint64 values out of int64 range.
+func IntegerOperators() map[string]Operator {
+	operators := BuiltinOperators()
+	for _, name := range wholeNumberOperators {
+		operators[name] = integerResult(operators[name])
+	}
+	overrides := map[string]Operator{
+		"==": integerEq,
+		"!=": integerNeq,
+		"<":  integerLt,
+		"<=": integerLte,
+		">":  integerGt,
+		">=": integerGte,
+
+		"+":  integerSum,
+		"-":  integerMinus,
+		"*":  integerMul,
+		"/":  integerDiv,
+		"%":  integerMod,
+		"**": integerPow,
+
+		"&":  integerBitwiseAnd,
+		"|":  integerBitwiseOr,
+		"^":  integerBitwiseXor,
+		"<<": integerBitwiseLShift,
+		">>": integerBitwiseRShift,
+		"~":  integerBitwiseInverse,
+
+		"in": integerContains,
+
+		"floor": integerRounding(math.Floor),
+		"ceil":  integerRounding(math.Ceil),
+		"round": integerRounding(math.Round),
+		"abs":   integerAbs,
+		"min":   integerMin,
+		"max":   integerMax,
+	}
+	for name, operator := range overrides {
+		operators[name] = operator
+	}
+	for name, operator := range operators {
+		operators[name] = IntegerOperator(operator)
+	}
+	return operators
+}
+
+// IntegerOperator makes EvalContext.Arg of the operator return integers as int64, instead of float64.
+// It can be used to add custom operators to IntegerOperators.
+func IntegerOperator(operator Operator) Operator {
+	return func(ctx EvalContext) (interface{}, error) {
+		ctx.numbers = NumberModeInteger
+		return operator(ctx)
+	}
+}
+
+// integerValue converts a value of any Go integer type to int64.
+// False is returned for other values, and for unsigned values out of int64 range.
+func integerValue(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint:
+		return int64(v), uint64(v) <= math.MaxInt64
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	}
+	return 0, false
+}
+
+// integerLiteral converts a number token text to int64, if it's an integer in int64 range.
+func integerLiteral(text string, value float64) interface{} {
+	if strings.HasPrefix(text, "0x") {
+		if v, err := strconv.ParseUint(text[2:], 16, 64); err == nil && v <= math.MaxInt64 {
+			return int64(v)
+		}
+		return value
+	}
+	if strings.ContainsRune(text, '.') {
+		return value
+	}
+	if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return v
+	}
+	return value
+}
+
+// floatValue converts an int64 to float64, other values are returned as is.
+func floatValue(value interface{}) interface{} {
+	if v, ok := value.(int64); ok {
+		return float64(v)
+	}
+	return value
+}
+
+// binaryNumberArgs returns numeric arguments as int64 if both are integers, or as float64 otherwise.
+func binaryNumberArgs(ctx EvalContext) (interface{}, interface{}, error) {
+	left, right, err := binaryArgs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return numberValues(ctx, left, right)
+}
+
+// numberValues converts evaluated arguments like binaryNumberArgs does.
+func numberValues(ctx EvalContext, left interface{}, right interface{}) (interface{}, interface{}, error) {
+	for idx, arg := range []interface{}{left, right} {
+		switch arg.(type) {
+		case int64, float64:
+		default:
+			return nil, nil, formatArgError(ctx.expr, idx, "is not numeric: %v", arg)
+		}
+	}
+	if _, ok := left.(int64); ok {
+		if _, ok := right.(int64); ok {
+			return left, right, nil
+		}
+	}
+	return floatValue(left), floatValue(right), nil
+}
+
+// binaryInt64Args returns arguments as int64, floats are accepted if they have no fractional part.
+func binaryInt64Args(ctx EvalContext) (int64, int64, error) {
+	left, right, err := binaryArgs(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	a, err := int64Arg(ctx, 0, left)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := int64Arg(ctx, 1, right)
+	return a, b, err
+}
+
+func int64Arg(ctx EvalContext, idx int, value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+			return int64(v), nil
+		}
+	}
+	return 0, formatArgError(ctx.expr, idx, "is not integer: %v", value)
+}
+
+func overflowError(ctx EvalContext, a interface{}, b interface{}) error {
+	if b == nil {
+		return ctx.FormatError("integer overflow: %s%v", ctx.expr.Name, a)
+	}
+	return ctx.FormatError("integer overflow: %v %s %v", a, ctx.expr.Name, b)
+}
+
+// numbersEqual compares values like ==, but numbers of different types are compared by value.
+func numbersEqual(a, b interface{}) bool {
+	if equal, ok := equalCollections(a, b, numbersEqual); ok {
+		return equal
+	}
+	if x, ok := integerValue(a); ok {
+		a = x
+	}
+	if y, ok := integerValue(b); ok {
+		b = y
+	}
+	if equalValues(a, b) {
+		return true
+	}
+	if _, ok := a.(int64); ok {
+		if _, ok := b.(int64); ok {
+			// different integers can be equal as floats
+			return false
+		}
+	}
+	switch a.(type) {
+	case int64, float64:
+		switch b.(type) {
+		case int64, float64:
+			return floatValue(a) == floatValue(b)
+		}
+	}
+	return false
+}
+
+func integerEq(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryArgs(ctx)
+	return numbersEqual(a, b), err
+}
+
+func integerNeq(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryArgs(ctx)
+	return !numbersEqual(a, b), err
+}
+
+// integerCompare returns -1, 0 or 1, comparing numeric arguments.
+func integerCompare(ctx EvalContext) (int, error) {
+	left, right, err := binaryArgs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if order, ok := compareTimes(left, right); ok {
+		return order, nil
+	}
+	if left, right, err = numberValues(ctx, left, right); err != nil {
+		return 0, err
+	}
+	if a, ok := left.(int64); ok {
+		b := right.(int64)
+		switch {
+		case a < b:
+			return -1, nil
+		case a > b:
+			return 1, nil
+		}
+		return 0, nil
+	}
+	x, y := left.(float64), right.(float64)
+	switch {
+	case x < y:
+		return -1, nil
+	case x > y:
+		return 1, nil
+	case x == y:
+		return 0, nil
+	}
+	// NaN is not ordered, all comparisons are false
+	return 2, nil
+}
+
+func integerLt(ctx EvalContext) (interface{}, error) {
+	result, err := integerCompare(ctx)
+	return result == -1, err
+}
+
+func integerLte(ctx EvalContext) (interface{}, error) {
+	result, err := integerCompare(ctx)
+	return result == -1 || result == 0, err
+}
+
+func integerGt(ctx EvalContext) (interface{}, error) {
+	result, err := integerCompare(ctx)
+	return result == 1, err
+}
+
+func integerGte(ctx EvalContext) (interface{}, error) {
+	result, err := integerCompare(ctx)
+	return result == 1 || result == 0, err
+}
+
+func integerSum(ctx EvalContext) (interface{}, error) {
+	left, right, err := binaryArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if sum, ok := timeSum(left, right); ok {
+		return sum, nil
+	}
+	if left, right, err = numberValues(ctx, left, right); err != nil {
+		return nil, err
+	}
+	if a, ok := left.(int64); ok {
+		b := right.(int64)
+		sum := a + b
+		if (sum > a) != (b > 0) {
+			return nil, overflowError(ctx, a, b)
+		}
+		return sum, nil
+	}
+	return left.(float64) + right.(float64), nil
+}
+
+func integerMinus(ctx EvalContext) (interface{}, error) {
+	if ctx.ArgCount() != 1 {
+		return integerSub(ctx)
+	}
+	arg, err := ctx.Arg(0)
+	if err != nil {
+		return nil, err
+	}
+	switch v := arg.(type) {
+	case int64:
+		if v == math.MinInt64 {
+			return nil, overflowError(ctx, v, nil)
+		}
+		return -v, nil
+	case float64:
+		return -v, nil
+	case time.Duration:
+		return -v, nil
+	}
+	return nil, formatArgError(ctx.expr, 0, "is not numeric: %v", arg)
+}
+
+func integerSub(ctx EvalContext) (interface{}, error) {
+	left, right, err := binaryArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if difference, ok := timeDifference(left, right); ok {
+		return difference, nil
+	}
+	if left, right, err = numberValues(ctx, left, right); err != nil {
+		return nil, err
+	}
+	if a, ok := left.(int64); ok {
+		b := right.(int64)
+		diff := a - b
+		if (diff < a) != (b > 0) {
+			return nil, overflowError(ctx, a, b)
+		}
+		return diff, nil
+	}
+	return left.(float64) - right.(float64), nil
+}
+
+func integerMul(ctx EvalContext) (interface{}, error) {
+	left, right, err := binaryNumberArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a, ok := left.(int64); ok {
+		b := right.(int64)
+		product, ok := mulInt64(a, b)
+		if !ok {
+			return nil, overflowError(ctx, a, b)
+		}
+		return product, nil
+	}
+	return left.(float64) * right.(float64), nil
+}
+
+// mulInt64 multiplies integers, false is returned on overflow.
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	product := a * b
+	if product/b != a || a == -1 && b == math.MinInt64 || b == -1 && a == math.MinInt64 {
+		return 0, false
+	}
+	return product, true
+}
+
+func integerDiv(ctx EvalContext) (interface{}, error) {
+	left, right, err := binaryNumberArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a, ok := left.(int64); ok {
+		b := right.(int64)
+		if b == 0 {
+			return nil, ctx.FormatError("integer division by zero")
+		}
+		if a == math.MinInt64 && b == -1 {
+			return nil, overflowError(ctx, a, b)
+		}
+		return a / b, nil
+	}
+	return left.(float64) / right.(float64), nil
+}
+
+func integerMod(ctx EvalContext) (interface{}, error) {
+	left, right, err := binaryNumberArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a, ok := left.(int64); ok {
+		b := right.(int64)
+		if b == 0 {
+			return nil, ctx.FormatError("integer division by zero")
+		}
+		return a % b, nil
+	}
+	return math.Mod(left.(float64), right.(float64)), nil
+}
+
+func integerPow(ctx EvalContext) (interface{}, error) {
+	left, right, err := binaryNumberArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a, ok := left.(int64); ok && right.(int64) >= 0 {
+		b := right.(int64)
+		result, ok := powInt64(a, b)
+		if !ok {
+			return nil, overflowError(ctx, a, b)
+		}
+		return result, nil
+	}
+	// negative exponent gives a fraction
+	return math.Pow(floatValue(left).(float64), floatValue(right).(float64)), nil
+}
+
+// powInt64 raises an integer to a non-negative power by squaring, false is returned on overflow.
+func powInt64(base, exp int64) (int64, bool) {
+	result := int64(1)
+	for exp > 0 {
+		if exp&1 == 1 {
+			var ok bool
+			if result, ok = mulInt64(result, base); !ok {
+				return 0, false
+			}
+		}
+		exp >>= 1
+		if exp > 0 {
+			var ok bool
+			if base, ok = mulInt64(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+func integerBitwiseAnd(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryInt64Args(ctx)
+	return a & b, err
+}
+
+func integerBitwiseOr(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryInt64Args(ctx)
+	return a | b, err
+}
+
+func integerBitwiseXor(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryInt64Args(ctx)
+	return a ^ b, err
+}
+
+func integerBitwiseLShift(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryInt64Args(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if b < 0 {
+		return nil, ctx.FormatError("shift count is negative: %d", b)
+	}
+	if a == 0 {
+		return a, nil
+	}
+	if b >= 64 || a<<uint(b)>>uint(b) != a {
+		return nil, overflowError(ctx, a, b)
+	}
+	return a << uint(b), nil
+}
+
+func integerBitwiseRShift(ctx EvalContext) (interface{}, error) {
+	a, b, err := binaryInt64Args(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if b < 0 {
+		return nil, ctx.FormatError("shift count is negative: %d", b)
+	}
+	return a >> uint(b), nil
+}
+
+func integerBitwiseInverse(ctx EvalContext) (interface{}, error) {
+	if err := ctx.CheckArgCount(1); err != nil {
+		return nil, err
+	}
+	arg, err := ctx.Arg(0)
+	if err != nil {
+		return nil, err
+	}
+	value, err := int64Arg(ctx, 0, arg)
+	return ^value, err
+}
+
+func integerContains(ctx EvalContext) (interface{}, error) {
+	if err := ctx.CheckArgCount(2); err != nil {
+		return nil, err
+	}
+	item, err := ctx.Arg(0)
+	if err != nil {
+		return nil, err
+	}
+	slice, err := ctx.SliceArg(1)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range slice {
+		if numbersEqual(item, v) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// integerRounding returns integers as is, and rounds floats with fn.
+func integerRounding(fn func(float64) float64) Operator {
+	return func(ctx EvalContext) (interface{}, error) {
+		if err := ctx.CheckArgCount(1); err != nil {
+			return nil, err
+		}
+		arg, err := ctx.Arg(0)
+		if err != nil {
+			return nil, err
+		}
+		switch v := arg.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			return fn(v), nil
+		}
+		return nil, formatArgError(ctx.expr, 0, "is not numeric: %v", arg)
+	}
+}
+
+func integerAbs(ctx EvalContext) (interface{}, error) {
+	if err := ctx.CheckArgCount(1); err != nil {
+		return nil, err
+	}
+	arg, err := ctx.Arg(0)
+	if err != nil {
+		return nil, err
+	}
+	switch v := arg.(type) {
+	case int64:
+		if v == math.MinInt64 {
+			return nil, overflowError(ctx, v, nil)
+		}
+		if v < 0 {
+			return -v, nil
+		}
+		return v, nil
+	case float64:
+		return math.Abs(v), nil
+	}
+	return nil, formatArgError(ctx.expr, 0, "is not numeric: %v", arg)
+}
+
+func integerMin(ctx EvalContext) (interface{}, error) {
+	left, right, err := binaryNumberArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a, ok := left.(int64); ok {
+		if b := right.(int64); b < a {
+			return b, nil
+		}
+		return a, nil
+	}
+	return math.Min(left.(float64), right.(float64)), nil
+}
+
+func integerMax(ctx EvalContext) (interface{}, error) {
+	left, right, err := binaryNumberArgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a, ok := left.(int64); ok {
+		if b := right.(int64); b > a {
+			return b, nil
+		}
+		return a, nil
+	}
+	return math.Max(left.(float64), right.(float64)), nil
+}
+
+// wholeNumberOperators are builtins, which always return whole float64 numbers,
+// they return numbers of the number mode instead.
+var wholeNumberOperators = []string{"len", "year", "month", "day", "hour", "minute", "second", "dayOfWeek", "count"}
+
+// integerResult converts the float64 result of an operator, which is always an integer, to int64.
+func integerResult(operator Operator) Operator {
+	return func(ctx EvalContext) (interface{}, error) {
+		result, err := operator(ctx)
+		if v, ok := result.(float64); ok && err == nil {
+			return int64(v), nil
+		}
+		return result, err
+	}
+}
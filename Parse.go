@@ -2,25 +2,41 @@ package govaluate
 
 import (
 	"fmt"
-	"strings"
 )
 
 // Grammar:
-// expr    = ternary | binary | indexer ;
+// expr    = ternary | lambda | binary | indexer ;
 // ternary = indexer, "?", expr, ":", expr ;
+// lambda  = ident, "=>", expr ;
 // binary  = indexer, operator, expr
 //         | indexer, ident, expr ;
-// indexer = value, { "[", expr, "]" } ;
-// value   = literal | call | boolean | ident | "(", expr, ")" | array | prefix ;
+// indexer = value, { "[", expr, "]" | ".", ident, [ "(", args, ")" ] } ;
+// value   = literal | call | boolean | ident | "(", expr, ")" | array | object | prefix ;
 // call    = ident, "(", args, ")" ;
 // array   = "[", args, "]" ;
+// object  = "{", [ item, { ",", item }, [ "," ] ], "}" ;
+// item    = ( ident | string ), ":", expr ;
 // args    = [ expr, { ",", expr }, [ "," ] ] ;
 // prefix  = operator, expr ;
 // boolean = "true" | "false" ;
 
+// ParseOptions configures parsing, the zero value is the default behavior of Parse.
+type ParseOptions struct {
+	// Numbers selects how number literals are parsed. By default, all numbers are float64.
+	// With NumberModeInteger, integer literals in int64 range are int64, and the others are float64.
+	// With NumberModeDecimal, all number literals are exact *big.Rat decimals.
+	Numbers NumberMode
+}
+
 // Parse converts expression string to an AST, which can be evaluated.
 func Parse(input string) (ExprNode, error) {
+	return ParseWithOptions(input, ParseOptions{})
+}
+
+// ParseWithOptions converts expression string to an AST like Parse does, with the given options.
+func ParseWithOptions(input string, options ParseOptions) (ExprNode, error) {
 	s := NewTokenStream(input)
+	s.numbers = options.Numbers
 	expr, err := parseExpr(s, 0)
 	if err == nil && !s.Peek().Is(TokenKindEOF, nil) {
 		return ExprNode{}, unexpectedToken(s.Peek(), "operator")
@@ -28,7 +44,43 @@ func Parse(input string) (ExprNode, error) {
 	if tokenizerErr := s.Error(); tokenizerErr != nil {
 		return ExprNode{}, tokenizerErr
 	}
-	return expr, err
+	if err != nil {
+		return expr, err
+	}
+	return attachComments(expr, s.comments), nil
+}
+
+// ParseWithRecovery converts expression string to an AST like Parse does, but it doesn't stop
+// at the first syntax error. Parser skips input up to the next ',', ')' or ']' and continues,
+// so all syntax errors are reported at once. Invalid parts of the AST are NodeTypeError nodes.
+func ParseWithRecovery(input string) (ExprNode, []ParseError) {
+	return ParseWithRecoveryOptions(input, ParseOptions{})
+}
+
+// ParseWithRecoveryOptions converts expression string to an AST like ParseWithRecovery does, with the given options.
+func ParseWithRecoveryOptions(input string, options ParseOptions) (ExprNode, []ParseError) {
+	var parseErrors []ParseError
+	s := NewTokenStream(input)
+	s.numbers = options.Numbers
+	s.parseErrors = &parseErrors
+	expr, err := parseExpr(s, 0)
+	if err != nil {
+		expr, _ = recoverError(s, err)
+	}
+	if !s.Peek().Is(TokenKindEOF, nil) {
+		reportError(s, unexpectedToken(s.Peek(), "operator"))
+	}
+	if tokenizerErr, ok := s.Error().(ParseError); ok {
+		// unexpected eof errors are caused by the tokenizer error, report it instead
+		filtered := parseErrors[:0]
+		for _, parseErr := range parseErrors {
+			if !parseErr.Token.Is(TokenKindEOF, nil) {
+				filtered = append(filtered, parseErr)
+			}
+		}
+		parseErrors = append(filtered, tokenizerErr)
+	}
+	return attachComments(expr, s.comments), parseErrors
 }
 
 // MustParse returns an AST or panics if string cannot be parsed.
@@ -60,10 +112,13 @@ func parseExpr(s *TokenStream, minPrecedence int) (ExprNode, error) {
 func parseExprInner(s *TokenStream, lhs ExprNode, minPrecedence int) (ExprNode, error) {
 	operator, precedence, ok := peekOperator(s)
 	for ok && precedence >= minPrecedence {
-		s.Next()
+		token := s.Next()
 		if operator == "?" {
 			return parseTernaryIf(s, lhs)
 		}
+		if operator == "=>" {
+			return parseLambda(s, token, lhs)
+		}
 		rhs, err := parseIndexer(s)
 		if err != nil {
 			return ExprNode{}, err
@@ -89,23 +144,65 @@ func parseIndexer(s *TokenStream) (ExprNode, error) {
 		return ExprNode{}, err
 	}
 	res := value
-	for s.Peek().Is(TokenKindBracket, '[') {
+	for {
+		if s.Peek().Is(TokenKindBracket, '[') {
+			s.Next()
+			index, err := parseExpr(s, 0)
+			if err != nil {
+				return ExprNode{}, err
+			}
+			bracket, err := consumeBracket(s, ']')
+			if err != nil {
+				return ExprNode{}, err
+			}
+			pos, len := value.SourcePos, bracket.SourcePos+bracket.SourceLen-value.SourcePos
+			res = NewExprNodeOperator("[]", []ExprNode{res, index}, pos, len, OperatorTypeIndexer)
+		} else if s.Peek().Is(TokenKindOperator, ".") {
+			s.Next()
+			res, err = parseMember(s, res)
+			if err != nil {
+				return ExprNode{}, err
+			}
+		} else {
+			return res, nil
+		}
+	}
+}
+
+func parseMember(s *TokenStream, receiver ExprNode) (ExprNode, error) {
+	nameToken := s.Peek()
+	if nameToken.Kind != TokenKindIdentifier {
+		return ExprNode{}, unexpectedToken(nameToken, "member name")
+	}
+	s.Next()
+	name := NewExprNodeLiteral(nameToken.Value, nameToken.SourcePos, nameToken.SourceLen)
+
+	// method call: x.method(args)
+	if s.Peek().Is(TokenKindBracket, '(') {
 		s.Next()
-		index, err := parseExpr(s, 0)
+		args, err := parseArgs(s, ')')
 		if err != nil {
 			return ExprNode{}, err
 		}
-		bracket, err := consumeBracket(s, ']')
+		bracket, err := consumeBracket(s, ')')
 		if err != nil {
 			return ExprNode{}, err
 		}
-		pos, len := value.SourcePos, bracket.SourcePos+bracket.SourceLen-value.SourcePos
-		res = NewExprNodeOperator("[]", []ExprNode{res, index}, pos, len, OperatorTypeIndexer)
+		pos, len := receiver.SourcePos, bracket.SourcePos+bracket.SourceLen-receiver.SourcePos
+		args = append([]ExprNode{receiver, name}, args...)
+		return NewExprNodeOperator(".()", args, pos, len, OperatorTypeMethodCall), nil
 	}
-	return res, nil
+
+	// field: x.field
+	pos, len := receiver.SourcePos, nameToken.SourcePos+nameToken.SourceLen-receiver.SourcePos
+	return NewExprNodeOperator(".", []ExprNode{receiver, name}, pos, len, OperatorTypeMember), nil
 }
 
 func parseValue(s *TokenStream) (ExprNode, error) {
+	if isClosingToken(s.Peek()) {
+		// don't consume it, it's needed to close the enclosing brackets
+		return recoverError(s, unexpectedToken(s.Peek(), "value"))
+	}
 	token := s.Next()
 
 	switch token.Kind {
@@ -135,7 +232,9 @@ func parseValue(s *TokenStream) (ExprNode, error) {
 			// expression in brackets
 			expr, err := parseExpr(s, 0)
 			if err != nil {
-				return ExprNode{}, err
+				if expr, err = recoverError(s, err); err != nil {
+					return ExprNode{}, err
+				}
 			}
 			bracket, err := consumeBracket(s, ')')
 			if err != nil {
@@ -157,6 +256,19 @@ func parseValue(s *TokenStream) (ExprNode, error) {
 			}
 			pos, len := token.SourcePos, bracket.SourcePos+bracket.SourceLen-token.SourcePos
 			return NewExprNodeOperator("array", items, pos, len, OperatorTypeArray), nil
+
+		case '{':
+			// object, keys and values are interleaved in args
+			items, err := parseObjectItems(s)
+			if err != nil {
+				return ExprNode{}, err
+			}
+			bracket, err := consumeBracket(s, '}')
+			if err != nil {
+				return ExprNode{}, err
+			}
+			pos, len := token.SourcePos, bracket.SourcePos+bracket.SourceLen-token.SourcePos
+			return NewExprNodeOperator("object", items, pos, len, OperatorTypeObject), nil
 		}
 
 	case TokenKindOperator:
@@ -171,7 +283,7 @@ func parseValue(s *TokenStream) (ExprNode, error) {
 		return NewExprNodeOperator(token.Value.(string), []ExprNode{expr}, pos, len, OperatorTypePrefix), nil
 	}
 
-	return ExprNode{}, unexpectedToken(token, "value")
+	return recoverError(s, unexpectedToken(token, "value"))
 }
 
 func parseCall(s *TokenStream, nameToken ExprToken) (ExprNode, error) {
@@ -196,18 +308,81 @@ func parseArgs(s *TokenStream, until rune) ([]ExprNode, error) {
 	for !s.Peek().Is(TokenKindBracket, until) {
 		arg, err := parseExpr(s, defaultPrecedence(",", 2)+1)
 		if err != nil {
-			return args, err
+			if arg, err = recoverError(s, err); err != nil {
+				return args, err
+			}
 		}
 		args = append(args, arg)
-		if s.Peek().Is(TokenKindOperator, ",") {
-			s.Next()
-		} else if !s.Peek().Is(TokenKindBracket, until) {
-			return args, unexpectedToken(s.Peek(), "'"+string(until)+"'", "','")
+		if next, err := parseSeparator(s, until); !next || err != nil {
+			return args, err
 		}
 	}
 	return args, nil
 }
 
+func parseObjectItems(s *TokenStream) ([]ExprNode, error) {
+	items := []ExprNode{}
+	keys := map[string]bool{}
+	for !s.Peek().Is(TokenKindBracket, '}') {
+		key, value, err := parseObjectItem(s, keys)
+		if err != nil {
+			// skip the whole item
+			if _, err = recoverError(s, err); err != nil {
+				return items, err
+			}
+		} else {
+			items = append(items, key, value)
+		}
+		if next, err := parseSeparator(s, '}'); !next || err != nil {
+			return items, err
+		}
+	}
+	return items, nil
+}
+
+// parseObjectItem parses "key: value", keys are the keys of previous items, which can't be repeated.
+func parseObjectItem(s *TokenStream, keys map[string]bool) (ExprNode, ExprNode, error) {
+	// key is either an identifier or a string, both are stored as string literals
+	key := s.Peek()
+	if key.Kind != TokenKindIdentifier && key.Kind != TokenKindString {
+		return ExprNode{}, ExprNode{}, unexpectedToken(key, "key")
+	}
+	if keys[key.Value.(string)] {
+		return ExprNode{}, ExprNode{}, duplicateKey(key)
+	}
+	keys[key.Value.(string)] = true
+	s.Next()
+	if !s.Peek().Is(TokenKindOperator, ":") {
+		return ExprNode{}, ExprNode{}, unexpectedToken(s.Peek(), "':'")
+	}
+	s.Next()
+	value, err := parseExpr(s, defaultPrecedence(",", 2)+1)
+	if err != nil {
+		return ExprNode{}, ExprNode{}, err
+	}
+	return NewExprNodeLiteral(key.Value, key.SourcePos, key.SourceLen), value, nil
+}
+
+// parseSeparator consumes ',' between list items, it returns false when there are no more items.
+func parseSeparator(s *TokenStream, until rune) (bool, error) {
+	if s.Peek().Is(TokenKindOperator, ",") {
+		s.Next()
+		return true, nil
+	}
+	if s.Peek().Is(TokenKindBracket, until) {
+		return false, nil
+	}
+	if _, err := recoverError(s, unexpectedToken(s.Peek(), "'"+string(until)+"'", "','")); err != nil {
+		return false, err
+	}
+	// invalid input is skipped up to the next item, or the end of the list
+	if s.Peek().Is(TokenKindOperator, ",") {
+		s.Next()
+		return true, nil
+	}
+	return false, nil
+}
+
 func parseTernaryIf(s *TokenStream, condition ExprNode) (ExprNode, error) {
 	precedence := defaultPrecedence("?:", 3)
 	valueIfTrue, err := parseExpr(s, precedence+1)
@@ -227,6 +402,19 @@ func parseTernaryIf(s *TokenStream, condition ExprNode) (ExprNode, error) {
 	return NewExprNodeOperator("?:", args, pos, len, OperatorTypeTernary), nil
 }
 
+// parseLambda parses the body of a lambda, like x => x.price > 10, which extends as far as possible.
+func parseLambda(s *TokenStream, arrow ExprToken, param ExprNode) (ExprNode, error) {
+	if param.Type != NodeTypeVariable {
+		return ExprNode{}, invalidLambdaParam(arrow, param)
+	}
+	body, err := parseExpr(s, defaultPrecedence("=>", 2))
+	if err != nil {
+		return ExprNode{}, err
+	}
+	pos, len := param.SourcePos, body.SourcePos+body.SourceLen-param.SourcePos
+	return NewExprNodeOperator("=>", []ExprNode{param, body}, pos, len, OperatorTypeLambda), nil
+}
+
 func peekOperator(s *TokenStream) (string, int, bool) {
 	if token := s.Peek(); token.Kind == TokenKindOperator || token.Kind == TokenKindIdentifier {
 		name := token.Value.(string)
@@ -236,19 +424,73 @@ func peekOperator(s *TokenStream) (string, int, bool) {
 }
 
 func consumeBracket(s *TokenStream, bracket rune) (ExprToken, error) {
-	token := s.Next()
-	if token.Kind != TokenKindBracket {
-		return token, unexpectedToken(token, "'"+string(bracket)+"'")
+	token := s.Peek()
+	if token.Is(TokenKindBracket, bracket) {
+		return s.Next(), nil
+	}
+	err := unexpectedToken(token, "'"+string(bracket)+"'")
+	if token.Kind == TokenKindBracket {
+		err = unmatchedBracket(token, bracket)
+	}
+	if isClosingToken(token) {
+		// it may close one of the enclosing brackets, so it's not consumed on recovery
+		if !reportError(s, err) {
+			return token, err
+		}
+		return ExprToken{Kind: TokenKindBracket, Value: bracket, SourcePos: token.SourcePos}, nil
+	}
+	if _, err := recoverError(s, err); err != nil {
+		return token, err
 	}
-	if token.Value != bracket {
-		return token, fmt.Errorf("unmatched bracket: '%v', expecting '%v', pos: %d", string(token.Value.(rune)), string(bracket), token.SourcePos)
+	if s.Peek().Is(TokenKindBracket, bracket) {
+		return s.Next(), nil
 	}
-	return token, nil
+	return ExprToken{Kind: TokenKindBracket, Value: bracket, SourcePos: s.Peek().SourcePos}, nil
 }
 
-func unexpectedToken(token ExprToken, expected ...string) error {
-	if token.Is(TokenKindEOF, nil) {
-		return fmt.Errorf("unexpected eof, expecting %s", strings.Join(expected, ", "))
+func isClosingToken(token ExprToken) bool {
+	return token.Is(TokenKindEOF, nil) ||
+		token.Is(TokenKindBracket, ')') ||
+		token.Is(TokenKindBracket, ']') ||
+		token.Is(TokenKindBracket, '}')
+}
+
+// reportError records a syntax error when parsing with recovery, it returns false if recovery is disabled.
+// Only the first error at a position is kept, the rest are caused by it.
+func reportError(s *TokenStream, err error) bool {
+	parseErr, ok := err.(ParseError)
+	if !ok || s.parseErrors == nil {
+		return false
+	}
+	errors := *s.parseErrors
+	if len(errors) == 0 || errors[len(errors)-1].SourcePos != parseErr.SourcePos {
+		*s.parseErrors = append(errors, parseErr)
+	}
+	return true
+}
+
+// recoverError returns err as is, unless parsing with recovery. Then the error is reported,
+// input is skipped up to the next ',' or unmatched closing bracket, and an error node is returned instead.
+func recoverError(s *TokenStream, err error) (ExprNode, error) {
+	if !reportError(s, err) {
+		return ExprNode{}, err
+	}
+	parseErr := err.(ParseError)
+	end := parseErr.SourcePos + parseErr.SourceLen
+	depth := 0
+	for token := s.Peek(); !token.Is(TokenKindEOF, nil); token = s.Peek() {
+		if depth == 0 && (isClosingToken(token) || token.Is(TokenKindOperator, ",")) {
+			break
+		}
+		if token.Kind == TokenKindBracket {
+			if isClosingToken(token) {
+				depth--
+			} else {
+				depth++
+			}
+		}
+		s.Next()
+		end = token.SourcePos + token.SourceLen
 	}
-	return fmt.Errorf("unexpected token %v, expecting %s, pos: %d", token, strings.Join(expected, ", "), token.SourcePos)
+	return newExprNodeError(parseErr, parseErr.SourcePos, end-parseErr.SourcePos), nil
 }
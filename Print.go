@@ -3,9 +3,14 @@ package govaluate
 import (
 	"fmt"
 	"math"
+	"math/big"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
+	"unicode/utf8"
 )
 
 // ExprNodePrinter is an output builder for ExprNode.
@@ -14,6 +19,16 @@ type ExprNodePrinter struct {
 	nodeHandler func(ExprNode, *ExprNodePrinter) error
 	output      strings.Builder
 	err         error
+
+	// column is the length of the current line, indent is the current indentation level,
+	// reserve is the length of a suffix which will follow the current node on the same line
+	column  int
+	indent  int
+	reserve int
+
+	// indentUnit is a single level of indentation, newlinePending is set after a line comment
+	indentUnit     string
+	newlinePending bool
 }
 
 // PrintConfig is used to override default behavior when printing an expression with a default node handler.
@@ -26,6 +41,19 @@ type PrintConfig struct {
 	// Default handler formats number with strconv.FormatFloat(value, 'f', -1, 64).
 	FormatNumberLiteral func(float64) string
 
+	// FormatIntegerLiteral overrides integer literal output, integers of all Go types are converted to int64.
+	// Default handler formats number with strconv.FormatInt(value, 10).
+	FormatIntegerLiteral func(int64) string
+
+	// FormatDecimalLiteral overrides decimal literal output.
+	// Default handler formats number with all its fractional digits, like *big.Rat.FloatString,
+	// and returns an error for a fraction with infinite decimal representation, like 1/3.
+	FormatDecimalLiteral func(*big.Rat) string
+
+	// Numbers is the mode the output will be parsed with. With NumberModeInteger, whole float64 numbers
+	// are printed with a fractional part, e.g. 2.0, so they are not parsed back as integers.
+	Numbers NumberMode
+
 	// FormatStringLiteral overrides string literal output.
 	// Default handler returns quoted value with other quotes and newlines escaped with backslash (\).
 	FormatStringLiteral func(string) string
@@ -60,12 +88,64 @@ type PrintConfig struct {
 	// Ternary if (?:) is printed like this: condition ? then : else.
 	// All other operators are printed as function calls: square(x), now(), pow(x, y).
 	Operators map[string]func(args []ExprNode, output *ExprNodePrinter) error
+
+	// Width enables formatting: an operator which doesn't fit into the line of this width is broken into lines.
+	// Chains of && and || are printed one operand per line, ternary branches, call arguments and items of arrays
+	// and objects are printed on separate indented lines. Only whitespace and brackets are added, so the output
+	// parses back to the same expression, and formatting it again doesn't change it.
+	// Zero means single line output.
+	Width int
+
+	// Indent is a single level of indentation when Width is set, two spaces by default.
+	Indent string
 }
 
 // AppendString appends a token to output as is.
 func (b *ExprNodePrinter) AppendString(token string) {
+	if b.err == nil && b.newlinePending && token != "" {
+		// line comment must be followed by a new line
+		b.newlinePending = false
+		if token[0] != '\n' {
+			b.appendNewline()
+		}
+	}
 	if b.err == nil {
 		b.output.WriteString(token)
+		if idx := strings.LastIndexByte(token, '\n'); idx >= 0 {
+			b.column = utf8.RuneCountInString(token[idx+1:])
+		} else {
+			b.column += utf8.RuneCountInString(token)
+		}
+	}
+}
+
+// appendNewline starts a new line, indented to the current level.
+func (b *ExprNodePrinter) appendNewline() {
+	indent := b.indentUnit
+	if indent == "" {
+		indent = defaultIndent
+	}
+	b.AppendString("\n" + strings.Repeat(indent, b.indent))
+}
+
+// appendComments appends either leading or trailing comments of a node.
+func (b *ExprNodePrinter) appendComments(node ExprNode, trailing bool) {
+	for _, comment := range node.Comments {
+		if comment.Trailing != trailing {
+			continue
+		}
+		if trailing {
+			b.AppendString(" ")
+		}
+		if comment.Block {
+			b.AppendString("/*" + comment.Text + "*/")
+		} else {
+			b.AppendString("//" + comment.Text)
+			b.newlinePending = true
+		}
+		if !trailing && comment.Block {
+			b.AppendString(" ")
+		}
 	}
 }
 
@@ -95,27 +175,109 @@ func (expr ExprNode) PrintWithHandler(nodeHandler func(ExprNode, *ExprNodePrinte
 
 func defaultNodeHandler(config PrintConfig) func(ExprNode, *ExprNodePrinter) error {
 	return func(node ExprNode, output *ExprNodePrinter) error {
+		output.indentUnit = config.Indent
+		output.appendComments(node, false)
+		var err error
 		switch node.Type {
 		case NodeTypeLiteral:
-			return literal(node.Value, output, &config)
+			err = literal(node.Value, output, &config)
 		case NodeTypeVariable:
-			return variable(node.Name, output, &config)
+			err = variable(node.Name, output, &config)
 		case NodeTypeOperator:
-			return operator(node.Name, node.Args, output, &config)
+			if config.Width > 0 {
+				err = formatOperator(node, output, &config)
+			} else {
+				err = operator(node.Name, node.Args, output, &config, false)
+			}
+		default:
+			err = fmt.Errorf("unexpected node: %v", node)
 		}
-		return fmt.Errorf("unexpected node: %v", node)
+		output.appendComments(node, true)
+		return err
+	}
+}
+
+// formatOperator prints an operator on a single line if it fits, and breaks it into lines otherwise.
+func formatOperator(node ExprNode, output *ExprNodePrinter, config *PrintConfig) error {
+	// comments of the node itself are printed by the node handler
+	bare := node
+	bare.Comments = nil
+	flat, fits, err := config.fits(bare, output, 0)
+	if err != nil {
+		return err
+	}
+	if fits {
+		output.AppendString(flat)
+		return nil
 	}
+	return operator(node.Name, node.Args, output, config, true)
+}
+
+const defaultIndent = "  "
+
+func (config *PrintConfig) indentUnit() string {
+	if config.Indent == "" {
+		return defaultIndent
+	}
+	return config.Indent
+}
+
+// flat returns a copy of config without formatting.
+func (config *PrintConfig) flat() PrintConfig {
+	flatConfig := *config
+	flatConfig.Width = 0
+	return flatConfig
+}
+
+// fits prints the node on a single line, and checks if it fits into the current line with extra chars.
+// A node with line comments never fits.
+func (config *PrintConfig) fits(node ExprNode, output *ExprNodePrinter, extra int) (string, bool, error) {
+	printer := &ExprNodePrinter{nodeHandler: defaultNodeHandler(config.flat())}
+	printer.AppendNode(node)
+	if printer.err != nil {
+		return "", false, printer.err
+	}
+	flat := printer.output.String()
+	if printer.newlinePending || strings.ContainsRune(flat, '\n') {
+		return flat, false, nil
+	}
+	column := output.column
+	if output.newlinePending {
+		// the node starts on a new line after a line comment
+		column = utf8.RuneCountInString(config.indentUnit()) * output.indent
+	}
+	return flat, column+utf8.RuneCountInString(flat)+extra+output.reserve <= config.Width, nil
 }
 
 func literal(value interface{}, output *ExprNodePrinter, config *PrintConfig) error {
+	if intValue, ok := integerValue(value); ok {
+		output.AppendString(integerLiteralString(intValue, config))
+		return nil
+	}
 	var literal string
 	switch value.(type) {
 	case bool:
 		literal = boolLiteral(value.(bool), config)
 	case float64:
 		literal = numberLiteral(value.(float64), config)
+	case time.Duration:
+		literal = value.(time.Duration).String()
+	case time.Time:
+		// there are no time literals, the time is parsed back from RFC 3339
+		literal = "date(" + stringLiteral(value.(time.Time).Format(time.RFC3339Nano), config) + ")"
+	case *big.Rat:
+		var err error
+		if literal, err = decimalLiteralString(value.(*big.Rat), config); err != nil {
+			return err
+		}
 	case string:
 		literal = stringLiteral(value.(string), config)
+	case *regexp.Regexp:
+		literal = stringLiteral(value.(*regexp.Regexp).String(), config)
+	case []interface{}:
+		return arrayLiteral(value.([]interface{}), output, config)
+	case map[string]interface{}:
+		return objectLiteral(value.(map[string]interface{}), output, config)
 	default:
 		return fmt.Errorf("unsupported literal type: %v", value)
 	}
@@ -137,7 +299,29 @@ func numberLiteral(value float64, config *PrintConfig) string {
 	if config.FormatNumberLiteral != nil {
 		return config.FormatNumberLiteral(value)
 	}
-	return strconv.FormatFloat(value, 'f', -1, 64)
+	literal := strconv.FormatFloat(value, 'f', -1, 64)
+	if config.Numbers == NumberModeInteger && value == math.Trunc(value) && !math.IsInf(value, 0) {
+		literal += ".0"
+	}
+	return literal
+}
+
+func integerLiteralString(value int64, config *PrintConfig) string {
+	if config.FormatIntegerLiteral != nil {
+		return config.FormatIntegerLiteral(value)
+	}
+	return strconv.FormatInt(value, 10)
+}
+
+func decimalLiteralString(value *big.Rat, config *PrintConfig) (string, error) {
+	if config.FormatDecimalLiteral != nil {
+		return config.FormatDecimalLiteral(value), nil
+	}
+	literal, ok := decimalString(value)
+	if !ok {
+		return "", fmt.Errorf("unsupported literal: infinite decimal %s", value.RatString())
+	}
+	return literal, nil
 }
 
 func stringLiteral(value string, config *PrintConfig) string {
@@ -153,6 +337,43 @@ func stringLiteral(value string, config *PrintConfig) string {
 	return "\"" + escapedValue + "\""
 }
 
+func arrayLiteral(value []interface{}, output *ExprNodePrinter, config *PrintConfig) error {
+	output.AppendString("[")
+	for idx, item := range value {
+		if idx > 0 {
+			output.AppendString(", ")
+		}
+		if err := literal(item, output, config); err != nil {
+			return err
+		}
+	}
+	output.AppendString("]")
+	return nil
+}
+
+func objectLiteral(value map[string]interface{}, output *ExprNodePrinter, config *PrintConfig) error {
+	// sort keys to make output stable
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	output.AppendString("{")
+	for idx, key := range keys {
+		if idx > 0 {
+			output.AppendString(", ")
+		}
+		output.AppendString(stringLiteral(key, config))
+		output.AppendString(": ")
+		if err := literal(value[key], output, config); err != nil {
+			return err
+		}
+	}
+	output.AppendString("}")
+	return nil
+}
+
 func variable(name string, output *ExprNodePrinter, config *PrintConfig) error {
 	variable := name
 	if config.FormatVariable != nil {
@@ -162,7 +383,7 @@ func variable(name string, output *ExprNodePrinter, config *PrintConfig) error {
 	return nil
 }
 
-func operator(name string, args []ExprNode, output *ExprNodePrinter, config *PrintConfig) error {
+func operator(name string, args []ExprNode, output *ExprNodePrinter, config *PrintConfig, wrap bool) error {
 	arity := len(args)
 	mappedName := config.mappedName(name, arity)
 
@@ -170,29 +391,108 @@ func operator(name string, args []ExprNode, output *ExprNodePrinter, config *Pri
 		return fn(args, output)
 	}
 
+	// array: [a, b, c]
+	if mappedName == "array" {
+		appendItems(output, config, "[", "]", arity, wrap, func(idx int) {
+			output.AppendNode(args[idx])
+		})
+		return nil
+	}
+
+	// object: {"key": value}
+	if mappedName == "object" && arity%2 == 0 {
+		appendItems(output, config, "{", "}", arity/2, wrap, func(idx int) {
+			output.AppendNode(args[idx*2])
+			output.AppendString(": ")
+			output.AppendNode(args[idx*2+1])
+		})
+		return nil
+	}
+
+	// indexer: x[i]
+	if mappedName == "[]" && arity == 2 {
+		printPostfixReceiver(args[0], output, config)
+		output.AppendString("[")
+		output.AppendNode(args[1])
+		output.AppendString("]")
+		return nil
+	}
+
+	// member: x.field
+	if mappedName == "." && arity == 2 && isMemberName(args[1]) {
+		printPostfixReceiver(args[0], output, config)
+		output.AppendString(".")
+		output.AppendString(args[1].Value.(string))
+		return nil
+	}
+
+	// method call: x.method(a, b)
+	if mappedName == ".()" && arity >= 2 && isMemberName(args[1]) {
+		printPostfixReceiver(args[0], output, config)
+		output.AppendString(".")
+		output.AppendString(args[1].Value.(string))
+		appendItems(output, config, "(", ")", arity-2, wrap, func(idx int) {
+			output.AppendNode(args[idx+2])
+		})
+		return nil
+	}
+
 	// binary operator: x + y
 	infix := config.isInfix(name, arity)
+	if infix && wrap && (mappedName == "&&" || mappedName == "||") {
+		// chain of && or ||, one operand per line
+		selfPrecedence := config.precedence(name, arity)
+		operands := config.chainOperands(name, args)
+		reserve := output.reserve
+		defer func() { output.reserve = reserve }()
+		for idx, operand := range operands {
+			if idx > 0 {
+				output.AppendString(" ")
+				output.AppendString(mappedName)
+				output.appendNewline()
+			}
+			if idx < len(operands)-1 {
+				// operator follows the operand
+				output.reserve = len(mappedName) + 1
+			} else {
+				output.reserve = reserve
+			}
+			operandPrecedence := config.precedenceForNode(operand)
+			brackets := operandPrecedence < selfPrecedence || idx > 0 && operandPrecedence == selfPrecedence
+			if !brackets && config.isChain(operand) {
+				// a nested chain of the other operator is bracketed, if it's broken too
+				_, fits, err := config.fits(operand, output, 0)
+				if err != nil {
+					return err
+				}
+				brackets = !fits
+			}
+			appendOperand(operand, brackets, output, config)
+		}
+		return nil
+	}
 	if infix {
 		selfPrecedence := config.precedence(name, arity)
 		leftPrecedence := config.precedenceForNode(args[0])
 		rightPrecedence := config.precedenceForNode(args[1])
-		if leftPrecedence < selfPrecedence {
-			output.AppendString("(")
-		}
-		output.AppendNode(args[0])
-		if leftPrecedence < selfPrecedence {
-			output.AppendString(")")
+		reserve := output.reserve
+		if wrap {
+			// the operator and the right operand follow the left one, if it fits
+			right, err := args[1].PrintWithHandler(defaultNodeHandler(config.flat()))
+			if err != nil {
+				return err
+			}
+			output.reserve = len(mappedName) + 2 + utf8.RuneCountInString(right)
+			if rightPrecedence <= selfPrecedence {
+				output.reserve += 2
+			}
 		}
+		appendOperand(args[0], leftPrecedence < selfPrecedence, output, config)
+		output.reserve = reserve
 		output.AppendString(" ")
 		output.AppendString(mappedName)
 		output.AppendString(" ")
-		if rightPrecedence <= selfPrecedence {
-			output.AppendString("(")
-		}
-		output.AppendNode(args[1])
-		if rightPrecedence <= selfPrecedence {
-			output.AppendString(")")
-		}
+		appendOperand(args[1], rightPrecedence <= selfPrecedence, output, config)
 		return nil
 	}
 
@@ -202,13 +502,7 @@ func operator(name string, args []ExprNode, output *ExprNodePrinter, config *Pri
 		selfPrecedence := config.precedence(name, arity)
 		rightPrecedence := config.precedenceForNode(args[0])
 		output.AppendString(mappedName)
-		if rightPrecedence < selfPrecedence {
-			output.AppendString("(")
-		}
-		output.AppendNode(args[0])
-		if rightPrecedence < selfPrecedence {
-			output.AppendString(")")
-		}
+		appendOperand(args[0], rightPrecedence < selfPrecedence, output, config)
 		return nil
 	}
 
@@ -218,43 +512,128 @@ func operator(name string, args []ExprNode, output *ExprNodePrinter, config *Pri
 		conditionPrecedence := config.precedenceForNode(args[0])
 		thenPrecedence := config.precedenceForNode(args[1])
 		elsePrecedence := config.precedenceForNode(args[2])
-		if conditionPrecedence <= selfPrecedence {
-			output.AppendString("(")
-		}
-		output.AppendNode(args[0])
-		if conditionPrecedence <= selfPrecedence {
-			output.AppendString(")")
+		reserve := output.reserve
+		if wrap {
+			// condition and then branch are followed by a new line
+			output.reserve = 0
 		}
-		output.AppendString(" ? ")
-		if thenPrecedence <= selfPrecedence {
-			output.AppendString("(")
-		}
-		output.AppendNode(args[1])
-		if thenPrecedence <= selfPrecedence {
-			output.AppendString(")")
+		appendOperand(args[0], conditionPrecedence <= selfPrecedence, output, config)
+		if wrap {
+			// branches on separate lines:
+			// x
+			//   ? y
+			//   : z
+			output.indent++
+			output.appendNewline()
+			output.AppendString("? ")
+		} else {
+			output.AppendString(" ? ")
 		}
-		output.AppendString(" : ")
-		if elsePrecedence < selfPrecedence {
-			output.AppendString("(")
+		appendOperand(args[1], thenPrecedence <= selfPrecedence, output, config)
+		if wrap {
+			output.appendNewline()
+			output.AppendString(": ")
+		} else {
+			output.AppendString(" : ")
 		}
-		output.AppendNode(args[2])
-		if elsePrecedence < selfPrecedence {
-			output.AppendString(")")
+		output.reserve = reserve
+		appendOperand(args[2], elsePrecedence < selfPrecedence, output, config)
+		if wrap {
+			output.indent--
 		}
 		return nil
 	}
 
 	// function call: fn(a, b, c)
 	output.AppendString(mappedName)
-	output.AppendString("(")
-	for idx, arg := range args {
-		if idx > 0 {
-			output.AppendString(", ")
+	appendItems(output, config, "(", ")", arity, wrap, func(idx int) {
+		output.AppendNode(args[idx])
+	})
+	return nil
+}
+
+// appendItems appends a list of items separated with commas,
+// either on the same line, or each on a separate indented line, if wrap is set.
+func appendItems(output *ExprNodePrinter, config *PrintConfig, open, close string, count int, wrap bool, item func(int)) {
+	output.AppendString(open)
+	if wrap && count > 0 {
+		reserve := output.reserve
+		output.indent++
+		for idx := 0; idx < count; idx++ {
+			if idx > 0 {
+				output.AppendString(",")
+			}
+			output.appendNewline()
+			// items are followed by a comma, except the last one
+			output.reserve = 0
+			if idx < count-1 {
+				output.reserve = 1
+			}
+			item(idx)
+		}
+		output.indent--
+		output.reserve = reserve
+		output.appendNewline()
+	} else {
+		for idx := 0; idx < count; idx++ {
+			if idx > 0 {
+				output.AppendString(", ")
+			}
+			item(idx)
+		}
+	}
+	output.AppendString(close)
+}
+
+// appendOperand appends an operand, in brackets if needed.
+// When formatting, an operand which doesn't fit is put on separate indented lines between the brackets.
+// Comments of a bracketed operand are put outside of the brackets.
+func appendOperand(operand ExprNode, brackets bool, output *ExprNodePrinter, config *PrintConfig) {
+	if !brackets {
+		output.AppendNode(operand)
+		return
+	}
+	output.appendComments(operand, false)
+	defer output.appendComments(operand, true)
+	operand.Comments = nil
+
+	wrap := false
+	if config.Width > 0 {
+		_, fits, err := config.fits(operand, output, 2)
+		if err != nil {
+			output.AppendNode(operand)
+			return
 		}
-		output.AppendNode(arg)
+		wrap = !fits
+	}
+	output.AppendString("(")
+	if wrap {
+		reserve := output.reserve
+		output.reserve = 0
+		output.indent++
+		output.appendNewline()
+		output.AppendNode(operand)
+		output.indent--
+		output.appendNewline()
+		output.reserve = reserve
+	} else {
+		output.AppendNode(operand)
 	}
 	output.AppendString(")")
-	return nil
+}
+
+func printPostfixReceiver(receiver ExprNode, output *ExprNodePrinter, config *PrintConfig) {
+	appendOperand(receiver, !config.isPrimary(receiver), output, config)
+}
+
+// isMemberName returns true if node is a string literal that can be printed as x.name
+func isMemberName(node ExprNode) bool {
+	name, ok := node.Value.(string)
+	if node.Type != NodeTypeLiteral || !ok {
+		return false
+	}
+	token := tokenizeIdentifier(name)
+	return token.SourceLen > 0 && token.SourceLen == len(name)
 }
 
 func isSpecial(name string) bool {
@@ -286,14 +665,55 @@ func (config *PrintConfig) isInfix(operator string, arity int) bool {
 	if infix, found := config.InfixOperators[mappedName]; found {
 		return infix
 	}
+	switch mappedName {
+	case "[]", ".", ".()":
+		// indexer and member access are printed as postfix: x[i], x.field
+		return false
+	}
 	return isSpecial(mappedName) || mappedName == "in"
 }
 
+// isChain returns true if node is && or || operator printed in infix notation.
+func (config *PrintConfig) isChain(node ExprNode) bool {
+	if node.Type != NodeTypeOperator || !config.isInfix(node.Name, len(node.Args)) {
+		return false
+	}
+	mappedName := config.mappedName(node.Name, len(node.Args))
+	return mappedName == "&&" || mappedName == "||"
+}
+
+// chainOperands collects operands of left-associative chain of the same operator, e.g. a && b && c.
+func (config *PrintConfig) chainOperands(name string, args []ExprNode) []ExprNode {
+	left := args[0]
+	if left.Type == NodeTypeOperator && left.Name == name && len(left.Args) == 2 {
+		return append(config.chainOperands(name, left.Args), args[1])
+	}
+	return []ExprNode{left, args[1]}
+}
+
+// isPrimary returns true if node is printed as a single operand (a literal, variable, call, etc),
+// so that it can be followed by a postfix operator without brackets.
+func (config *PrintConfig) isPrimary(node ExprNode) bool {
+	if node.Type != NodeTypeOperator {
+		return true
+	}
+	arity := len(node.Args)
+	mappedName := config.mappedName(node.Name, arity)
+	if _, ok := config.Operators[mappedName]; ok {
+		// custom output, can not tell
+		return false
+	}
+	infix := config.isInfix(node.Name, arity)
+	prefix := arity == 1 && isSpecial(mappedName)
+	ternary := mappedName == "?:" && arity == 3
+	return !infix && !prefix && !ternary
+}
+
 func (config *PrintConfig) precedenceForNode(node ExprNode) int {
-	if node.Type == NodeTypeOperator {
+	if !config.isPrimary(node) {
 		return config.precedence(node.Name, len(node.Args))
 	}
-	// variable and literal have max precedence
+	// variable, literal and call-like operators have max precedence
 	return math.MaxInt32
 }
 
@@ -312,7 +732,7 @@ func defaultPrecedence(operator string, arity int) int {
 	switch operator {
 	case ",":
 		return 0
-	case "?:", "?", ":":
+	case "?:", "?", ":", "=>":
 		return 1
 	case "??":
 		return 2
@@ -10,7 +10,10 @@ func (expr ExprNode) Reduce(params EvalParams, optimizers map[string]Optimizer)
 		return expr, nil
 
 	case NodeTypeVariable:
-		value, ok := params.Variables[expr.Name]
+		value, ok, err := params.resolveVariable(expr)
+		if err != nil {
+			return expr, err
+		}
 		if !ok {
 			// variable is unknown, return as is
 			return expr, nil
@@ -24,7 +27,7 @@ func (expr ExprNode) Reduce(params EvalParams, optimizers map[string]Optimizer)
 
 		for _, v := range node.Vars() {
 			if v == expr.Name {
-				return ExprNode{}, fmt.Errorf("variable can not refer to itself: %v [pos=%d; len=%d]", expr.Name, expr.SourcePos, expr.SourceLen)
+				return ExprNode{}, selfReferenceError(expr)
 			}
 		}
 		node.SourcePos = expr.SourcePos
@@ -40,8 +43,20 @@ func (expr ExprNode) Reduce(params EvalParams, optimizers map[string]Optimizer)
 		// reduce arguments
 		reducedArgs := make([]ExprNode, len(expr.Args))
 		allArgsKnown := true
+		paramIdx, bodyIdx, isLambda := lambdaParams(expr)
 		for idx, arg := range expr.Args {
-			reducedArg, err := arg.Reduce(params, optimizers)
+			argParams := params
+			if isLambda && idx >= paramIdx && idx < bodyIdx {
+				// lambda parameters are kept as is, so the lambda is never folded
+				reducedArgs[idx] = arg
+				allArgsKnown = false
+				continue
+			}
+			if isLambda && idx == bodyIdx {
+				// lambda parameters shadow variables of the same name in the body
+				argParams.scope = paramScope(expr.Args[paramIdx:bodyIdx], params.scope)
+			}
+			reducedArg, err := arg.Reduce(argParams, optimizers)
 			if err != nil {
 				return expr, err
 			}
@@ -53,12 +68,17 @@ func (expr ExprNode) Reduce(params EvalParams, optimizers map[string]Optimizer)
 
 		_, operatorKnown := params.Operators[expr.Name]
 		if allArgsKnown && operatorKnown {
-			// all arguments are known, perform the operation
-			value, err := expr.Eval(params)
+			// all arguments are known, perform the operation, unless it depends on the clock
+			clock := &clockRecorder{clock: clockOrDefault(params.Clock)}
+			clockParams := params
+			clockParams.Clock = clock
+			value, err := expr.Eval(clockParams)
 			if err != nil {
 				return expr, err
 			}
-			return NewExprNodeLiteral(value, expr.SourcePos, expr.SourceLen), nil
+			if !clock.used {
+				return NewExprNodeLiteral(value, expr.SourcePos, expr.SourceLen), nil
+			}
 		}
 
 		expr.Args = reducedArgs
@@ -68,6 +88,10 @@ func (expr ExprNode) Reduce(params EvalParams, optimizers map[string]Optimizer)
 		}
 
 		return expr, nil
+
+	case NodeTypeError:
+		// invalid part of expression, keep it as is
+		return expr, nil
 	}
 	return expr, fmt.Errorf("bad node type: %v", expr)
 }
@@ -0,0 +1,59 @@
+package govaluate
+
+// VariableResolver provides variable values on demand, so only the variables
+// referenced by an expression are fetched.
+type VariableResolver interface {
+	// Resolve returns the value of the variable, false if it's not defined,
+	// or an error if the value could not be fetched.
+	Resolve(name string) (interface{}, bool, error)
+}
+
+// MapResolver resolves variables from a map.
+type MapResolver map[string]interface{}
+
+func (r MapResolver) Resolve(name string) (interface{}, bool, error) {
+	value, ok := r[name]
+	return value, ok, nil
+}
+
+// ParametersResolver adapts legacy Parameters to VariableResolver.
+// As Parameters.Get returns an error for missing parameters, any error means the variable is not defined.
+func ParametersResolver(parameters Parameters) VariableResolver {
+	return parametersResolver{parameters}
+}
+
+type parametersResolver struct {
+	parameters Parameters
+}
+
+func (r parametersResolver) Resolve(name string) (interface{}, bool, error) {
+	value, err := r.parameters.Get(name)
+	if err != nil {
+		return nil, false, nil
+	}
+	return value, true, nil
+}
+
+// resolveVariable looks up the variable in lambda parameters, then in Variables, then in Resolver.
+func (params EvalParams) resolveVariable(expr ExprNode) (interface{}, bool, error) {
+	if value, known, found := params.scope.lookup(expr.Name); found {
+		return value, known, nil
+	}
+	if value, ok := params.Variables[expr.Name]; ok {
+		return value, true, nil
+	}
+	if params.Resolver == nil {
+		return nil, false, nil
+	}
+	value, ok, err := params.Resolver.Resolve(expr.Name)
+	if err != nil {
+		return nil, false, EvalError{
+			Message:   err.Error(),
+			ArgIndex:  -1,
+			SourcePos: expr.SourcePos,
+			SourceLen: expr.SourceLen,
+			Cause:     err,
+		}
+	}
+	return value, ok, nil
+}
@@ -0,0 +1,445 @@
+package govaluate
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLDialect describes how an expression is converted to SQL, see ExprNode.ToSQL.
+// Predefined dialects can be copied and modified to support other databases.
+type SQLDialect struct {
+	// QuoteIdentifier quotes variable names, which are used as column names.
+	QuoteIdentifier func(name string) string
+
+	// QuoteString quotes string literals.
+	QuoteString func(value string) string
+
+	// FormatBool formats boolean literals.
+	FormatBool func(value bool) string
+
+	// FormatTime formats time literals. If it's nil, times are quoted strings in RFC 3339 format.
+	FormatTime func(value time.Time) string
+
+	// Placeholder returns a placeholder of n-th bind argument (starting from 1), e.g. ? or $1.
+	Placeholder func(n int) string
+
+	// InfixOperators maps binary operators to SQL operators, e.g. && -> AND.
+	InfixOperators map[string]string
+
+	// Functions maps operators and functions to SQL functions, e.g. ?? -> COALESCE, lower -> LOWER.
+	Functions map[string]string
+}
+
+// SQLDialectPostgreSQL is a dialect of PostgreSQL.
+var SQLDialectPostgreSQL = SQLDialect{
+	QuoteIdentifier: sqlQuoter('"', ""),
+	QuoteString:     sqlQuoter('\'', ""),
+	FormatBool:      sqlBool("TRUE", "FALSE"),
+	FormatTime:      sqlTime("TIMESTAMP WITH TIME ZONE '2006-01-02 15:04:05.999999Z07:00'", nil),
+	Placeholder:     sqlNumberedPlaceholder,
+	InfixOperators: sqlInfixOperators(map[string]string{
+		"^":  "#",
+		"=~": "~",
+		"!~": "!~",
+	}),
+	Functions: sqlFunctions(map[string]string{
+		"len": "LENGTH",
+		"min": "LEAST",
+		"max": "GREATEST",
+	}),
+}
+
+// SQLDialectMySQL is a dialect of MySQL.
+var SQLDialectMySQL = SQLDialect{
+	QuoteIdentifier: sqlQuoter('`', ""),
+	QuoteString:     sqlQuoter('\'', "\\"),
+	FormatBool:      sqlBool("TRUE", "FALSE"),
+	FormatTime:      sqlTime("TIMESTAMP '2006-01-02 15:04:05.999999'", time.UTC),
+	Placeholder:     sqlQuestionPlaceholder,
+	InfixOperators: sqlInfixOperators(map[string]string{
+		"^":  "^",
+		"=~": "REGEXP",
+		"!~": "NOT REGEXP",
+	}),
+	Functions: sqlFunctions(map[string]string{
+		"len": "CHAR_LENGTH",
+		"min": "LEAST",
+		"max": "GREATEST",
+	}),
+}
+
+// SQLDialectSQLite is a dialect of SQLite. Regular expressions require REGEXP function to be defined.
+var SQLDialectSQLite = SQLDialect{
+	QuoteIdentifier: sqlQuoter('"', ""),
+	QuoteString:     sqlQuoter('\'', ""),
+	FormatBool:      sqlBool("1", "0"),
+	FormatTime:      sqlTime("'2006-01-02 15:04:05.999'", time.UTC),
+	Placeholder:     sqlQuestionPlaceholder,
+	InfixOperators: sqlInfixOperators(map[string]string{
+		"=~": "REGEXP",
+		"!~": "NOT REGEXP",
+	}),
+	Functions: sqlFunctions(map[string]string{
+		"len": "LENGTH",
+		"min": "MIN",
+		"max": "MAX",
+	}),
+}
+
+func sqlQuoter(quote rune, escapeChars string) func(string) string {
+	q := string(quote)
+	replacements := []string{q, q + q}
+	for _, ch := range escapeChars {
+		replacements = append(replacements, string(ch), `\`+string(ch))
+	}
+	replacer := strings.NewReplacer(replacements...)
+	return func(value string) string {
+		return q + replacer.Replace(value) + q
+	}
+}
+
+func sqlBool(trueLiteral, falseLiteral string) func(bool) string {
+	return func(value bool) string {
+		if value {
+			return trueLiteral
+		}
+		return falseLiteral
+	}
+}
+
+// sqlTime formats times with a layout, converted to a location, unless it's nil.
+func sqlTime(layout string, location *time.Location) func(time.Time) string {
+	return func(value time.Time) string {
+		if location != nil {
+			value = value.In(location)
+		}
+		return value.Format(layout)
+	}
+}
+
+func sqlQuestionPlaceholder(int) string {
+	return "?"
+}
+
+func sqlNumberedPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func sqlInfixOperators(dialectOperators map[string]string) map[string]string {
+	operators := map[string]string{
+		"==": "=",
+		"!=": "<>",
+		"<":  "<",
+		"<=": "<=",
+		">":  ">",
+		">=": ">=",
+		"&&": "AND",
+		"||": "OR",
+		"+":  "+",
+		"-":  "-",
+		"*":  "*",
+		"/":  "/",
+		"%":  "%",
+		"&":  "&",
+		"|":  "|",
+		"<<": "<<",
+		">>": ">>",
+	}
+	for name, sqlName := range dialectOperators {
+		operators[name] = sqlName
+	}
+	return operators
+}
+
+func sqlFunctions(dialectFunctions map[string]string) map[string]string {
+	functions := map[string]string{
+		"**":    "POWER",
+		"??":    "COALESCE",
+		"abs":   "ABS",
+		"ceil":  "CEIL",
+		"floor": "FLOOR",
+		"round": "ROUND",
+		"sqrt":  "SQRT",
+		"lower": "LOWER",
+		"upper": "UPPER",
+		"trim":  "TRIM",
+	}
+	for name, sqlName := range dialectFunctions {
+		functions[name] = sqlName
+	}
+	return functions
+}
+
+// ToSQL converts the expression to an SQL condition, variables are used as column names.
+// Ternary if is converted to CASE WHEN, "in" to IN (...), and "??" to COALESCE.
+// Time literals are timestamps, MySQL and SQLite timestamps are in UTC.
+// An error is returned for operators the dialect doesn't support, e.g. member access or custom functions,
+// and for durations, as databases don't agree on interval types.
+func (expr ExprNode) ToSQL(dialect SQLDialect) (string, error) {
+	return expr.PrintWithHandler(sqlNodeHandler(dialect, nil))
+}
+
+// ToSQLWithArgs converts the expression to SQL like ToSQL does, but literals are replaced with placeholders,
+// and their values are returned as bind arguments, in order. Result can be passed to database/sql as is.
+func (expr ExprNode) ToSQLWithArgs(dialect SQLDialect) (string, []interface{}, error) {
+	args := []interface{}{}
+	query, err := expr.PrintWithHandler(sqlNodeHandler(dialect, &args))
+	if err != nil {
+		return "", nil, err
+	}
+	return query, args, nil
+}
+
+// sqlNodeHandler returns a handler printing SQL, literals are collected to args, unless it's nil.
+func sqlNodeHandler(dialect SQLDialect, args *[]interface{}) func(ExprNode, *ExprNodePrinter) error {
+	return func(node ExprNode, output *ExprNodePrinter) error {
+		switch node.Type {
+		case NodeTypeLiteral:
+			return sqlLiteral(node.Value, output, dialect, args)
+		case NodeTypeVariable:
+			output.AppendString(dialect.QuoteIdentifier(node.Name))
+			return nil
+		case NodeTypeOperator:
+			return sqlOperator(node, output, dialect)
+		}
+		return fmt.Errorf("unexpected node: %v", node)
+	}
+}
+
+// sqlLiteral appends a literal, or its placeholder if args are collected.
+func sqlLiteral(value interface{}, output *ExprNodePrinter, dialect SQLDialect, args *[]interface{}) error {
+	var literal string
+	switch v := value.(type) {
+	case nil:
+		output.AppendString("NULL")
+		return nil
+	case bool:
+		literal = dialect.FormatBool(v)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("unsupported number in SQL: %v", v)
+		}
+		literal = strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		literal = strconv.FormatInt(v, 10)
+	case *big.Rat:
+		var ok bool
+		if literal, ok = decimalString(v); !ok {
+			return fmt.Errorf("unsupported number in SQL: %s", v.RatString())
+		}
+		// drivers accept exact decimals as strings
+		value = literal
+	case string:
+		literal = dialect.QuoteString(v)
+	case *regexp.Regexp:
+		value = v.String()
+		literal = dialect.QuoteString(v.String())
+	case time.Time:
+		if dialect.FormatTime != nil {
+			literal = dialect.FormatTime(v)
+		} else {
+			literal = dialect.QuoteString(v.Format(time.RFC3339Nano))
+		}
+	case time.Duration:
+		return fmt.Errorf("durations are not supported in SQL: %v", v)
+	default:
+		return fmt.Errorf("unsupported literal type in SQL: %v", value)
+	}
+	if args == nil {
+		output.AppendString(literal)
+		return nil
+	}
+	*args = append(*args, value)
+	output.AppendString(dialect.Placeholder(len(*args)))
+	return nil
+}
+
+func sqlOperator(node ExprNode, output *ExprNodePrinter, dialect SQLDialect) error {
+	args := node.Args
+	arity := len(args)
+
+	switch {
+	case node.Name == "in" && arity == 2:
+		return sqlIn(node, output, dialect)
+
+	case node.Name == "?:" && arity == 3:
+		output.AppendString("CASE WHEN ")
+		output.AppendNode(args[0])
+		output.AppendString(" THEN ")
+		output.AppendNode(args[1])
+		output.AppendString(" ELSE ")
+		output.AppendNode(args[2])
+		output.AppendString(" END")
+		return nil
+
+	case node.Name == "!" && arity == 1:
+		output.AppendString("NOT ")
+		sqlOperand(node, args[0], true, output)
+		return nil
+
+	case (node.Name == "-" || node.Name == "~") && arity == 1:
+		output.AppendString(node.Name)
+		if node.Name == "-" && !sqlNeedsBrackets(node, args[0], true) && sqlStartsWithMinus(args[0]) {
+			// "--" starts a comment in SQL
+			output.AppendString("(")
+			output.AppendNode(args[0])
+			output.AppendString(")")
+			return nil
+		}
+		sqlOperand(node, args[0], true, output)
+		return nil
+	}
+
+	if sqlName, ok := dialect.InfixOperators[node.Name]; ok && arity == 2 {
+		sqlOperand(node, args[0], false, output)
+		output.AppendString(" " + sqlName + " ")
+		sqlOperand(node, args[1], true, output)
+		return nil
+	}
+
+	if sqlName, ok := dialect.Functions[node.Name]; ok {
+		output.AppendString(sqlName + "(")
+		for idx, arg := range args {
+			if idx > 0 {
+				output.AppendString(", ")
+			}
+			output.AppendNode(arg)
+		}
+		output.AppendString(")")
+		return nil
+	}
+
+	return fmt.Errorf("operator is not supported in SQL: %s [pos=%d; len=%d]", node.Name, node.SourcePos, node.SourceLen)
+}
+
+// sqlIn converts "x in [a, b]" to "x IN (a, b)", the array must be known.
+func sqlIn(node ExprNode, output *ExprNodePrinter, dialect SQLDialect) error {
+	var items []ExprNode
+	switch list := node.Args[1]; {
+	case list.IsOperator("array"):
+		items = list.Args
+	case list.Type == NodeTypeLiteral:
+		values, ok := list.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("rhs of in is not array: %v [pos=%d; len=%d]", list.Value, list.SourcePos, list.SourceLen)
+		}
+		for _, value := range values {
+			items = append(items, NewExprNodeLiteral(value, list.SourcePos, list.SourceLen))
+		}
+	default:
+		return fmt.Errorf("rhs of in must be an array in SQL [pos=%d; len=%d]", list.SourcePos, list.SourceLen)
+	}
+
+	if len(items) == 0 {
+		// IN () is not valid
+		output.AppendString(dialect.FormatBool(false))
+		return nil
+	}
+	sqlOperand(node, node.Args[0], false, output)
+	output.AppendString(" IN (")
+	for idx, item := range items {
+		if idx > 0 {
+			output.AppendString(", ")
+		}
+		output.AppendNode(item)
+	}
+	output.AppendString(")")
+	return nil
+}
+
+// sqlOperand appends an operand of an operator, adding brackets where needed.
+// Operands of prefix operators are handled as right operands.
+func sqlOperand(parent, operand ExprNode, isRight bool, output *ExprNodePrinter) {
+	brackets := sqlNeedsBrackets(parent, operand, isRight)
+	if brackets {
+		output.AppendString("(")
+	}
+	output.AppendNode(operand)
+	if brackets {
+		output.AppendString(")")
+	}
+}
+
+// sqlStartsWithMinus returns true if SQL of a node starts with "-", like negative numbers do.
+func sqlStartsWithMinus(node ExprNode) bool {
+	switch node.Type {
+	case NodeTypeLiteral:
+		switch v := node.Value.(type) {
+		case float64:
+			return math.Signbit(v)
+		case int64:
+			return v < 0
+		case *big.Rat:
+			return v.Sign() < 0
+		}
+	case NodeTypeOperator:
+		if node.Name == "-" && len(node.Args) == 1 {
+			return true
+		}
+		if node.OperatorType == OperatorTypeInfix && len(node.Args) == 2 {
+			return !sqlNeedsBrackets(node, node.Args[0], false) && sqlStartsWithMinus(node.Args[0])
+		}
+	}
+	return false
+}
+
+func sqlNeedsBrackets(parent, operand ExprNode, isRight bool) bool {
+	operandPrecedence, ok := sqlPrecedence(operand)
+	if !ok {
+		// literal, variable, or a function call
+		return false
+	}
+	parentPrecedence, _ := sqlPrecedence(parent)
+	if sqlIsBitwise(parent) || sqlIsBitwise(operand) {
+		// precedence of bitwise operators differs between databases
+		return true
+	}
+	if isRight {
+		return operandPrecedence <= parentPrecedence
+	}
+	return operandPrecedence < parentPrecedence
+}
+
+// sqlPrecedence returns precedence of an operator printed in SQL infix or prefix form,
+// false is returned for nodes which don't need brackets.
+func sqlPrecedence(node ExprNode) (int, bool) {
+	if node.Type != NodeTypeOperator {
+		return 0, false
+	}
+	arity := len(node.Args)
+	switch {
+	case node.Name == "!" && arity == 1:
+		// NOT has lower precedence than comparison in SQL, but higher than AND
+		return 4, true
+	case (node.Name == "-" || node.Name == "~") && arity == 1:
+		return defaultPrecedence(node.Name, arity), true
+	case node.Name == "in" && arity == 2:
+		return defaultPrecedence(node.Name, arity), true
+	case arity == 2 && isSpecial(node.Name):
+		switch node.Name {
+		case "**", "??", "[]", ".", ".()":
+			// printed as functions, or not supported
+			return 0, false
+		}
+		return defaultPrecedence(node.Name, arity), true
+	}
+	return 0, false
+}
+
+func sqlIsBitwise(node ExprNode) bool {
+	if node.Type != NodeTypeOperator {
+		return false
+	}
+	switch node.Name {
+	case "&", "|", "^", "<<", ">>":
+		return len(node.Args) == 2
+	case "~":
+		return len(node.Args) == 1
+	}
+	return false
+}
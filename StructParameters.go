@@ -0,0 +1,266 @@
+package govaluate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// StructParameters exposes fields of a struct, or a pointer to a struct, as parameters.
+// A field is named by its `govaluate:"name"` tag, or by its `json:"name"` tag, or by the field name otherwise.
+// Fields tagged with "-" and unexported fields are skipped, and fields of embedded structs are promoted like in encoding/json.
+// Nested structs, maps and pointers can be accessed with dotted names, e.g. "address.city".
+// Member access of ExprNode, Filter and Select accept these names too, after Go field names.
+// In EvaluableExpression, dotted names must be in brackets, e.g. [address.city].
+// The field layout of each struct type is cached, so repeated lookups don't walk the struct with reflection.
+func StructParameters(v interface{}) Parameters {
+	return structParameters{reflect.ValueOf(v)}
+}
+
+// StructResolver is the same as StructParameters, but for EvalParams.Resolver.
+func StructResolver(v interface{}) VariableResolver {
+	return structParameters{reflect.ValueOf(v)}
+}
+
+type structParameters struct {
+	value reflect.Value
+}
+
+func (p structParameters) Get(name string) (interface{}, error) {
+
+	value, found := p.lookup(name)
+	if !found {
+		errorMessage := "No parameter '" + name + "' found."
+		return nil, errors.New(errorMessage)
+	}
+	return value, nil
+}
+
+func (p structParameters) Resolve(name string) (interface{}, bool, error) {
+	value, found := p.lookup(name)
+	return value, found, nil
+}
+
+func (p structParameters) lookup(name string) (interface{}, bool) {
+	value, found := lookupStructPath(p.value, name)
+	if !found {
+		return nil, false
+	}
+	return value.Interface(), true
+}
+
+// lookupStructPath looks up a field by name, and then by dotted path.
+// Field names containing dots take precedence over nested fields.
+func lookupStructPath(value reflect.Value, path string) (reflect.Value, bool) {
+	if field, found := lookupStructField(value, path); found {
+		return field, true
+	}
+	for idx := strings.IndexByte(path, '.'); idx >= 0; idx = nextDot(path, idx) {
+		field, found := lookupStructField(value, path[:idx])
+		if !found {
+			continue
+		}
+		if nested, found := lookupStructPath(field, path[idx+1:]); found {
+			return nested, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func nextDot(path string, idx int) int {
+	next := strings.IndexByte(path[idx+1:], '.')
+	if next < 0 {
+		return -1
+	}
+	return idx + 1 + next
+}
+
+// lookupStructField looks up a field of a struct, or a key of a map with string keys.
+func lookupStructField(value reflect.Value, name string) (reflect.Value, bool) {
+	value, ok := indirectValue(value)
+	if !ok {
+		return reflect.Value{}, false
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		index, found := structLayoutOf(value.Type()).fields[name]
+		if !found {
+			return reflect.Value{}, false
+		}
+		return fieldByIndex(value, index)
+	case reflect.Map:
+		keyType := value.Type().Key()
+		if keyType.Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		item := value.MapIndex(reflect.ValueOf(name).Convert(keyType))
+		return item, item.IsValid()
+	}
+	return reflect.Value{}, false
+}
+
+// indirectValue dereferences pointers and interfaces, false is returned for nil.
+func indirectValue(value reflect.Value) (reflect.Value, bool) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}, false
+		}
+		value = value.Elem()
+	}
+	return value, value.IsValid()
+}
+
+// structLayout maps parameter names of a struct type to field indices.
+// Indices of promoted fields can go through embedded pointers.
+type structLayout struct {
+	fields map[string][]int
+
+	// goFields maps names of exported fields, including promoted ones, like in Go
+	goFields map[string][]int
+}
+
+// member returns the index of a field by its Go name, like in EvaluableExpression, or by its parameter name.
+func (layout *structLayout) member(name string) ([]int, bool) {
+	if index, ok := layout.goFields[name]; ok {
+		return index, true
+	}
+	index, ok := layout.fields[name]
+	return index, ok
+}
+
+// structLayouts caches layouts by struct type.
+var structLayouts sync.Map
+
+func structLayoutOf(structType reflect.Type) *structLayout {
+	if layout, ok := structLayouts.Load(structType); ok {
+		return layout.(*structLayout)
+	}
+
+	// like in encoding/json, the shallowest field wins, and ambiguous fields are dropped
+	type candidate struct {
+		index     []int
+		ambiguous bool
+	}
+	candidates := map[string]*candidate{}
+	visited := map[reflect.Type]bool{}
+	level := []candidate{{index: []int{}}}
+	for len(level) > 0 {
+		var next []candidate
+		found := map[string]*candidate{}
+		for _, parent := range level {
+			parentType := structType
+			if len(parent.index) > 0 {
+				parentType = embeddedType(structType, parent.index)
+			}
+			if visited[parentType] {
+				continue
+			}
+			visited[parentType] = true
+
+			for idx := 0; idx < parentType.NumField(); idx++ {
+				field := parentType.Field(idx)
+				index := append(append([]int{}, parent.index...), idx)
+				name, tagged, skip := structFieldName(field)
+				if skip {
+					continue
+				}
+				fieldType := field.Type
+				if fieldType.Kind() == reflect.Ptr {
+					fieldType = fieldType.Elem()
+				}
+				if field.Anonymous && !tagged && fieldType.Kind() == reflect.Struct {
+					next = append(next, candidate{index: index})
+					continue
+				}
+				if field.PkgPath != "" {
+					continue
+				}
+				if _, shadowed := candidates[name]; shadowed {
+					continue
+				}
+				if existing, ok := found[name]; ok {
+					existing.ambiguous = true
+					continue
+				}
+				found[name] = &candidate{index: index}
+			}
+		}
+		for name, c := range found {
+			candidates[name] = c
+		}
+		level = next
+	}
+
+	layout := &structLayout{fields: map[string][]int{}, goFields: map[string][]int{}}
+	for name, c := range candidates {
+		if !c.ambiguous {
+			layout.fields[name] = c.index
+		}
+	}
+	for name := range collectFieldNames(structType, map[string]bool{}, map[reflect.Type]bool{}) {
+		// FieldByName resolves shadowed and ambiguous promoted fields like Go does
+		if field, ok := structType.FieldByName(name); ok && field.PkgPath == "" {
+			layout.goFields[name] = field.Index
+		}
+	}
+	actual, _ := structLayouts.LoadOrStore(structType, layout)
+	return actual.(*structLayout)
+}
+
+// collectFieldNames collects names of fields, and of fields of embedded structs.
+func collectFieldNames(structType reflect.Type, names map[string]bool, visited map[reflect.Type]bool) map[string]bool {
+	if visited[structType] {
+		return names
+	}
+	visited[structType] = true
+	for idx := 0; idx < structType.NumField(); idx++ {
+		field := structType.Field(idx)
+		names[field.Name] = true
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct {
+			collectFieldNames(fieldType, names, visited)
+		}
+	}
+	return names
+}
+
+// embeddedType returns the struct type of an embedded field, dereferencing pointers.
+func embeddedType(structType reflect.Type, index []int) reflect.Type {
+	fieldType := structType
+	for _, idx := range index {
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		fieldType = fieldType.Field(idx).Type
+	}
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType
+}
+
+// structFieldName returns the parameter name of a field, whether it's set by a tag, or true if the field is skipped.
+func structFieldName(field reflect.StructField) (string, bool, bool) {
+	for _, key := range []string{"govaluate", "json"} {
+		tag, ok := field.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+		name := tag
+		if idx := strings.IndexByte(tag, ','); idx >= 0 {
+			name = tag[:idx]
+		}
+		if name == "-" && len(tag) == 1 {
+			return "", false, true
+		}
+		if name != "" {
+			return name, true, false
+		}
+	}
+	return field.Name, false, false
+}
@@ -0,0 +1,341 @@
+package govaluate
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// dateLayouts are tried in order by date() without a layout.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// durationUnits are suffixes of duration literals, longer ones first.
+var durationUnits = []string{"ns", "us", "µs", "ms", "s", "m", "h"}
+
+// tokenizeDuration reads a duration literal, a sequence of numbers with units, like 5m, 1.5s or 2h30m.
+func tokenizeDuration(input string) ExprToken {
+	end := 0
+	for end < len(input) {
+		next := end
+		for next < len(input) && '0' <= input[next] && input[next] <= '9' {
+			next++
+		}
+		if next < len(input)-1 && next > end && input[next] == '.' && '0' <= input[next+1] && input[next+1] <= '9' {
+			next++
+			for next < len(input) && '0' <= input[next] && input[next] <= '9' {
+				next++
+			}
+		}
+		if next == end {
+			break
+		}
+		unitLen := 0
+		for _, unit := range durationUnits {
+			if strings.HasPrefix(input[next:], unit) {
+				unitLen = len(unit)
+				break
+			}
+		}
+		if unitLen == 0 {
+			break
+		}
+		end = next + unitLen
+	}
+	if end == 0 || end < len(input) && isIdentifierChar(input[end:]) {
+		// a number followed by an identifier, like 5min, is not a duration
+		return ExprToken{}
+	}
+	value, err := time.ParseDuration(input[:end])
+	if err != nil {
+		return ExprToken{}
+	}
+	return NewExprToken(TokenKindNumber, value, end)
+}
+
+func isIdentifierChar(input string) bool {
+	for _, ch := range input {
+		return unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_'
+	}
+	return false
+}
+
+// timeSum adds a duration to a time or to another duration, false is returned for other values.
+func timeSum(a, b interface{}) (interface{}, bool) {
+	switch x := a.(type) {
+	case time.Time:
+		if y, ok := b.(time.Duration); ok {
+			return x.Add(y), true
+		}
+	case time.Duration:
+		switch y := b.(type) {
+		case time.Time:
+			return y.Add(x), true
+		case time.Duration:
+			return x + y, true
+		}
+	}
+	return nil, false
+}
+
+// timeDifference subtracts a time or a duration from a time, or a duration from a duration,
+// false is returned for other values.
+func timeDifference(a, b interface{}) (interface{}, bool) {
+	switch x := a.(type) {
+	case time.Time:
+		switch y := b.(type) {
+		case time.Time:
+			return x.Sub(y), true
+		case time.Duration:
+			return x.Add(-y), true
+		}
+	case time.Duration:
+		if y, ok := b.(time.Duration); ok {
+			return x - y, true
+		}
+	}
+	return nil, false
+}
+
+// compareTimes compares two times or two durations, and returns -1, 0 or 1.
+// False is returned for other values.
+func compareTimes(a, b interface{}) (int, bool) {
+	switch x := a.(type) {
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			switch {
+			case x.Before(y):
+				return -1, true
+			case x.After(y):
+				return 1, true
+			}
+			return 0, true
+		}
+	case time.Duration:
+		if y, ok := b.(time.Duration); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	return 0, false
+}
+
+// equalValues compares values like ==, but times are equal if they are the same instant, in any location,
+// and arrays and objects are equal if their items are.
+func equalValues(a, b interface{}) bool {
+	if equal, ok := equalCollections(a, b, equalValues); ok {
+		return equal
+	}
+	if x, ok := a.(time.Time); ok {
+		if y, ok := b.(time.Time); ok {
+			return x.Equal(y)
+		}
+	}
+	if a != nil && !reflect.TypeOf(a).Comparable() {
+		// == panics on values like slices of other types
+		return reflect.DeepEqual(a, b)
+	}
+	return a == b
+}
+
+// equalCollections compares arrays and objects item by item with equal, ok is false if a is neither.
+func equalCollections(a, b interface{}, equal func(a, b interface{}) bool) (result bool, ok bool) {
+	switch x := a.(type) {
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false, true
+		}
+		for idx := range x {
+			if !equal(x[idx], y[idx]) {
+				return false, true
+			}
+		}
+		return true, true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false, true
+		}
+		for key, value := range x {
+			other, found := y[key]
+			if !found || !equal(value, other) {
+				return false, true
+			}
+		}
+		return true, true
+	}
+	return false, false
+}
+
+func timeArg(ctx EvalContext, idx int) (time.Time, error) {
+	val, err := ctx.Arg(idx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if timeVal, ok := val.(time.Time); ok {
+		return timeVal, nil
+	}
+	return time.Time{}, formatArgError(ctx.expr, idx, "is not time: %v", val)
+}
+
+// locations caches loaded time zones by name.
+var locations sync.Map
+
+// locationArg returns a time zone by IANA name, like "Europe/Paris", "UTC" or "Local".
+func locationArg(ctx EvalContext, idx int) (*time.Location, error) {
+	name, err := ctx.StringArg(idx)
+	if err != nil {
+		return nil, err
+	}
+	if cached, ok := locations.Load(name); ok {
+		return cached.(*time.Location), nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, wrapArgError(ctx.expr, idx, err, "is not a valid time zone: %v", err)
+	}
+	locations.Store(name, location)
+	return location, nil
+}
+
+// timeInLocation returns a time argument, converted to the time zone of an optional argument.
+func timeInLocation(ctx EvalContext, zoneIdx int) (time.Time, error) {
+	value, err := timeArg(ctx, 0)
+	if err != nil || ctx.ArgCount() <= zoneIdx {
+		return value, err
+	}
+	location, err := locationArg(ctx, zoneIdx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return value.In(location), nil
+}
+
+func builtinNow(ctx EvalContext) (interface{}, error) {
+	if err := ctx.CheckArgCount(0); err != nil {
+		return nil, err
+	}
+	return ctx.Now(), nil
+}
+
+// builtinDate parses a time: date(str), date(str, layout) or date(str, layout, zone).
+// Without a layout, RFC 3339 and similar formats are tried. Times without offset are in the zone, UTC by default.
+func builtinDate(ctx EvalContext) (interface{}, error) {
+	if ctx.ArgCount() < 1 || ctx.ArgCount() > 3 {
+		return nil, ctx.FormatError("wrong number of arguments: %d, expected: 1 to 3", ctx.ArgCount())
+	}
+	str, err := ctx.StringArg(0)
+	if err != nil {
+		return nil, err
+	}
+	location := time.UTC
+	if ctx.ArgCount() == 3 {
+		if location, err = locationArg(ctx, 2); err != nil {
+			return nil, err
+		}
+	}
+	if ctx.ArgCount() == 1 {
+		for _, layout := range dateLayouts {
+			if value, err := time.ParseInLocation(layout, str, location); err == nil {
+				return value, nil
+			}
+		}
+		return nil, formatArgError(ctx.expr, 0, "is not a valid date: %q", str)
+	}
+	layout, err := ctx.StringArg(1)
+	if err != nil {
+		return nil, err
+	}
+	value, err := time.ParseInLocation(layout, str, location)
+	if err != nil {
+		return nil, wrapArgError(ctx.expr, 0, err, "is not a valid date: %v", err)
+	}
+	return value, nil
+}
+
+// timePart returns an operator, which extracts a part of a time, optionally in a time zone: year(t, "Europe/Paris").
+func timePart(part func(time.Time) int) Operator {
+	return func(ctx EvalContext) (interface{}, error) {
+		if ctx.ArgCount() != 1 && ctx.ArgCount() != 2 {
+			return nil, ctx.FormatError("wrong number of arguments: %d, expected: 1 or 2", ctx.ArgCount())
+		}
+		value, err := timeInLocation(ctx, 1)
+		if err != nil {
+			return nil, err
+		}
+		return float64(part(value)), nil
+	}
+}
+
+// builtinAddDays adds calendar days, so the time of day is kept across daylight saving changes:
+// addDays(t, n) or addDays(t, n, zone).
+func builtinAddDays(ctx EvalContext) (interface{}, error) {
+	if ctx.ArgCount() != 2 && ctx.ArgCount() != 3 {
+		return nil, ctx.FormatError("wrong number of arguments: %d, expected: 2 or 3", ctx.ArgCount())
+	}
+	value, err := timeInLocation(ctx, 2)
+	if err != nil {
+		return nil, err
+	}
+	days, err := ctx.IntegerArg(1)
+	if err != nil {
+		return nil, err
+	}
+	return value.AddDate(0, 0, days), nil
+}
+
+// builtinTruncate rounds a time down: truncate(t, unit) or truncate(t, unit, zone).
+// The unit is "year", "month", "week" (starting on Monday), "day", "hour", "minute" or "second",
+// and the time is truncated in its zone, or in the given one. The unit can be a duration too,
+// like truncate(t, 15m), then the time is truncated since the zero time, regardless of the zone.
+func builtinTruncate(ctx EvalContext) (interface{}, error) {
+	if ctx.ArgCount() != 2 && ctx.ArgCount() != 3 {
+		return nil, ctx.FormatError("wrong number of arguments: %d, expected: 2 or 3", ctx.ArgCount())
+	}
+	value, err := timeInLocation(ctx, 2)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := ctx.Arg(1)
+	if err != nil {
+		return nil, err
+	}
+	if duration, ok := unit.(time.Duration); ok {
+		return value.Truncate(duration), nil
+	}
+	year, month, day := value.Date()
+	hour, min, sec := value.Clock()
+	switch unit {
+	case "year":
+		month, day, hour, min, sec = time.January, 1, 0, 0, 0
+	case "month":
+		day, hour, min, sec = 1, 0, 0, 0
+	case "week":
+		// Weekday is counted from Sunday, but weeks start on Monday
+		day -= (int(value.Weekday()) + 6) % 7
+		hour, min, sec = 0, 0, 0
+	case "day":
+		hour, min, sec = 0, 0, 0
+	case "hour":
+		min, sec = 0, 0
+	case "minute":
+		sec = 0
+	case "second":
+	default:
+		return nil, formatArgError(ctx.expr, 1, "is not a valid time unit: %v", unit)
+	}
+	return time.Date(year, month, day, hour, min, sec, 0, value.Location()), nil
+}
@@ -18,6 +18,16 @@ type TokenStream struct {
 	pos       int
 	hasNext   bool
 	nextToken ExprToken
+
+	// syntax errors collected by ParseWithRecovery, nil if recovery is disabled
+	parseErrors *[]ParseError
+
+	// comments skipped so far, and the end of the last token before them
+	comments []sourceComment
+	lastEnd  int
+
+	// numbers selects the type of number literals, see ParseOptions
+	numbers NumberMode
 }
 
 // Tokenize converts input string to a list of tokens.
@@ -49,7 +59,12 @@ func (s *TokenStream) Next() ExprToken {
 // Error returns an error if there was an error reading input.
 func (s *TokenStream) Error() error {
 	if s.Peek().Is(TokenKindEOF, nil) && s.pos != len(s.input) {
-		return fmt.Errorf("unable to parse input at pos=%d", s.pos)
+		return ParseError{
+			Message:   fmt.Sprintf("unable to parse input at pos=%d", s.pos),
+			Token:     s.Peek(),
+			SourcePos: s.pos,
+			SourceLen: len(s.input) - s.pos,
+		}
 	}
 	return nil
 }
@@ -67,16 +82,14 @@ func (s *TokenStream) next(advance bool) ExprToken {
 }
 
 func (s *TokenStream) readNext() ExprToken {
+	s.skipComments()
 	if s.pos == len(s.input) {
-		return ExprToken{}
-	}
-	whitespace := tokenizeWhitespace(s.input[s.pos:])
-	if whitespace.SourceLen > 0 {
-		s.pos += whitespace.SourceLen
+		return ExprToken{SourcePos: s.pos}
 	}
 	nextInput := s.input[s.pos:]
 	var tokenizers = [...]Tokenizer{
 		tokenizeIdentifier,
+		tokenizeDuration,
 		tokenizeHexNumber,
 		tokenizeDecimalNumber,
 		tokenizeString,
@@ -86,12 +99,46 @@ func (s *TokenStream) readNext() ExprToken {
 	for _, tokenizer := range tokenizers {
 		token := tokenizer(nextInput)
 		if token.SourceLen > 0 {
+			if value, ok := token.Value.(float64); ok && token.Kind == TokenKindNumber {
+				token.Value = s.numberLiteral(nextInput[:token.SourceLen], value)
+			}
 			token.SourcePos = s.pos
 			s.pos += token.SourceLen
+			s.lastEnd = s.pos
 			return token
 		}
 	}
-	return ExprToken{}
+	return ExprToken{SourcePos: s.pos}
+}
+
+// numberLiteral converts a number token to the type of the number mode.
+func (s *TokenStream) numberLiteral(text string, value float64) interface{} {
+	switch s.numbers {
+	case NumberModeInteger:
+		return integerLiteral(text, value)
+	case NumberModeDecimal:
+		return decimalLiteral(text, value)
+	}
+	return value
+}
+
+// skipComments skips whitespace and comments, comments are collected to be attached to nodes.
+func (s *TokenStream) skipComments() {
+	first := len(s.comments)
+	for {
+		s.pos += tokenizeWhitespace(s.input[s.pos:]).SourceLen
+		token := tokenizeComment(s.input[s.pos:])
+		if token.SourceLen == 0 {
+			break
+		}
+		comment := token.Value.(Comment)
+		comment.SourcePos, comment.SourceLen = s.pos, token.SourceLen
+		s.comments = append(s.comments, sourceComment{Comment: comment, prevEnd: s.lastEnd})
+		s.pos += token.SourceLen
+	}
+	for idx := first; idx < len(s.comments); idx++ {
+		s.comments[idx].nextPos = s.pos
+	}
 }
 
 func tokenizeWhitespace(input string) ExprToken {
@@ -225,6 +272,26 @@ func tokenizeString(input string) ExprToken {
 	return ExprToken{}
 }
 
+// tokenizeComment reads a // line comment, or a /* block */ comment.
+// An unterminated block comment is not read, so it's reported as invalid input.
+func tokenizeComment(input string) ExprToken {
+	switch {
+	case strings.HasPrefix(input, "//"):
+		end := strings.IndexByte(input, '\n')
+		if end < 0 {
+			end = len(input)
+		}
+		return NewExprToken(TokenKindComment, Comment{Text: input[2:end]}, end)
+	case strings.HasPrefix(input, "/*"):
+		end := strings.Index(input[2:], "*/")
+		if end < 0 {
+			return ExprToken{}
+		}
+		return NewExprToken(TokenKindComment, Comment{Text: input[2 : end+2], Block: true}, end+4)
+	}
+	return ExprToken{}
+}
+
 func tokenizeBracket(input string) ExprToken {
 	if len(input) == 0 {
 		return ExprToken{}
@@ -243,14 +310,24 @@ func tokenizeOperator(input string) ExprToken {
 
 	// handle symbols that can not be combined
 	switch input[0] {
-	case ',':
+	case ',', ':':
+		// ':' can be followed by a prefix operator: {a:-1}
 		return NewExprToken(TokenKindOperator, input[:1], 1)
 	}
+	if strings.HasPrefix(input, "=>") {
+		// lambda body can start with a prefix operator: x=>-x
+		return NewExprToken(TokenKindOperator, input[:2], 2)
+	}
 
 	// read symbols as a single token, like ==, ||, &&
 	operatorSymbols := []rune("~!#$%^&*-+|\\=:./?<>")
 	nextNonOperator := len(input)
 	for idx, ch := range input {
+		if ch == '/' && (strings.HasPrefix(input[idx:], "//") || strings.HasPrefix(input[idx:], "/*")) {
+			// comment starts
+			nextNonOperator = idx
+			break
+		}
 		found := false
 		for _, sym := range operatorSymbols {
 			if sym == ch {
@@ -0,0 +1,373 @@
+package govaluate
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"time"
+)
+
+// Type is a static type of an expression, inferred by TypeCheck.
+type Type struct {
+	Kind TypeKind
+
+	// Elem is the item type of an array. Nil means items can be of any type.
+	Elem *Type
+}
+
+// TypeKind is a kind of Type.
+type TypeKind int
+
+const (
+	// TypeKindAny is a value of unknown type, it is compatible with all other types.
+	TypeKindAny TypeKind = iota
+	TypeKindNil
+	TypeKindBool
+	TypeKindNumber
+	TypeKindString
+	TypeKindArray
+	TypeKindObject
+	TypeKindTime
+	TypeKindDuration
+)
+
+var (
+	TypeAny    = Type{Kind: TypeKindAny}
+	TypeNil    = Type{Kind: TypeKindNil}
+	TypeBool   = Type{Kind: TypeKindBool}
+	TypeNumber = Type{Kind: TypeKindNumber}
+	TypeString = Type{Kind: TypeKindString}
+	TypeObject = Type{Kind: TypeKindObject}
+
+	TypeTime     = Type{Kind: TypeKindTime}
+	TypeDuration = Type{Kind: TypeKindDuration}
+)
+
+// TypeArrayOf returns an array type with the given item type.
+func TypeArrayOf(elem Type) Type {
+	return Type{Kind: TypeKindArray, Elem: &elem}
+}
+
+// Equal returns true if both types are the same.
+func (t Type) Equal(other Type) bool {
+	if t.Kind != other.Kind {
+		return false
+	}
+	if t.Kind == TypeKindArray {
+		return t.elem().Equal(other.elem())
+	}
+	return true
+}
+
+// AssignableTo returns true if a value of this type can be used where the target type is expected.
+// Any type is assignable to any, and any is assignable to all types, as it's not known until evaluation.
+func (t Type) AssignableTo(target Type) bool {
+	if t.Kind == TypeKindAny || target.Kind == TypeKindAny {
+		return true
+	}
+	if t.Kind != target.Kind {
+		return false
+	}
+	if t.Kind == TypeKindArray {
+		return t.elem().AssignableTo(target.elem())
+	}
+	return true
+}
+
+func (t Type) String() string {
+	switch t.Kind {
+	case TypeKindAny:
+		return "any"
+	case TypeKindNil:
+		return "nil"
+	case TypeKindBool:
+		return "bool"
+	case TypeKindNumber:
+		return "number"
+	case TypeKindString:
+		return "string"
+	case TypeKindArray:
+		if t.Elem == nil {
+			return "array"
+		}
+		return fmt.Sprintf("array<%v>", *t.Elem)
+	case TypeKindObject:
+		return "object"
+	case TypeKindTime:
+		return "time"
+	case TypeKindDuration:
+		return "duration"
+	}
+	return fmt.Sprintf("unknown(%d)", t.Kind)
+}
+
+func (t Type) elem() Type {
+	if t.Elem == nil {
+		return TypeAny
+	}
+	return *t.Elem
+}
+
+// unifyTypes returns the type that values of both types can be described with.
+func unifyTypes(a, b Type) Type {
+	if a.Equal(b) {
+		return a
+	}
+	if a.Kind == TypeKindArray && b.Kind == TypeKindArray {
+		return TypeArrayOf(unifyTypes(a.elem(), b.elem()))
+	}
+	return TypeAny
+}
+
+// TypeOf returns the static type of a Go value, as seen by expressions.
+func TypeOf(value interface{}) Type {
+	switch v := value.(type) {
+	case nil:
+		return TypeNil
+	case bool:
+		return TypeBool
+	case float64, float32, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		*big.Rat, *big.Float, *big.Int:
+		return TypeNumber
+	case string, *regexp.Regexp:
+		return TypeString
+	case time.Time:
+		return TypeTime
+	case time.Duration:
+		return TypeDuration
+	case map[string]interface{}:
+		return TypeObject
+	case []interface{}:
+		if len(v) == 0 {
+			return Type{Kind: TypeKindArray}
+		}
+		elem := TypeOf(v[0])
+		for _, item := range v[1:] {
+			elem = unifyTypes(elem, TypeOf(item))
+		}
+		return TypeArrayOf(elem)
+	}
+	return TypeAny
+}
+
+// TypeError describes a type mismatch found by TypeCheck.
+type TypeError struct {
+	Message string
+
+	// Operator is set when the error is about the operator itself, rather than one of its arguments.
+	Operator string
+
+	SourcePos, SourceLen int
+}
+
+func (err TypeError) Error() string {
+	if err.Operator != "" {
+		return fmt.Sprintf("%s [op=%s; pos=%d; len=%d]", err.Message, err.Operator, err.SourcePos, err.SourceLen)
+	}
+	return fmt.Sprintf("%s [pos=%d; len=%d]", err.Message, err.SourcePos, err.SourceLen)
+}
+
+// TypeEnv declares types of variables and signatures of operators for TypeCheck.
+type TypeEnv struct {
+	Variables map[string]Type
+	Operators map[string]OperatorSignature
+}
+
+// OperatorSignature checks argument types of an operator and returns its result type.
+// Mismatches are reported with TypeContext methods.
+type OperatorSignature func(ctx TypeContext) Type
+
+// TypeContext gives an OperatorSignature access to argument types and a way to report errors.
+type TypeContext struct {
+	expr   ExprNode
+	args   []Type
+	errors *[]TypeError
+}
+
+var builtinSignatures = BuiltinSignatures()
+
+// NewTypeEnv returns a TypeEnv with declared variables and builtin operator signatures.
+func NewTypeEnv(variables map[string]Type) TypeEnv {
+	return TypeEnv{
+		Variables: variables,
+		Operators: builtinSignatures,
+	}
+}
+
+// TypeCheck infers the type of an expression without evaluating it.
+// All mismatches found are returned, the result type is TypeAny where it could not be inferred.
+func TypeCheck(expr ExprNode, env TypeEnv) (Type, []TypeError) {
+	errors := []TypeError{}
+	result := typeCheckNode(expr, env, &errors)
+	return result, errors
+}
+
+func typeCheckNode(expr ExprNode, env TypeEnv, errors *[]TypeError) Type {
+	switch expr.Type {
+	case NodeTypeLiteral:
+		return TypeOf(expr.Value)
+	case NodeTypeVariable:
+		varType, ok := env.Variables[expr.Name]
+		if !ok {
+			*errors = append(*errors, newTypeError(expr, "variable undefined: %v", expr.Name))
+			return TypeAny
+		}
+		return varType
+	case NodeTypeOperator:
+		return typeCheckOperator(expr, env, TypeAny, errors)
+	case NodeTypeError:
+		// already reported by the parser
+		return TypeAny
+	}
+	*errors = append(*errors, newTypeError(expr, "bad expr type: %v", expr.Type))
+	return TypeAny
+}
+
+// typeCheckOperator infers the type of an operator, param is the type of the parameter if it's a lambda.
+// Lambda arguments have the type of their body, and their parameters have the item type of the array,
+// except accumulators, which have the type of the initial value.
+func typeCheckOperator(expr ExprNode, env TypeEnv, param Type, errors *[]TypeError) Type {
+	args := make([]Type, len(expr.Args))
+	paramIdx, bodyIdx, isLambda := lambdaParams(expr)
+	lambdaIdx, _, hasLambdaArg := lambdaArgIndex(expr)
+	for idx, arg := range expr.Args {
+		switch {
+		case isLambda && idx >= paramIdx && idx < bodyIdx:
+			switch {
+			case expr.Name == "=>":
+				args[idx] = param
+			case idx == bodyIdx-1:
+				// inline lambda, like filter(items, x, x > 1)
+				args[idx] = args[0].elem()
+			default:
+				// accumulator, like in reduce(items, 0, acc, x, acc + x)
+				args[idx] = args[paramIdx-1]
+			}
+		case isLambda && idx == bodyIdx:
+			bodyEnv := env
+			for p := paramIdx; p < bodyIdx; p++ {
+				bodyEnv = bodyEnv.withVariable(expr.Args[p].Name, args[p])
+			}
+			args[idx] = typeCheckNode(arg, bodyEnv, errors)
+		case hasLambdaArg && idx == lambdaIdx && arg.IsOperator("=>"):
+			args[idx] = typeCheckOperator(arg, env, args[0].elem(), errors)
+		default:
+			args[idx] = typeCheckNode(arg, env, errors)
+		}
+	}
+	signature, ok := env.Operators[expr.Name]
+	if !ok {
+		*errors = append(*errors, newTypeError(expr, "operator undefined: %v", expr.Name))
+		return TypeAny
+	}
+	return signature(TypeContext{expr: expr, args: args, errors: errors})
+}
+
+// withVariable returns a copy of env with one more variable, e.g. a lambda parameter.
+func (env TypeEnv) withVariable(name string, varType Type) TypeEnv {
+	variables := make(map[string]Type, len(env.Variables)+1)
+	for key, value := range env.Variables {
+		variables[key] = value
+	}
+	variables[name] = varType
+	env.Variables = variables
+	return env
+}
+
+func newTypeError(expr ExprNode, msg string, msgArgs ...interface{}) TypeError {
+	return TypeError{
+		Message:   fmt.Sprintf(msg, msgArgs...),
+		SourcePos: expr.SourcePos,
+		SourceLen: expr.SourceLen,
+	}
+}
+
+// ArgCount returns the number of operator arguments.
+func (ctx TypeContext) ArgCount() int {
+	return len(ctx.args)
+}
+
+// ArgType returns the inferred type of an argument.
+func (ctx TypeContext) ArgType(idx int) Type {
+	return ctx.args[idx]
+}
+
+// Arg returns an argument node, e.g. to inspect literal values.
+func (ctx TypeContext) Arg(idx int) ExprNode {
+	return ctx.expr.Args[idx]
+}
+
+// CheckArgCount reports an error and returns false if argument count doesn't match.
+func (ctx TypeContext) CheckArgCount(count int) bool {
+	if ctx.ArgCount() != count {
+		ctx.Errorf("wrong number of arguments: %d, expected: %d", ctx.ArgCount(), count)
+		return false
+	}
+	return true
+}
+
+// ExpectArg reports an error and returns false if an argument is not assignable to the expected type.
+func (ctx TypeContext) ExpectArg(idx int, expected Type) bool {
+	if !ctx.args[idx].AssignableTo(expected) {
+		ctx.ArgErrorf(idx, "is %v, expected %v", ctx.args[idx], expected)
+		return false
+	}
+	return true
+}
+
+// Errorf reports an error at the operator position.
+func (ctx TypeContext) Errorf(msg string, msgArgs ...interface{}) {
+	err := newTypeError(ctx.expr, msg, msgArgs...)
+	err.Operator = ctx.expr.Name
+	*ctx.errors = append(*ctx.errors, err)
+}
+
+// ArgErrorf reports an error at the argument position.
+func (ctx TypeContext) ArgErrorf(idx int, msg string, msgArgs ...interface{}) {
+	err := newTypeError(ctx.expr.Args[idx], msg, msgArgs...)
+	err.Message = formatArgName(ctx.expr, idx) + " " + err.Message
+	*ctx.errors = append(*ctx.errors, err)
+}
+
+// Signature returns an OperatorSignature with fixed argument types.
+func Signature(result Type, args ...Type) OperatorSignature {
+	return func(ctx TypeContext) Type {
+		if ctx.CheckArgCount(len(args)) {
+			for idx, arg := range args {
+				ctx.ExpectArg(idx, arg)
+			}
+		}
+		return result
+	}
+}
+
+// VariadicSignature returns an OperatorSignature with fixed argument types,
+// followed by any number of arguments of the variadic type.
+func VariadicSignature(result Type, variadic Type, args ...Type) OperatorSignature {
+	return func(ctx TypeContext) Type {
+		if ctx.ArgCount() < len(args) {
+			ctx.Errorf("wrong number of arguments: %d, expected at least: %d", ctx.ArgCount(), len(args))
+			return result
+		}
+		for idx := 0; idx < ctx.ArgCount(); idx++ {
+			if idx < len(args) {
+				ctx.ExpectArg(idx, args[idx])
+			} else {
+				ctx.ExpectArg(idx, variadic)
+			}
+		}
+		return result
+	}
+}
+
+// OverloadedSignature returns an OperatorSignature that picks one of the signatures by argument count.
+func OverloadedSignature(signatures map[int]OperatorSignature) OperatorSignature {
+	return func(ctx TypeContext) Type {
+		signature, ok := signatures[ctx.ArgCount()]
+		if !ok {
+			ctx.Errorf("wrong number of arguments: %d", ctx.ArgCount())
+			return TypeAny
+		}
+		return signature(ctx)
+	}
+}
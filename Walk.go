@@ -0,0 +1,106 @@
+package govaluate
+
+// ExprPathStep is a step from a parent node to one of its arguments.
+type ExprPathStep struct {
+	Parent   ExprNode
+	ArgIndex int
+}
+
+// ExprPath is a location of a node in an expression tree, as a list of steps from the root.
+// It's empty for the root node.
+type ExprPath []ExprPathStep
+
+// Parent returns the parent node, and the index of the node in its arguments.
+// False is returned for the root node.
+func (path ExprPath) Parent() (ExprNode, int, bool) {
+	if len(path) == 0 {
+		return ExprNode{}, -1, false
+	}
+	step := path[len(path)-1]
+	return step.Parent, step.ArgIndex, true
+}
+
+// Visitor is called for every node of an expression by Walk.
+// The path is only valid during the call, it must be copied to be kept.
+type Visitor interface {
+	// Enter is called before the arguments of the node are visited,
+	// if it returns false, the arguments are skipped, and Leave is not called.
+	Enter(node ExprNode, path ExprPath) bool
+
+	// Leave is called after the arguments of the node are visited.
+	Leave(node ExprNode, path ExprPath)
+}
+
+// VisitorFuncs implements Visitor with functions, either of which can be nil.
+type VisitorFuncs struct {
+	Pre  func(node ExprNode, path ExprPath) bool
+	Post func(node ExprNode, path ExprPath)
+}
+
+func (v VisitorFuncs) Enter(node ExprNode, path ExprPath) bool {
+	if v.Pre == nil {
+		return true
+	}
+	return v.Pre(node, path)
+}
+
+func (v VisitorFuncs) Leave(node ExprNode, path ExprPath) {
+	if v.Post != nil {
+		v.Post(node, path)
+	}
+}
+
+// Walk visits the expression tree depth first, arguments are visited in order.
+func Walk(expr ExprNode, visitor Visitor) {
+	walk(expr, ExprPath{}, visitor)
+}
+
+func walk(expr ExprNode, path ExprPath, visitor Visitor) {
+	if !visitor.Enter(expr, path) {
+		return
+	}
+	for idx, arg := range expr.Args {
+		walk(arg, append(path, ExprPathStep{Parent: expr, ArgIndex: idx}), visitor)
+	}
+	visitor.Leave(expr, path)
+}
+
+// Rewrite transforms the expression tree bottom up: fn is called for every node, after its arguments are rewritten.
+// If fn returns true, the node is replaced with the returned one, which is not rewritten again.
+// Only the changed nodes and their ancestors are rebuilt, unchanged subtrees are shared with the original tree.
+func Rewrite(expr ExprNode, fn func(node ExprNode) (ExprNode, bool)) ExprNode {
+	return RewriteWithPath(expr, func(node ExprNode, _ ExprPath) (ExprNode, bool) {
+		return fn(node)
+	})
+}
+
+// RewriteWithPath is the same as Rewrite, but fn also receives the path of the node.
+// Parents in the path are the original nodes, before their arguments are rewritten.
+func RewriteWithPath(expr ExprNode, fn func(node ExprNode, path ExprPath) (ExprNode, bool)) ExprNode {
+	result, _ := rewrite(expr, ExprPath{}, fn)
+	return result
+}
+
+func rewrite(expr ExprNode, path ExprPath, fn func(ExprNode, ExprPath) (ExprNode, bool)) (ExprNode, bool) {
+	var args []ExprNode
+	for idx, arg := range expr.Args {
+		newArg, changed := rewrite(arg, append(path, ExprPathStep{Parent: expr, ArgIndex: idx}), fn)
+		if changed && args == nil {
+			// copy on first change, the original args can be shared with other trees
+			args = make([]ExprNode, len(expr.Args))
+			copy(args, expr.Args)
+		}
+		if args != nil {
+			args[idx] = newArg
+		}
+	}
+
+	changed := args != nil
+	if changed {
+		expr.Args = args
+	}
+	if replacement, ok := fn(expr, path); ok {
+		return replacement, true
+	}
+	return expr, changed
+}
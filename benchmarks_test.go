@@ -265,7 +265,7 @@ func BenchmarkTokenizer(t *testing.B) {
 
 func BenchmarkTokenizerOld(t *testing.B) {
 	for i := 0; i < t.N; i++ {
-		tokens, err := parseTokens("x + y**2 - 2/(1 + z**2)", map[string]ExpressionFunction{})
+		tokens, err := parseTokens("x + y**2 - 2/(1 + z**2)", map[string]ExpressionFunction{}, nil)
 		if err != nil || len(tokens) != 15 {
 			assert.Equal(t, 15, len(tokens))
 			assert.Nil(t, err)
@@ -350,6 +350,20 @@ func BenchmarkEvalSimpleOld(t *testing.B) {
 	}
 }
 
+func BenchmarkEvalSimpleCompiled(t *testing.B) {
+	program, err := Compile(MustParse("a + 1"), CompileOptions{})
+	assert.Nil(t, err)
+	t.ResetTimer()
+	for i := 0; i < t.N; i++ {
+		result, err := program.Run(map[string]interface{}{"a": 8.0})
+		if err != nil || result != 9.0 {
+			assert.Nil(t, err)
+			assert.Equal(t, 9.0, result)
+			t.FailNow()
+		}
+	}
+}
+
 func BenchmarkEvalMedium(t *testing.B) {
 	expr, err := Parse("x ? (y > 0.15 && y < 0.5) : (y < -0.15 && y > -0.5)")
 	assert.Nil(t, err)
@@ -378,6 +392,20 @@ func BenchmarkEvalMediumOld(t *testing.B) {
 	}
 }
 
+func BenchmarkEvalMediumCompiled(t *testing.B) {
+	program, err := Compile(MustParse("x ? (y > 0.15 && y < 0.5) : (y < -0.15 && y > -0.5)"), CompileOptions{})
+	assert.Nil(t, err)
+	t.ResetTimer()
+	for i := 0; i < t.N; i++ {
+		result, err := program.Run(map[string]interface{}{"x": false, "y": -0.4})
+		if err != nil || result != true {
+			assert.Nil(t, err)
+			assert.Equal(t, true, result)
+			t.FailNow()
+		}
+	}
+}
+
 func BenchmarkEvalComplex(t *testing.B) {
 	expr, err := Parse("(0 <= x && x < max && ((1 + y) / 2) ** 2 == 0.25 ||" +
 		" ((-a + -b) * -(c / d)) >> 2 != 0) && (a != 0 ? (1 + 2) * ((10 - 1) / 3) : ~1) == 9")
@@ -407,3 +435,35 @@ func BenchmarkEvalComplexOld(t *testing.B) {
 		}
 	}
 }
+
+func BenchmarkEvalComplexCompiled(t *testing.B) {
+	program, err := Compile(MustParse("(0 <= x && x < max && ((1 + y) / 2) ** 2 == 0.25 ||" +
+		" ((-a + -b) * -(c / d)) >> 2 != 0) && (a != 0 ? (1 + 2) * ((10 - 1) / 3) : ~1) == 9"), CompileOptions{})
+	assert.Nil(t, err)
+	t.ResetTimer()
+	for i := 0; i < t.N; i++ {
+		result, err := program.Run(map[string]interface{}{"x": 1.0, "max": 10.0, "y": 2.0, "a": 5.0, "b": 7.0, "c": 9.0, "d": 3.0})
+		if err != nil || result != true {
+			assert.Nil(t, err)
+			assert.Equal(t, true, result)
+			t.FailNow()
+		}
+	}
+}
+
+func BenchmarkFilterStructs(t *testing.B) {
+	items := make([]filterItem, 100)
+	for i := range items {
+		items[i] = filterItem{Name: "foo", Amount: float64(i)}
+	}
+	expr := MustParse("Amount >= min && Name == 'foo'")
+	params := NewEvalParams(map[string]interface{}{"min": 50.0})
+	t.ResetTimer()
+	for i := 0; i < t.N; i++ {
+		result, err := Filter(expr, items, params)
+		if err != nil || len(result.([]filterItem)) != 50 {
+			assert.Nil(t, err)
+			t.FailNow()
+		}
+	}
+}
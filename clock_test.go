@@ -0,0 +1,163 @@
+package govaluate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalWithClock(t *testing.T) {
+	clock := &FixedClock{Time: time.Date(2024, time.March, 9, 12, 30, 0, 0, time.UTC)}
+	params := NewEvalParams(map[string]interface{}{
+		"expiry": time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC),
+	})
+	params.Clock = clock
+	testCases := map[string]interface{}{
+		"now()":                      clock.Time,
+		"expiry - now()":             11*time.Hour + 30*time.Minute,
+		"now() < expiry":             true,
+		"dayOfWeek(now())":           6.0,
+		"truncate(now(), 'day')":     time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC),
+		"addDays(now(), 1) > expiry": true,
+	}
+	for input, expected := range testCases {
+		expr := MustParse(input)
+		actual, err := expr.Eval(params)
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+
+		program, err := Compile(expr, CompileOptions{Clock: clock})
+		require.NoError(t, err, "input=%s", input)
+		actual, err = program.Run(params.Variables)
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+	}
+
+	clock.Time = clock.Time.Add(12 * time.Hour)
+	actual, err := MustParse("now() < expiry").Eval(params)
+	require.NoError(t, err)
+	assert.Equal(t, false, actual)
+}
+
+func TestEvalClockOperators(t *testing.T) {
+	clock := FixedClock{Time: time.Date(2024, time.March, 9, 12, 30, 0, 0, time.UTC)}
+	params := EvalParams{
+		Operators: BuiltinOperators(),
+		Clock:     clock,
+	}
+	params.Operators["hoursAgo"] = ClockFunctionOperator(func(clock Clock, arguments ...interface{}) (interface{}, error) {
+		return clock.Now().Add(-time.Duration(arguments[0].(float64)) * time.Hour), nil
+	})
+	params.Operators["today"] = func(ctx EvalContext) (interface{}, error) {
+		return ctx.Now().Format("2006-01-02"), nil
+	}
+
+	actual, err := MustParse("hoursAgo(2)").Eval(params)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.March, 9, 10, 30, 0, 0, time.UTC), actual)
+
+	actual, err = MustParse("today()").Eval(params)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-09", actual)
+}
+
+func TestReduceClockOperators(t *testing.T) {
+	testCases := map[string]string{
+		"x < now() - (1h + 1h)":                    "x < now() - 2h0m0s",
+		"year(now()) == 2024":                      "year(now()) == 2024",
+		"hoursAgo(1 + 1)":                          "hoursAgo(2)",
+		"today() + '!'":                            `today() + "!"`,
+		"date('2024-03-09T12:00:00Z') + 1h < x":    `date("2024-03-09T13:00:00Z") < x`,
+		"now() > date('2024-03-09') && 1 + 1 == 2": `now() > date("2024-03-09T00:00:00Z")`,
+		"len(string(1)) + 1":                       "2",
+	}
+	params := EvalParams{
+		Operators: FunctionOperators(map[string]ExpressionFunction{
+			"string": func(arguments ...interface{}) (interface{}, error) {
+				return "1", nil
+			},
+		}),
+		Clock: FixedClock{Time: time.Date(2024, time.March, 9, 12, 30, 0, 0, time.UTC)},
+	}
+	params.Operators["hoursAgo"] = ClockFunctionOperator(func(clock Clock, arguments ...interface{}) (interface{}, error) {
+		return clock.Now().Add(-time.Duration(arguments[0].(float64)) * time.Hour), nil
+	})
+	params.Operators["today"] = func(ctx EvalContext) (interface{}, error) {
+		return ctx.Now().Format("2006-01-02"), nil
+	}
+	for input, expected := range testCases {
+		reduced, err := MustParse(input).Reduce(params, BuiltinOptimizers())
+		require.NoError(t, err, "input=%s", input)
+		actual, err := reduced.Print(PrintConfig{})
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+	}
+}
+
+func TestEvaluableExpressionClock(t *testing.T) {
+	functions := map[string]ExpressionFunction{
+		"days": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0].(float64) * 86400, nil
+		},
+	}
+	clockFunctions := map[string]ClockExpressionFunction{
+		"now": func(clock Clock, arguments ...interface{}) (interface{}, error) {
+			return float64(clock.Now().Unix()), nil
+		},
+		"daysSince": func(clock Clock, arguments ...interface{}) (interface{}, error) {
+			return float64(clock.Now().Unix()-int64(arguments[0].(float64))) / 86400, nil
+		},
+	}
+	input := "'2024-03-01' < now() - days(1) && daysSince('2024-03-01') > limit"
+	expression, err := NewEvaluableExpressionWithClockFunctions(input, functions, clockFunctions)
+	require.NoError(t, err)
+	expression.Clock = FixedClock{Time: time.Date(2024, time.March, 9, 0, 0, 0, 0, time.Local)}
+
+	actual, err := expression.Evaluate(map[string]interface{}{"limit": 7})
+	require.NoError(t, err)
+	assert.Equal(t, true, actual)
+
+	actual, err = expression.Evaluate(map[string]interface{}{"limit": 8})
+	require.NoError(t, err)
+	assert.Equal(t, false, actual)
+
+	// converted expressions take the clock from EvalParams
+	expr, err := expression.ToExprNode()
+	require.NoError(t, err)
+	params := EvalParams{
+		Variables: map[string]interface{}{"limit": 7},
+		Operators: FunctionOperators(functions),
+		Clock:     expression.Clock,
+	}
+	for name, function := range clockFunctions {
+		params.Operators[name] = ClockFunctionOperator(function)
+	}
+	actual, err = expr.Eval(params)
+	require.NoError(t, err)
+	assert.Equal(t, true, actual)
+}
+
+func TestEvaluableExpressionClockFunctionFromTokens(t *testing.T) {
+	var now ClockExpressionFunction = func(clock Clock, arguments ...interface{}) (interface{}, error) {
+		return clock.Now(), nil
+	}
+	expression, err := NewEvaluableExpressionFromTokens([]ExpressionToken{
+		{Kind: FUNCTION, Value: now},
+		{Kind: CLAUSE},
+		{Kind: CLAUSE_CLOSE},
+	})
+	require.NoError(t, err)
+	expression.Clock = FixedClock{Time: time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC)}
+	actual, err := expression.Evaluate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC), actual)
+
+	_, err = NewEvaluableExpressionFromTokens([]ExpressionToken{
+		{Kind: FUNCTION, Value: "now"},
+		{Kind: CLAUSE},
+		{Kind: CLAUSE_CLOSE},
+	})
+	assert.EqualError(t, err, "Unable to plan function of type string")
+}
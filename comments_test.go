@@ -0,0 +1,93 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenizeComments(t *testing.T) {
+	tokens, err := Tokenize("a // line\n+ /* block */ b/2")
+	require.NoError(t, err)
+	assert.Equal(t, []ExprToken{
+		{Kind: TokenKindIdentifier, Value: "a", SourcePos: 0, SourceLen: 1},
+		{Kind: TokenKindOperator, Value: "+", SourcePos: 10, SourceLen: 1},
+		{Kind: TokenKindIdentifier, Value: "b", SourcePos: 24, SourceLen: 1},
+		{Kind: TokenKindOperator, Value: "/", SourcePos: 25, SourceLen: 1},
+		{Kind: TokenKindNumber, Value: 2.0, SourcePos: 26, SourceLen: 1},
+	}, tokens)
+
+	_, err = Parse("a + /* not closed")
+	assert.EqualError(t, err, "unable to parse input at pos=4")
+}
+
+func TestParseComments(t *testing.T) {
+	expr, err := Parse("/* lead */ a + f(b /* after b */, /* before c */ c, /* empty */) // tail")
+	require.NoError(t, err)
+
+	assert.Equal(t, []Comment{
+		{Text: " lead ", Block: true, SourcePos: 0, SourceLen: 10},
+		{Text: " tail", Trailing: true, SourcePos: 65, SourceLen: 7},
+	}, expr.Comments)
+	call := expr.Args[1]
+	assert.Equal(t, []Comment{{Text: " after b ", Block: true, Trailing: true, SourcePos: 19, SourceLen: 13}}, call.Args[0].Comments)
+	assert.Equal(t, []Comment{{Text: " before c ", Block: true, SourcePos: 34, SourceLen: 14}}, call.Args[1].Comments)
+	assert.Equal(t, []Comment{{Text: " empty ", Block: true, Trailing: true, SourcePos: 52, SourceLen: 11}}, call.Comments)
+
+	// comments don't affect evaluation
+	value, err := expr.Eval(NewEvalParams(map[string]interface{}{"a": 1.0, "b": 2.0, "c": 3.0}))
+	assert.EqualError(t, err, "rhs of + / operator undefined: f [pos=15; len=49]")
+	value, err = MustParse("a + // plus one\n1").Eval(NewEvalParams(map[string]interface{}{"a": 1.0}))
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, value)
+}
+
+func TestPrintComments(t *testing.T) {
+	inputs := []string{
+		"/* lead */ a + b // tail",
+		"f(a /* first */, /* second */ b) == 1",
+		"x // x\n && y",
+		"(a || /* b */ b) && c",
+		"f() /* nothing */",
+	}
+	for _, input := range inputs {
+		output, err := MustParse(input).Print(PrintConfig{})
+		require.NoError(t, err)
+		assert.Equal(t, input, output)
+	}
+
+	expr := MustParse("status == 'active' && // must be active\n(age >= 18 /* adult */ || guardian) && /* verified */ verified")
+	output, err := expr.Print(PrintConfig{Width: 40})
+	require.NoError(t, err)
+	assert.Equal(t, `status == "active" &&
+// must be active
+(age >= 18 /* adult */ || guardian) &&
+/* verified */ verified`, output)
+
+	reformatted, err := MustParse(output).Print(PrintConfig{Width: 40})
+	require.NoError(t, err)
+	assert.Equal(t, output, reformatted)
+}
+
+func TestExprNodeJSONComments(t *testing.T) {
+	expr := MustParse("a /* x */ + b // y")
+	data, err := json.Marshal(expr)
+	require.NoError(t, err)
+
+	var actual ExprNode
+	require.NoError(t, json.Unmarshal(data, &actual))
+	assert.Equal(t, expr, actual)
+}
+
+func TestEvaluableExpressionComments(t *testing.T) {
+	expression, err := NewEvaluableExpression("1 + /* two */ 2 // three\n * 3")
+	require.NoError(t, err)
+	result, err := expression.Evaluate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 7.0, result)
+
+	_, err = NewEvaluableExpression("1 /* not closed")
+	assert.EqualError(t, err, "Unterminated block comment")
+}
@@ -0,0 +1,219 @@
+package govaluate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	type testCase struct {
+		input  string
+		params map[string]interface{}
+		result interface{}
+	}
+	testCases := [...]testCase{
+		testCase{
+			"x + y * z**2",
+			map[string]interface{}{"x": -1.0, "y": 3.0, "z": 5.0},
+			74.0,
+		},
+		testCase{
+			"x > 0 ? x ** 0.5 : -x + 1",
+			map[string]interface{}{"x": -6.4},
+			7.4,
+		},
+		testCase{
+			"false && something()",
+			map[string]interface{}{},
+			false,
+		},
+		testCase{
+			"item in [1, 2, 3, 5]",
+			map[string]interface{}{"item": 3.0},
+			true,
+		},
+		testCase{
+			"a[2] + (foo ? a : b)[1+1]",
+			map[string]interface{}{
+				"a":   []interface{}{1.0, 2.0, 3.0},
+				"b":   []interface{}{4.0, 5.0, 6.0},
+				"foo": false,
+			},
+			9.0,
+		},
+		testCase{
+			"a == 9",
+			map[string]interface{}{"a": MustParse("(b-1)"), "b": 10.0},
+			true,
+		},
+		testCase{
+			"a == 9",
+			map[string]interface{}{"a": uint(9)},
+			true,
+		},
+	}
+	for _, testCase := range testCases {
+		program, err := Compile(MustParse(testCase.input), CompileOptions{})
+		assert.Nil(t, err, "input=%s", testCase.input)
+		val, err := program.Run(testCase.params)
+		assert.Nil(t, err, "input=%s", testCase.input)
+		assert.Equal(t, testCase.result, val, "input=%s", testCase.input)
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	type testCase struct {
+		input  string
+		params map[string]interface{}
+	}
+	testCases := [...]testCase{
+		testCase{
+			"x + y * (z**2 > 0)",
+			map[string]interface{}{"x": 1.0, "y": 2.0, "z": 3.0},
+		},
+		testCase{
+			"[1, arr[0], 3]",
+			map[string]interface{}{"arr": 1.0},
+		},
+		testCase{
+			"2**floor(x, y)",
+			map[string]interface{}{},
+		},
+		testCase{
+			"1 + unknown(2)",
+			map[string]interface{}{},
+		},
+		testCase{
+			"x + 1",
+			map[string]interface{}{},
+		},
+		testCase{
+			"x + 1",
+			map[string]interface{}{"x": MustParse("x + 1")},
+		},
+		testCase{
+			"name =~ '(a'",
+			map[string]interface{}{"name": "abc"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		expr := MustParse(testCase.input)
+		_, evalErr := expr.Eval(NewEvalParams(testCase.params))
+		assert.NotNil(t, evalErr, "input=%s", testCase.input)
+
+		program, err := Compile(expr, CompileOptions{})
+		assert.Nil(t, err, "input=%s", testCase.input)
+		_, err = program.Run(testCase.params)
+		assert.Equal(t, evalErr, err, "input=%s", testCase.input)
+	}
+}
+
+func TestCompileRecoveredErrors(t *testing.T) {
+	// invalid parts are reported when they are evaluated, like by Eval
+	expr, parseErrors := ParseWithRecovery("x && (1 +)")
+	assert.Len(t, parseErrors, 1)
+
+	program, err := Compile(expr, CompileOptions{})
+	assert.Nil(t, err)
+	for _, x := range []bool{false, true} {
+		params := map[string]interface{}{"x": x}
+		evalVal, evalErr := expr.Eval(NewEvalParams(params))
+		val, err := program.Run(params)
+		assert.Equal(t, evalVal, val, "x=%v", x)
+		assert.Equal(t, evalErr, err, "x=%v", x)
+	}
+
+	_, err = program.Run(map[string]interface{}{"x": false})
+	assert.Nil(t, err)
+	_, err = program.Run(map[string]interface{}{"x": true})
+	assert.NotNil(t, err)
+}
+
+func TestCompileCustomOperators(t *testing.T) {
+	operators := BuiltinOperators()
+	operators["double"] = func(ctx EvalContext) (interface{}, error) {
+		if err := ctx.CheckArgCount(1); err != nil {
+			return nil, err
+		}
+		arg, err := ctx.NumericArg(0)
+		return arg * 2, err
+	}
+
+	expr := MustParse("double(x) + 1")
+	program, err := Compile(expr, CompileOptions{Operators: operators})
+	assert.Nil(t, err)
+	assert.Equal(t, expr, program.Expr())
+
+	val, err := program.Run(map[string]interface{}{"x": 4})
+	assert.Nil(t, err)
+	assert.Equal(t, 9.0, val)
+}
+
+func TestCompileRegexp(t *testing.T) {
+	program, err := Compile(MustParse("name =~ '^compiled-[0-9]+$' && name !~ pattern"), CompileOptions{})
+	assert.Nil(t, err)
+	val, err := program.Run(map[string]interface{}{"name": "compiled-1", "pattern": "^dynamic-[a-z]+"})
+	assert.Nil(t, err)
+	assert.Equal(t, true, val)
+
+	// only patterns built at runtime are cached
+	assert.NotContains(t, regexpCache.entries, "^compiled-[0-9]+$")
+	assert.Contains(t, regexpCache.entries, "^dynamic-[a-z]+")
+}
+
+func TestCompileLimits(t *testing.T) {
+	expr := MustParse("(x + 2) * (3 + 4) > 0")
+	vars := map[string]interface{}{"x": 1.0}
+	for _, limits := range []EvalLimits{{MaxSteps: 5}, {MaxDepth: 2}, {MaxSteps: 9, MaxDepth: 4}} {
+		program, err := Compile(expr, CompileOptions{Limits: limits})
+		assert.NoError(t, err)
+		params := NewEvalParams(vars)
+		params.Limits = limits
+		expected, expectedErr := expr.Eval(params)
+		val, err := program.Run(vars)
+		assert.Equal(t, expected, val)
+		assert.Equal(t, expectedErr, err)
+	}
+
+	program, err := Compile(expr, CompileOptions{})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = program.RunWithContext(ctx, vars)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestCompileRunWithParams(t *testing.T) {
+	program, err := Compile(MustParse("x > 5 || expensive > 100"), CompileOptions{Limits: EvalLimits{MaxSteps: 10}})
+	assert.NoError(t, err)
+
+	resolver := testCountingResolver{}
+	params := EvalParams{Variables: map[string]interface{}{"x": 1.0}, Resolver: resolver}
+	val, err := program.RunWithParams(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, false, val)
+	assert.Equal(t, testCountingResolver{"expensive": 1}, resolver)
+
+	params.Variables["x"] = 10.0
+	val, err = program.RunWithParams(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, true, val)
+	assert.Equal(t, testCountingResolver{"expensive": 1}, resolver)
+
+	params.Resolver = StructResolver(struct {
+		Expensive float64 `json:"expensive"`
+	}{101})
+	params.Variables["x"] = 1.0
+	val, err = program.RunWithParams(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, true, val)
+
+	params.Limits = EvalLimits{MaxSteps: 2}
+	_, err = program.RunWithParams(context.Background(), params)
+	var budgetErr BudgetExceededError
+	assert.True(t, errors.As(err, &budgetErr))
+}
@@ -0,0 +1,230 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseDecimals(t *testing.T, input string) ExprNode {
+	expr, err := ParseWithOptions(input, ParseOptions{Numbers: NumberModeDecimal})
+	require.NoError(t, err, "input=%s", input)
+	return expr
+}
+
+func decimal(text string) *big.Rat {
+	value, ok := new(big.Rat).SetString(text)
+	if !ok {
+		panic("invalid decimal: " + text)
+	}
+	return value
+}
+
+func TestParseDecimalLiterals(t *testing.T) {
+	testCases := map[string]string{
+		"0.1":                   "1/10",
+		"42":                    "42",
+		"0x1F":                  "31",
+		"123456789012345678901": "123456789012345678901",
+	}
+	for input, expected := range testCases {
+		expr := parseDecimals(t, input)
+		assert.Equal(t, NewExprNodeLiteral(decimal(expected), 0, len(input)), expr, "input=%s", input)
+	}
+}
+
+func TestEvalDecimals(t *testing.T) {
+	testCases := map[string]string{
+		"0.1 + 0.2":                 "0.3",
+		"price * qty":               "59.97",
+		"price * qty - discount":    "49.97",
+		"-price":                    "-19.99",
+		"1 / 3":                     "0.3333",
+		"2 / 3":                     "0.6667",
+		"10 / 4":                    "2.5",
+		"7.5 % 2":                   "1.5",
+		"-7.5 % 2":                  "-1.5",
+		"1.1 ** 2":                  "1.21",
+		"2 ** -2":                   "0.25",
+		"3 ** -1":                   "0.3333",
+		"4 ** 0.5":                  "2",
+		"round(2.5)":                "2",
+		"round(3.5)":                "4",
+		"round(2.345, 2)":           "2.34",
+		"round(2.355, 2)":           "2.36",
+		"floor(-2.5)":               "-3",
+		"ceil(2.1)":                 "3",
+		"abs(-0.5)":                 "0.5",
+		"min(0.3, 0.1 + 0.1)":       "0.2",
+		"max(rate, 0.05)":           "0.07",
+		"x > 0 ? x * 1.5 : 0":       "15",
+		"[1.5, 2.5][1] + 0":         "2.5",
+		"123456789012345678901 + 1": "123456789012345678902",
+		"len('abc')":                "3",
+		"count([1, 2], x => x > 1)": "1",
+		"year(start) + 0.5":         "2024.5",
+	}
+	params := EvalParams{
+		Variables: map[string]interface{}{
+			"price":    decimal("19.99"),
+			"qty":      3,
+			"discount": 10.0,
+			"rate":     float32(0.07),
+			"x":        big.NewInt(10),
+			"start":    time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC),
+		},
+		Operators: DecimalOperators(4, big.ToNearestEven),
+	}
+	for input, expected := range testCases {
+		expr := parseDecimals(t, input)
+		actual, err := expr.Eval(params)
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, decimal(expected).String(), actual.(*big.Rat).String(), "input=%s", input)
+
+		program, err := Compile(expr, CompileOptions{Operators: params.Operators})
+		require.NoError(t, err, "input=%s", input)
+		actual, err = program.Run(params.Variables)
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, decimal(expected).String(), actual.(*big.Rat).String(), "input=%s", input)
+	}
+}
+
+func TestFormatDecimals(t *testing.T) {
+	testCases := map[string]string{
+		"format('%v', 1.5)":             "1.5",
+		"format('%s', 1 / 3)":           "0.33",
+		"format('%v', [0.1, {a: 2.5}])": "[0.1 map[a:2.5]]",
+		"format('%.3f', 0.125 + 1)":     "1.125",
+		"format('%+6.1f|', 2.25)":       "  +2.3|",
+		"format('%-6v|', 2.5)":          "2.5   |",
+		"format('%f', rate)":            "0.070000",
+		"format('%d', 3.0)":             "3",
+		"format('%05d', len('ab'))":     "00002",
+		"format('%.2e', 1234.5)":        "1.23e+03",
+		"format('%v %v', 'a', true)":    "a true",
+	}
+	params := EvalParams{
+		Variables: map[string]interface{}{"rate": 0.07},
+		Operators: DecimalOperators(2, big.ToNearestEven),
+	}
+	for input, expected := range testCases {
+		actual, err := parseDecimals(t, input).Eval(params)
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+	}
+}
+
+func TestTypeOfDecimals(t *testing.T) {
+	assert.True(t, TypeNumber.Equal(TypeOf(decimal("1.5"))))
+	assert.True(t, TypeNumber.Equal(TypeOf(big.NewFloat(1.5))))
+	assert.True(t, TypeNumber.Equal(TypeOf(big.NewInt(1))))
+
+	result, errors := TypeCheck(parseDecimals(t, "0.1 + x > 1 ? [0.5] : [2]"), NewTypeEnv(map[string]Type{"x": TypeNumber}))
+	assert.Empty(t, errors)
+	assert.True(t, TypeArrayOf(TypeNumber).Equal(result), "result=%v", result)
+}
+
+func TestEvalDecimalsBoolean(t *testing.T) {
+	testCases := map[string]bool{
+		"0.1 + 0.2 == 0.3":                       true,
+		"0.1 + 0.2 != 0.3":                       false,
+		"total == 0.3":                           true,
+		"total < 0.30001":                        true,
+		"total <= 0.3":                           true,
+		"total > 0.3":                            false,
+		"total >= 0.3":                           true,
+		"n == 2":                                 true,
+		"0.2 in [0.1, 0.2]":                      true,
+		"'a' == 'a'":                             true,
+		"'a' == 1":                               false,
+		"sqrt(4) == 2":                           true,
+		"len('abc') == 3":                        true,
+		"[1, 2, 3][n] == 3":                      true,
+		"round(1.005, 2) == 1":                   true,
+		"[0.1 + 0.2, {a: n}] == [total, {a: 2}]": true,
+		"[total] in [[0.3]]":                     true,
+	}
+	params := EvalParams{
+		Variables: map[string]interface{}{"total": 0.3, "n": int64(2)},
+		Operators: DecimalOperators(2, big.ToZero),
+	}
+	for input, expected := range testCases {
+		actual, err := parseDecimals(t, input).Eval(params)
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+	}
+}
+
+func TestDecimalRounding(t *testing.T) {
+	type testCase struct {
+		rounding big.RoundingMode
+		expected [4]string
+	}
+	inputs := [4]string{"2.5", "-2.5", "2.4", "-2.6"}
+	testCases := []testCase{
+		{big.ToNearestEven, [4]string{"2", "-2", "2", "-3"}},
+		{big.ToNearestAway, [4]string{"3", "-3", "2", "-3"}},
+		{big.ToZero, [4]string{"2", "-2", "2", "-2"}},
+		{big.AwayFromZero, [4]string{"3", "-3", "3", "-3"}},
+		{big.ToNegativeInf, [4]string{"2", "-3", "2", "-3"}},
+		{big.ToPositiveInf, [4]string{"3", "-2", "3", "-2"}},
+	}
+	for _, testCase := range testCases {
+		for idx, input := range inputs {
+			actual := roundDecimal(decimal(input), 0, testCase.rounding)
+			assert.Equal(t, decimal(testCase.expected[idx]).String(), actual.String(), "rounding=%v, input=%s", testCase.rounding, input)
+		}
+	}
+}
+
+func TestEvalDecimalsError(t *testing.T) {
+	testCases := map[string]string{
+		"1 / 0":           "division by zero [op=/; pos=0; len=5]",
+		"1 % 0":           "division by zero [op=%; pos=0; len=5]",
+		"0 ** -1":         "division by zero [op=**; pos=0; len=7]",
+		"2 ** 5000":       "rhs of ** is too large: 5000 [pos=5; len=4]",
+		"'a' + 1":         "lhs of + is not numeric: a [pos=0; len=3]",
+		"round(1.5, 0.5)": "argument #2 of round is not a valid number of digits: 1/2 [pos=11; len=3]",
+		"[1, 2][0.5]":     "index is not integer: 0.5 [pos=7; len=3]",
+	}
+	params := EvalParams{Operators: DecimalOperators(2, big.ToNearestEven)}
+	for input, expected := range testCases {
+		_, err := parseDecimals(t, input).Eval(params)
+		assert.EqualError(t, err, expected, "input=%s", input)
+	}
+}
+
+func TestReduceDecimals(t *testing.T) {
+	testCases := map[string]string{
+		"x + 0.1 + 0.2":   "x + 0.1 + 0.2",
+		"x + (0.1 + 0.2)": "x + 0.3",
+		"x * (2 - 1)":     "x",
+		"1 / 8 + x":       "0.125 + x",
+	}
+	params := EvalParams{Operators: DecimalOperators(4, big.ToNearestEven)}
+	for input, expected := range testCases {
+		reduced, err := parseDecimals(t, input).Reduce(params, BuiltinOptimizers())
+		require.NoError(t, err, "input=%s", input)
+		actual, err := reduced.Print(PrintConfig{})
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+	}
+
+	_, err := NewExprNodeLiteral(big.NewRat(1, 3), 0, 0).Print(PrintConfig{})
+	assert.EqualError(t, err, "unsupported literal: infinite decimal 1/3")
+}
+
+func TestDecimalsJSON(t *testing.T) {
+	expr := parseDecimals(t, "0.1 + 0.2")
+	data, err := json.Marshal(expr)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"valueType":"decimal","value":"1/10"`)
+
+	var actual ExprNode
+	require.NoError(t, json.Unmarshal(data, &actual))
+	assert.Equal(t, expr, actual)
+}
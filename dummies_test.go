@@ -32,6 +32,10 @@ func (this dummyParameter) FuncArgStr(arg1 string) string {
 	return arg1
 }
 
+func (this dummyParameter) FuncArgInt(arg1 int) int {
+	return arg1
+}
+
 func (this dummyParameter) TestArgs(str string, ui uint, ui8 uint8, ui16 uint16, ui32 uint32, ui64 uint64, i int, i8 int8, i16 int16, i32 int32, i64 int64, f32 float32, f64 float64, b bool) string {
 	
 	var sum float64
@@ -0,0 +1,98 @@
+package govaluate
+
+import (
+	"errors"
+	"regexp/syntax"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseErrorType(t *testing.T) {
+	_, err := Parse("1 + (2 * 3]")
+	var parseErr ParseError
+	require.True(t, errors.As(err, &parseErr))
+	assert.Equal(t, "unmatched bracket: ']', expecting ')', pos: 10", err.Error())
+	assert.Equal(t, 10, parseErr.SourcePos)
+	assert.Equal(t, 1, parseErr.SourceLen)
+	assert.Equal(t, []string{"')'"}, parseErr.Expected)
+
+	_, err = Parse("1 + ")
+	require.True(t, errors.As(err, &parseErr))
+	assert.Equal(t, "unexpected eof, expecting value", err.Error())
+	assert.True(t, parseErr.Token.Is(TokenKindEOF, nil))
+	assert.Equal(t, 4, parseErr.SourcePos)
+
+	_, err = Parse("1 + 'abc")
+	require.True(t, errors.As(err, &parseErr))
+	assert.Equal(t, "unable to parse input at pos=4", err.Error())
+	assert.Equal(t, 4, parseErr.SourcePos)
+	assert.Equal(t, 4, parseErr.SourceLen)
+}
+
+func TestEvalErrorType(t *testing.T) {
+	eval := func(input string, vars map[string]interface{}) error {
+		_, err := MustParse(input).Eval(NewEvalParams(vars))
+		require.Error(t, err)
+		return err
+	}
+
+	err := eval("1 + foo", nil)
+	var undefinedVar UndefinedVariableError
+	require.True(t, errors.As(err, &undefinedVar))
+	assert.Equal(t, UndefinedVariableError{Name: "foo", SourcePos: 4, SourceLen: 3}, undefinedVar)
+
+	var evalErr EvalError
+	require.True(t, errors.As(err, &evalErr))
+	assert.Equal(t, "+", evalErr.Operator)
+	assert.Equal(t, 1, evalErr.ArgIndex)
+	assert.Equal(t, "rhs of +", evalErr.ArgName)
+	assert.Equal(t, 0, evalErr.SourcePos)
+	assert.Equal(t, 7, evalErr.SourceLen)
+
+	err = eval("1 + bar(2)", nil)
+	var undefinedOp UndefinedOperatorError
+	require.True(t, errors.As(err, &undefinedOp))
+	assert.Equal(t, UndefinedOperatorError{Name: "bar", SourcePos: 4, SourceLen: 6}, undefinedOp)
+
+	err = eval("2 * (true + 1)", nil)
+	var argErr ArgTypeError
+	require.True(t, errors.As(err, &argErr))
+	assert.Equal(t, "+", argErr.Operator)
+	assert.Equal(t, 0, argErr.ArgIndex)
+	assert.Equal(t, "lhs of +", argErr.ArgName)
+	assert.Equal(t, "is not numeric: true", argErr.Message)
+	assert.Equal(t, 5, argErr.SourcePos)
+	assert.Equal(t, 4, argErr.SourceLen)
+
+	err = eval("[1, 2][5]", nil)
+	require.True(t, errors.As(err, &evalErr))
+	assert.Equal(t, "[]", evalErr.Operator)
+	assert.Equal(t, -1, evalErr.ArgIndex)
+	assert.Nil(t, evalErr.Cause)
+
+	err = eval("'abc' =~ '('", nil)
+	require.True(t, errors.As(err, &argErr))
+	assert.Equal(t, 1, argErr.ArgIndex)
+	var syntaxErr *syntax.Error
+	require.True(t, errors.As(err, &syntaxErr))
+}
+
+type testFailingAccessor struct {
+	err error
+}
+
+func (a testFailingAccessor) GetMember(name string) (interface{}, bool, error) {
+	return nil, false, a.err
+}
+
+func TestEvalErrorCause(t *testing.T) {
+	cause := errors.New("access denied")
+	vars := map[string]interface{}{
+		"obj": testFailingAccessor{cause},
+	}
+	_, err := MustParse("obj.secret").Eval(NewEvalParams(vars))
+	assert.True(t, errors.Is(err, cause))
+	assert.Equal(t, "access denied [op=.; pos=0; len=10]", err.Error())
+}
@@ -1,10 +1,13 @@
 package govaluate
 
 import (
+	"context"
+	"errors"
 	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEval(t *testing.T) {
@@ -173,6 +176,183 @@ func TestEval(t *testing.T) {
 			map[string]interface{}{"a": TryParse("0x12g1")},
 			true,
 		},
+		testCase{
+			"{a: x + 1, 'b': [x]}",
+			map[string]interface{}{"x": 1.0},
+			map[string]interface{}{"a": 2.0, "b": []interface{}{1.0}},
+		},
+		testCase{
+			"{a: {b: 3}}['a'][key] * 2",
+			map[string]interface{}{"key": "b"},
+			6.0,
+		},
+		testCase{
+			"obj['a'] + 1",
+			map[string]interface{}{"obj": map[string]interface{}{"a": 1.0}},
+			2.0,
+		},
+		testCase{
+			"{a: 1, b: [x]} == {b: [1], a: 1}",
+			map[string]interface{}{"x": 1.0},
+			true,
+		},
+		testCase{
+			"{a: 1} != {a: 1, b: 2} && {a: [1]} != {a: [2]} && [1, 2] != [1]",
+			map[string]interface{}{},
+			true,
+		},
+		testCase{
+			"[[1], {a: 1}] == [[1], obj] && [1] == slice",
+			map[string]interface{}{"obj": map[string]interface{}{"a": 1.0}, "slice": []interface{}{1.0}},
+			true,
+		},
+		testCase{
+			"[1] in [[2], [1]] && {a: 1} in [{a: 1}] && !([1] in [1, {}])",
+			map[string]interface{}{},
+			true,
+		},
+		testCase{
+			"names == other",
+			map[string]interface{}{"names": []string{"a"}, "other": []string{"a"}},
+			true,
+		},
+		testCase{
+			"name =~ '^a' && name !~ 'z$'",
+			map[string]interface{}{"name": "abc"},
+			true,
+		},
+		testCase{
+			"name =~ pattern",
+			map[string]interface{}{"name": "abc", "pattern": "b+"},
+			true,
+		},
+		testCase{
+			"name !~ '^a'",
+			map[string]interface{}{"name": "abc"},
+			false,
+		},
+	}
+	for _, testCase := range testCases {
+		expr, err := Parse(testCase.input)
+		assert.Nil(t, err, "input=%s", testCase.input)
+		val, err := expr.Eval(NewEvalParams(testCase.params))
+		assert.Nil(t, err, "input=%s", testCase.input)
+		assert.Equal(t, testCase.result, val, "input=%s", testCase.input)
+	}
+}
+
+func TestEvalStrings(t *testing.T) {
+	type testCase struct {
+		input  string
+		result interface{}
+	}
+	testCases := [...]testCase{
+		testCase{"len('héllo')", 5.0},
+		testCase{"len([1, 2])", 2.0},
+		testCase{"len({a: 1})", 1.0},
+		testCase{"lower('ÀBC')", "àbc"},
+		testCase{"upper('àbc')", "ÀBC"},
+		testCase{"trim('  abc \\n')", "abc"},
+		testCase{"trim('--abc-', '-')", "abc"},
+		testCase{"contains('abc', 'bc')", true},
+		testCase{"startsWith('abc', 'b')", false},
+		testCase{"endsWith('abc', 'bc')", true},
+		testCase{"substr('héllo', 1)", "éllo"},
+		testCase{"substr('héllo', 1, 3)", "éll"},
+		testCase{"substr('héllo', 3, 10)", "lo"},
+		testCase{"substr('héllo', 5)", ""},
+		testCase{"replace('a-b-c', '-', '+')", "a+b+c"},
+		testCase{"split('a,b', ',')", []interface{}{"a", "b"}},
+		testCase{"join(split('a,b', ','), '; ')", "a; b"},
+		testCase{"format('%s=%v', 'x', 1.5)", "x=1.5"},
+		testCase{"repeat('ab', 3)", "ababab"},
+	}
+	for _, testCase := range testCases {
+		expr, err := Parse(testCase.input)
+		assert.Nil(t, err, "input=%s", testCase.input)
+		val, err := expr.Eval(NewEvalParams(map[string]interface{}{}))
+		assert.Nil(t, err, "input=%s", testCase.input)
+		assert.Equal(t, testCase.result, val, "input=%s", testCase.input)
+	}
+}
+
+type testMemberAccessor map[string]interface{}
+
+func (a testMemberAccessor) GetMember(name string) (interface{}, bool, error) {
+	value, found := a[name]
+	return value, found, nil
+}
+
+func (a testMemberAccessor) CallMethod(name string, args []interface{}) (interface{}, bool, error) {
+	if name == "args" {
+		return args, true, nil
+	}
+	return nil, false, nil
+}
+
+func TestRegexpLRU(t *testing.T) {
+	cache := newRegexpLRU(2)
+	a, err := cache.compile("a")
+	require.NoError(t, err)
+	_, err = cache.compile("b")
+	require.NoError(t, err)
+
+	cached, err := cache.compile("a")
+	require.NoError(t, err)
+	assert.True(t, a == cached)
+
+	// b is the least recently used
+	_, err = cache.compile("c")
+	require.NoError(t, err)
+	assert.Equal(t, 2, cache.order.Len())
+	assert.Contains(t, cache.entries, "a")
+	assert.NotContains(t, cache.entries, "b")
+
+	_, err = cache.compile("(")
+	assert.Error(t, err)
+	assert.Equal(t, 2, len(cache.entries))
+}
+
+type testInner struct {
+	X int
+}
+
+type testOuter struct {
+	*testInner
+	Y int
+}
+
+func TestEvalMember(t *testing.T) {
+	type testCase struct {
+		input  string
+		params map[string]interface{}
+		result interface{}
+	}
+	params := map[string]interface{}{
+		"foo":    dummyParameterInstance,
+		"fooptr": &dummyParameterInstance,
+		"obj":    map[string]interface{}{"a": map[string]int{"b": 7}},
+		"acc":    testMemberAccessor{"x": 1.0, "y": "str"},
+		"outer":  testOuter{testInner: &testInner{X: 2}, Y: 3},
+	}
+	testCases := [...]testCase{
+		testCase{"foo.String", params, "string!"},
+		testCase{"foo.Int + 1", params, 102.0},
+		testCase{"foo.Nested.Funk", params, "funkalicious"},
+		testCase{"foo.Func()", params, "funk"},
+		testCase{"foo.Func", params, "funk"},
+		testCase{"foo.Func2()", params, "frink"},
+		testCase{"fooptr.Func3()", params, "fronk"},
+		testCase{"fooptr.Nested.Dunk('boop')", params, "boopdunk"},
+		testCase{"foo.FuncArgStr('boop')", params, "boop"},
+		testCase{"foo.FuncArgInt(2.0 * 3)", params, 6},
+		testCase{"foo.TestArgs(\"hello\", 1, 2, 3, 4, 5, 1, 2, 3, 4, 5, 1.0, 2.0, true)", params, "hello: 33"},
+		testCase{"foo.Nil ?? 5", params, 5.0},
+		testCase{"obj.a.b * 2", params, 14.0},
+		testCase{"{x: {y: 3}}.x.y", params, 3.0},
+		testCase{"acc.x + 1", params, 2.0},
+		testCase{"acc.args(1, acc.y)", params, []interface{}{1.0, "str"}},
+		testCase{"outer.X + outer.Y", params, 5.0},
 	}
 	for _, testCase := range testCases {
 		expr, err := Parse(testCase.input)
@@ -215,6 +395,106 @@ func TestEvalError(t *testing.T) {
 			map[string]interface{}{},
 			"lhs of * / index out of bounds: 3, len: 3 [op=[]; pos=0; len=12]",
 		},
+		testCase{
+			"{a: 1}['b']",
+			map[string]interface{}{},
+			"key not found: b [op=[]; pos=0; len=11]",
+		},
+		testCase{
+			"{a: 1}[0]",
+			map[string]interface{}{},
+			"index is not string: 0 [pos=7; len=1]",
+		},
+		testCase{
+			"foo.Missing",
+			map[string]interface{}{"foo": dummyParameterInstance},
+			"govaluate.dummyParameter has no field or method Missing [op=.; pos=0; len=11]",
+		},
+		testCase{
+			"outer.X",
+			map[string]interface{}{"outer": testOuter{Y: 3}},
+			"govaluate.testOuter has no field or method X [op=.; pos=0; len=7]",
+		},
+		testCase{
+			"foo.Func3()",
+			map[string]interface{}{"foo": dummyParameterInstance},
+			"govaluate.dummyParameter has no method Func3 [op=.(); pos=0; len=11]",
+		},
+		testCase{
+			"foo.AlwaysFail()",
+			map[string]interface{}{"foo": dummyParameterInstance},
+			"function should always fail [op=.(); pos=0; len=16]",
+		},
+		testCase{
+			"foo.FuncArgStr(1, 2)",
+			map[string]interface{}{"foo": dummyParameterInstance},
+			"wrong number of arguments: 2, expected: 1 [op=.(); pos=0; len=20]",
+		},
+		testCase{
+			"foo.FuncArgStr(x + 1)",
+			map[string]interface{}{"foo": dummyParameterInstance, "x": true},
+			"argument #1 of FuncArgStr() / lhs of + is not numeric: true [pos=15; len=1]",
+		},
+		testCase{
+			"foo.FuncArgStr(1)",
+			map[string]interface{}{"foo": dummyParameterInstance},
+			"argument #1 of FuncArgStr() can not be converted to string: 1 [pos=15; len=1]",
+		},
+		testCase{
+			"foo.FuncArgInt(1.9)",
+			map[string]interface{}{"foo": dummyParameterInstance},
+			"argument #1 of FuncArgInt() can not be converted to int: 1.9 [pos=15; len=3]",
+		},
+		testCase{
+			"foo.FuncArgInt(2**64)",
+			map[string]interface{}{"foo": dummyParameterInstance},
+			"argument #1 of FuncArgInt() can not be converted to int: 1.8446744073709552e+19 [pos=15; len=5]",
+		},
+		testCase{
+			"name =~ '(a'",
+			map[string]interface{}{"name": "abc"},
+			"rhs of =~ is not a valid regular expression: error parsing regexp: missing closing ): `(a` [pos=8; len=4]",
+		},
+		testCase{
+			"name !~ 'a'",
+			map[string]interface{}{"name": 1.0},
+			"lhs of !~ is not string: 1 [pos=0; len=4]",
+		},
+		testCase{
+			"substr('héllo', 6)",
+			map[string]interface{}{},
+			"argument #2 of substr is out of range: 6, len: 5 [pos=17; len=1]",
+		},
+		testCase{
+			"repeat('a', -1)",
+			map[string]interface{}{},
+			"argument #2 of repeat is negative: -1 [pos=12; len=2]",
+		},
+		testCase{
+			"repeat('ab', 9000000000000000000)",
+			map[string]interface{}{},
+			"argument #2 of repeat is too large: 9000000000000000000, string length limit: 16777216 [pos=13; len=19]",
+		},
+		testCase{
+			"join([1], ',')",
+			map[string]interface{}{},
+			"argument #1 of join item #1 is not string: 1 [pos=5; len=3]",
+		},
+		testCase{
+			"upper(1)",
+			map[string]interface{}{},
+			"argument #1 of upper is not string: 1 [pos=6; len=1]",
+		},
+		testCase{
+			"len(true)",
+			map[string]interface{}{},
+			"argument #1 of len has no length: true [pos=4; len=4]",
+		},
+		testCase{
+			"{a: 1 + x}",
+			map[string]interface{}{"x": true},
+			"object item \"a\" / rhs of + is not numeric: true [pos=8; len=1]",
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -224,3 +504,107 @@ func TestEvalError(t *testing.T) {
 		assert.EqualError(t, err, testCase.err, "input=%s", testCase.input)
 	}
 }
+
+func TestEvalWithContext(t *testing.T) {
+	expr := MustParse("(x + 2) * (3 + 4) > 0")
+	params := NewEvalParams(map[string]interface{}{"x": 1.0})
+
+	val, err := expr.EvalWithContext(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, true, val)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = expr.EvalWithContext(ctx, params)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	params.Limits = EvalLimits{MaxSteps: 5}
+	_, err = expr.Eval(params)
+	var budgetErr BudgetExceededError
+	require.True(t, errors.As(err, &budgetErr))
+	assert.Equal(t, BudgetExceededError{Limit: "step", Max: 5, SourcePos: 10, SourceLen: 7}, budgetErr)
+
+	params.Limits = EvalLimits{MaxDepth: 2}
+	_, err = expr.Eval(params)
+	require.True(t, errors.As(err, &budgetErr))
+	assert.Equal(t, BudgetExceededError{Limit: "depth", Max: 2, SourcePos: 0, SourceLen: 7}, budgetErr)
+	assert.EqualError(t, err, "lhs of > / lhs of * / depth limit exceeded: 2 [pos=0; len=7]")
+
+	params.Limits = EvalLimits{MaxSteps: 9, MaxDepth: 4}
+	_, err = expr.Eval(params)
+	assert.NoError(t, err)
+
+	params.Limits = EvalLimits{MaxStringLength: 5}
+	_, err = MustParse("repeat('ab', 3)").Eval(params)
+	require.True(t, errors.As(err, &budgetErr))
+	assert.Equal(t, BudgetExceededError{Limit: "string length", Max: 5}, budgetErr)
+	val, err = MustParse("len(repeat('ab', 2)) + len(repeat('', 100))").Eval(params)
+	assert.NoError(t, err)
+	assert.Equal(t, 4.0, val)
+}
+
+func TestEvalContextOperator(t *testing.T) {
+	type ctxKey struct{}
+	params := NewEvalParams(nil)
+	params.Operators = map[string]Operator{
+		"value": func(ctx EvalContext) (interface{}, error) {
+			return ctx.Context().Value(ctxKey{}), nil
+		},
+	}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "foo")
+	val, err := MustParse("value()").EvalWithContext(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", val)
+
+	val, err = MustParse("value()").Eval(params)
+	assert.NoError(t, err)
+	assert.Nil(t, val)
+}
+
+type testCountingResolver map[string]int
+
+func (r testCountingResolver) Resolve(name string) (interface{}, bool, error) {
+	r[name]++
+	switch name {
+	case "fail":
+		return nil, false, errors.New("fetch failed")
+	case "missing":
+		return nil, false, nil
+	}
+	return float64(len(name)), true, nil
+}
+
+func TestEvalResolver(t *testing.T) {
+	resolver := testCountingResolver{}
+	params := NewEvalParams(map[string]interface{}{"x": 10.0})
+	params.Resolver = resolver
+
+	val, err := MustParse("x > 5 || expensive > 0").Eval(params)
+	assert.NoError(t, err)
+	assert.Equal(t, true, val)
+	assert.Equal(t, testCountingResolver{}, resolver)
+
+	val, err = MustParse("x + abc * abc").Eval(params)
+	assert.NoError(t, err)
+	assert.Equal(t, 19.0, val)
+	assert.Equal(t, testCountingResolver{"abc": 2}, resolver)
+
+	_, err = MustParse("x + missing").Eval(params)
+	assert.EqualError(t, err, "rhs of + / variable undefined: missing [pos=4; len=7]")
+
+	_, err = MustParse("x + fail").Eval(params)
+	assert.EqualError(t, err, "rhs of + / fetch failed [pos=4; len=4]")
+	assert.Equal(t, "fetch failed", errors.Unwrap(errors.Unwrap(err)).Error())
+}
+
+func TestEvalParametersResolver(t *testing.T) {
+	params := NewEvalParams(nil)
+	params.Resolver = ParametersResolver(MapParameters{"foo": 2.0})
+
+	val, err := MustParse("foo * 3").Eval(params)
+	assert.NoError(t, err)
+	assert.Equal(t, 6.0, val)
+
+	_, err = MustParse("bar").Eval(params)
+	assert.EqualError(t, err, "variable undefined: bar [pos=0; len=3]")
+}
@@ -230,21 +230,36 @@ func makeLiteralStage(literal interface{}) evaluationOperator {
 	}
 }
 
-func makeFunctionStage(function ExpressionFunction) evaluationOperator {
+func makeFunctionStage(function interface{}) (evaluationOperator, error) {
+
+	var call func(parameters Parameters, arguments ...interface{}) (interface{}, error)
+
+	switch function := function.(type) {
+	case ExpressionFunction:
+		call = func(parameters Parameters, arguments ...interface{}) (interface{}, error) {
+			return function(arguments...)
+		}
+	case ClockExpressionFunction:
+		call = func(parameters Parameters, arguments ...interface{}) (interface{}, error) {
+			return function(parametersClock(parameters), arguments...)
+		}
+	default:
+		return nil, fmt.Errorf("Unable to plan function of type %T", function)
+	}
 
 	return func(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
 
 		if right == nil {
-			return function()
+			return call(parameters)
 		}
 
 		switch right.(type) {
 		case []interface{}:
-			return function(right.([]interface{})...)
+			return call(parameters, right.([]interface{})...)
 		default:
-			return function(right)
+			return call(parameters, right)
 		}
-	}
+	}, nil
 }
 
 func typeConvertParam(p reflect.Value, t reflect.Type) (ret reflect.Value, err error) {
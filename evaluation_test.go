@@ -1,6 +1,7 @@
 package govaluate
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -1465,6 +1466,45 @@ func TestStructFunctions(test *testing.T) {
 	}
 }
 
+/*
+	Tests cancellation and limits of evaluation.
+*/
+func TestEvaluableExpressionWithContext(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("(1 + 2) * (3 + 4) > foo")
+	parameters := MapParameters(map[string]interface{}{"foo": 1})
+
+	result, err := expression.EvalWithContext(context.Background(), parameters)
+	if err != nil || result != true {
+		test.Logf("Expected true, got %v, error: %v", result, err)
+		test.Fail()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = expression.EvalWithContext(ctx, parameters)
+	if !errors.Is(err, context.Canceled) {
+		test.Logf("Expected context.Canceled, got %v", err)
+		test.Fail()
+	}
+
+	expression.Limits = EvalLimits{MaxSteps: 5}
+	_, err = expression.Eval(parameters)
+
+	var budgetErr BudgetExceededError
+	if !errors.As(err, &budgetErr) || budgetErr.Limit != "step" || err.Error() != "step limit exceeded: 5" {
+		test.Logf("Expected step limit error, got %v", err)
+		test.Fail()
+	}
+
+	expression.Limits = EvalLimits{MaxDepth: 2}
+	_, err = expression.Eval(parameters)
+	if !errors.As(err, &budgetErr) || budgetErr.Limit != "depth" {
+		test.Logf("Expected depth limit error, got %v", err)
+		test.Fail()
+	}
+}
+
 func runEvaluationTests(evaluationTests []EvaluationTest, test *testing.T) {
 
 	var expression *EvaluableExpression
@@ -6,3 +6,9 @@ package govaluate
 	An error returned will halt execution of the expression.
 */
 type ExpressionFunction func(arguments ...interface{}) (interface{}, error)
+
+/*
+	Represents a function which is given the clock of the evaluation before its arguments, see `EvaluableExpression.Clock`.
+	Time-aware functions, like `now()`, should use it instead of the wall clock, so they can be tested with `FixedClock`.
+*/
+type ClockExpressionFunction func(clock Clock, arguments ...interface{}) (interface{}, error)
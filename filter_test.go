@@ -0,0 +1,116 @@
+package govaluate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type filterBase struct {
+	ID int
+}
+
+type filterItem struct {
+	filterBase
+	Name   string
+	Amount float64
+	hidden int
+}
+
+func TestFilter(test *testing.T) {
+
+	items := []filterItem{
+		{filterBase{1}, "foo", 10, 0},
+		{filterBase{2}, "bar", 20, 0},
+		{filterBase{3}, "baz", 30, 0},
+	}
+	params := NewEvalParams(map[string]interface{}{"min": 15, "Amount": 0})
+
+	result, err := Filter(MustParse("Amount > min && Name != 'baz'"), items, params)
+	require.NoError(test, err)
+	assert.Equal(test, []filterItem{items[1]}, result)
+
+	// pointers and promoted fields
+	pointers := []*filterItem{&items[0], &items[1], &items[2]}
+	result, err = Filter(MustParse("ID % 2 == 1"), pointers, params)
+	require.NoError(test, err)
+	assert.Equal(test, []*filterItem{&items[0], &items[2]}, result)
+
+	// maps, both generic and typed
+	objects := []map[string]interface{}{{"a": 1}, {"a": 2}}
+	result, err = Filter(MustParse("a >= 2"), objects, EvalParams{})
+	require.NoError(test, err)
+	assert.Equal(test, []map[string]interface{}{{"a": 2}}, result)
+
+	typed := []map[string]int{{"a": 1}, {"a": 2}}
+	result, err = Filter(MustParse("a < 2"), typed, EvalParams{})
+	require.NoError(test, err)
+	assert.Equal(test, []map[string]int{{"a": 1}}, result)
+}
+
+func TestSelect(test *testing.T) {
+
+	items := []interface{}{
+		filterItem{Name: "foo", Amount: 10},
+		&filterItem{Name: "bar", Amount: 20},
+		map[string]interface{}{"Name": "baz", "Amount": 30},
+	}
+	params := EvalParams{Resolver: MapResolver{"rate": 0.5}}
+
+	result, err := Select(MustParse("Amount * rate"), items, params)
+	require.NoError(test, err)
+	assert.Equal(test, []interface{}{5.0, 10.0, 15.0}, result)
+}
+
+func TestFilterTaggedStructs(test *testing.T) {
+
+	users := []paramsUser{
+		{paramsAudit: &paramsAudit{Created: "today"}, Name: "foo", Home: &paramsAddress{City: "Paris"}},
+		{Name: "bar", Work: paramsAddress{City: "Berlin", Zip: "10115"}},
+	}
+
+	// fields are named like in StructParameters, and member access accepts the same names
+	result, err := Select(MustParse("[name, work.city, work.postcode]"), users, EvalParams{})
+	require.NoError(test, err)
+	assert.Equal(test, []interface{}{
+		[]interface{}{"foo", "", ""},
+		[]interface{}{"bar", "Berlin", "10115"},
+	}, result)
+
+	filtered, err := Filter(MustParse("name == 'foo' && home.city == 'Paris' && created == 'today'"), users, EvalParams{})
+	require.NoError(test, err)
+	assert.Equal(test, users[:1], filtered)
+
+	// promoted through a nil pointer
+	_, err = Select(MustParse("created"), users[1:], EvalParams{})
+	assert.EqualError(test, err, "item #0: variable undefined: created [pos=0; len=7]")
+
+	// Go field names work too
+	result, err = Select(MustParse("[Name, Work.Zip]"), users, EvalParams{})
+	require.NoError(test, err)
+	assert.Equal(test, []interface{}{
+		[]interface{}{"foo", ""},
+		[]interface{}{"bar", "10115"},
+	}, result)
+}
+
+func TestFilterErrors(test *testing.T) {
+
+	items := []filterItem{{Name: "foo"}, {Name: "bar"}}
+
+	_, err := Filter(MustParse("Name"), items, EvalParams{})
+	assert.EqualError(test, err, "item #0: expression result is not boolean: foo")
+
+	_, err = Filter(MustParse("hidden == 0"), items, EvalParams{})
+	assert.EqualError(test, err, "item #0: lhs of == / variable undefined: hidden [pos=0; len=6]")
+
+	_, err = Filter(MustParse("true"), []*filterItem{nil}, EvalParams{})
+	assert.EqualError(test, err, "item #0: item is nil")
+
+	_, err = Select(MustParse("true"), []int{1}, EvalParams{})
+	assert.EqualError(test, err, "item #0: item must be a struct or map: int")
+
+	_, err = Select(MustParse("true"), filterItem{}, EvalParams{})
+	assert.EqualError(test, err, "items must be a slice: govaluate.filterItem")
+}
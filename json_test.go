@@ -0,0 +1,99 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExprNodeJSON(test *testing.T) {
+
+	expr := MustParse("a + 1 == 2")
+	data, err := json.Marshal(expr)
+	require.NoError(test, err)
+	expected := `{"version":1,"type":"operator","name":"==","operatorType":"infix","args":[` +
+		`{"type":"operator","name":"+","operatorType":"infix","args":[` +
+		`{"type":"variable","name":"a","sourcePos":0,"sourceLen":1},` +
+		`{"type":"literal","valueType":"number","value":1,"sourcePos":4,"sourceLen":1}],"sourcePos":0,"sourceLen":5},` +
+		`{"type":"literal","valueType":"number","value":2,"sourcePos":9,"sourceLen":1}],"sourcePos":0,"sourceLen":10}`
+	assert.Equal(test, expected, string(data))
+}
+
+func TestExprNodeJSONRoundTrip(test *testing.T) {
+
+	inputs := []string{
+		"a + 1 > 2 && !b",
+		"f(x, 'y') ?? -z",
+		"x ? [1, '1', true] : {a: 1, 'b': nil}",
+		"x.y.z(1)[0] =~ 'abc'",
+		"g()",
+		"items.filter(x => x.price > 10)",
+	}
+	for _, input := range inputs {
+		expr := MustParse(input)
+		data, err := json.Marshal(expr)
+		require.NoError(test, err, input)
+
+		var actual ExprNode
+		require.NoError(test, json.Unmarshal(data, &actual), input)
+		assert.Equal(test, expr, actual, input)
+	}
+
+	literals := []interface{}{
+		nil, true, 1.0, "1", math.Inf(-1), regexp.MustCompile("^a+"),
+		[]interface{}{1.0, "1", []interface{}{false}},
+		map[string]interface{}{"a": 1.0, "b": map[string]interface{}{"c": nil}},
+	}
+	for _, literal := range literals {
+		expr := NewExprNodeLiteral(literal, 1, 2)
+		data, err := json.Marshal(expr)
+		require.NoError(test, err, literal)
+
+		var actual ExprNode
+		require.NoError(test, json.Unmarshal(data, &actual), literal)
+		assert.Equal(test, expr, actual, string(data))
+	}
+
+	// NaN is never equal to itself
+	data, err := json.Marshal(NewExprNodeLiteral(math.NaN(), 0, 0))
+	require.NoError(test, err)
+	var actual ExprNode
+	require.NoError(test, json.Unmarshal(data, &actual))
+	assert.True(test, math.IsNaN(actual.Value.(float64)))
+}
+
+func TestExprNodeJSONErrorNode(test *testing.T) {
+
+	expr, errs := ParseWithRecovery("f(1 +, [2)")
+	require.NotEmpty(test, errs)
+	data, err := json.Marshal(expr)
+	require.NoError(test, err)
+
+	var actual ExprNode
+	require.NoError(test, json.Unmarshal(data, &actual))
+	assert.Equal(test, expr, actual)
+}
+
+func TestExprNodeJSONErrors(test *testing.T) {
+
+	_, err := NewExprNodeLiteral(1, 0, 1).MarshalJSON()
+	assert.EqualError(test, err, "unsupported literal type: int [pos=0; len=1]")
+
+	testCases := map[string]string{
+		`{"type":"variable","name":"a"}`:                                  "unsupported ExprNode JSON version: 0",
+		`{"version":2,"type":"variable","name":"a"}`:                      "unsupported ExprNode JSON version: 2",
+		`{"version":1,"type":"foo"}`:                                      `unsupported node type: "foo"`,
+		`{"version":1,"type":"operator","operatorType":"foo"}`:            `unsupported operator type: "foo"`,
+		`{"version":1,"type":"literal","valueType":"int"}`:                `unsupported literal type: "int" [pos=0; len=0]`,
+		`{"version":1,"type":"literal","valueType":"regexp","value":"("}`: "error parsing regexp: missing closing ): `(` [pos=0; len=0]",
+	}
+	for input, expected := range testCases {
+		var actual ExprNode
+		err := json.Unmarshal([]byte(input), &actual)
+		assert.EqualError(test, err, expected, input)
+	}
+}
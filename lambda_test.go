@@ -0,0 +1,338 @@
+package govaluate
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testProduct struct {
+	Name  string
+	Price float64
+	Tags  []interface{}
+}
+
+func TestParseLambda(t *testing.T) {
+	expr := MustParse("filter(items, x => x > 1 ? a : b)")
+	lambda := expr.Args[1]
+	assert.Equal(t, "=>", lambda.Name)
+	assert.Equal(t, OperatorTypeLambda, lambda.OperatorType)
+	assert.Equal(t, NewExprNodeVariable("x", 14, 1), lambda.Args[0])
+	assert.Equal(t, "?:", lambda.Args[1].Name)
+	assert.Equal(t, 14, lambda.SourcePos)
+	assert.Equal(t, 18, lambda.SourceLen)
+
+	testCases := map[string]string{
+		"items.filter(x => x.price > 10)":                          "items.filter(x => x.price > 10)",
+		"filter(items, x, x.price > 10)":                           "filter(items, x, x.price > 10)",
+		"map(items, x => x => x * 2)":                              "map(items, x => (x => x * 2))",
+		"any(items, x => x > 1 || x < -1)":                         "any(items, x => x > 1 || x < -1)",
+		"sort(items, (x) => -x)":                                   "sort(items, x => -x)",
+		"sort(items, x=>-x)":                                       "sort(items, x => -x)",
+		"first(items, x => x ? 1 : 2) ?? 0":                        "first(items, x => (x ? 1 : 2)) ?? 0",
+		"count(orders, o => any(o.items, i => i.price > o.limit))": "count(orders, o => any(o.items, i => i.price > o.limit))",
+		"reduce(items, 0, acc, x, acc + x.price)":                  "reduce(items, 0, acc, x, acc + x.price)",
+		"items.reduce(0, acc, x, acc + x)":                         "items.reduce(0, acc, x, acc + x)",
+	}
+	for input, expected := range testCases {
+		actual, err := MustParse(input).Print(PrintConfig{})
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+		assert.Equal(t, MustParse(input).Vars(), MustParse(actual).Vars(), "input=%s", input)
+	}
+
+	_, err := Parse("filter(items, 1 => 2)")
+	assert.EqualError(t, err, "invalid lambda parameter, expecting identifier, pos: 14")
+	_, err = Parse("filter(items, a.b => 2)")
+	assert.EqualError(t, err, "invalid lambda parameter, expecting identifier, pos: 14")
+}
+
+func TestEvalCollections(t *testing.T) {
+	testCases := map[string]interface{}{
+		"any(nums, x => x > 2)":                              true,
+		"any(nums, x => x > 3)":                              false,
+		"any([])":                                            false,
+		"any([false, true])":                                 true,
+		"all(nums, x => x > 0)":                              true,
+		"all(nums, x, x > 1)":                                false,
+		"all([], x => false)":                                true,
+		"none(nums, x => x > 3)":                             true,
+		"none([true])":                                       false,
+		"filter(nums, x => x % 2 == 1)":                      []interface{}{1.0, 3.0},
+		"filter(nums, x, x > 5)":                             []interface{}{},
+		"nums.filter(x => x > 1)":                            []interface{}{2.0, 3.0},
+		"nums.filter(x, x > 2)":                              []interface{}{3.0},
+		"map(nums, x => x * 10)":                             []interface{}{10.0, 20.0, 30.0},
+		"map(products, p => p.Name)":                         []interface{}{"pen", "book", "lamp"},
+		"count(nums)":                                        3.0,
+		"count(products, p => p.Price > 10)":                 2.0,
+		"products.count(p => 'sale' in p.Tags)":              2.0,
+		"sum(nums)":                                          6.0,
+		"sum([])":                                            0.0,
+		"sum(products, p => p.Price)":                        42.5,
+		"sum([1h, 30m])":                                     90 * time.Minute,
+		"avg(nums)":                                          2.0,
+		"avg(products, p, p.Price) > 14":                     true,
+		"avg([])":                                            nil,
+		"avg([1h, 30m])":                                     45 * time.Minute,
+		"sort([3, 1, 2])":                                    []interface{}{1.0, 2.0, 3.0},
+		"sort(['b', 'c', 'a'])":                              []interface{}{"a", "b", "c"},
+		"map(sort(products, p => -p.Price), p => p.Name)":    []interface{}{"book", "lamp", "pen"},
+		"map(sort(products, p => p.Name), p => p.Price)":     []interface{}{20.0, 20.0, 2.5},
+		"distinct([1, 2, 1, 3, 2])":                          []interface{}{1.0, 2.0, 3.0},
+		"map(distinct(products, p => p.Price), p => p.Name)": []interface{}{"pen", "book"},
+		"distinct([[1], [1, 2], [1]])":                       []interface{}{[]interface{}{1.0}, []interface{}{1.0, 2.0}},
+		"count(distinct([orders[1], orders[0], orders[1]]))": 2.0,
+		"distinct(orders, o => o.items)[1].limit":            1.0,
+		"first(nums)":                                        1.0,
+		"first(nums, x => x > 1)":                            2.0,
+		"first(nums, x => x > 3)":                            nil,
+		"first([])":                                          nil,
+		"last(nums)":                                         3.0,
+		"last(nums, x => x < 3)":                             2.0,
+		"nums.last()":                                        3.0,
+		"filter(nums, x => x > min)":                         []interface{}{3.0},
+		"map(nums, x => x + x)":                              []interface{}{2.0, 4.0, 6.0},
+		"any(orders, o => any(o.items, i => i > o.limit))":   true,
+		"map(orders, o => count(o.items, i => i > o.limit))": []interface{}{0.0, 1.0},
+		"map(nums, min => min)":                              []interface{}{1.0, 2.0, 3.0},
+		"map(nums, x => map([x], x => x * 2)[0] + x)":        []interface{}{3.0, 6.0, 9.0},
+		"reduce(nums, 0, acc, x, acc + x)":                   6.0,
+		"map(nums, x=>-x)":                                   []interface{}{-1.0, -2.0, -3.0},
+		"nums.filter(x=>!(x > 1))":                           []interface{}{1.0},
+		"reduce(nums, 10, acc, x, acc - x)":                  4.0,
+		"reduce([], 5, acc, x, acc + x)":                     5.0,
+		"nums.reduce(0, acc, x, acc + x * min)":              12.0,
+		"reduce(products, 0, max, p, p.Price > max ? p.Price : max)":  20.0,
+		"reduce(nums, 0, acc, x, acc + reduce(nums, 0, a, y, a + x))": 18.0,
+		"reduce(nums, 0, acc, x, x)":                                  3.0,
+	}
+	params := NewEvalParams(map[string]interface{}{
+		"x":    "outer",
+		"min":  2.0,
+		"nums": []interface{}{1.0, 2.0, 3.0},
+		"products": []interface{}{
+			testProduct{Name: "pen", Price: 2.5, Tags: []interface{}{"sale"}},
+			testProduct{Name: "book", Price: 20, Tags: []interface{}{}},
+			&testProduct{Name: "lamp", Price: 20, Tags: []interface{}{"new", "sale"}},
+		},
+		"orders": []interface{}{
+			map[string]interface{}{"limit": 10.0, "items": []interface{}{5.0, 10.0}},
+			map[string]interface{}{"limit": 1.0, "items": []interface{}{0.5, 2.0}},
+		},
+	})
+	for input, expected := range testCases {
+		expr := MustParse(input)
+		actual, err := expr.Eval(params)
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+
+		program, err := Compile(expr, CompileOptions{})
+		require.NoError(t, err, "input=%s", input)
+		actual, err = program.Run(params.Variables)
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+	}
+}
+
+func TestEvalCollectionsWithNumberModes(t *testing.T) {
+	variables := map[string]interface{}{
+		"nums":   []interface{}{1, 2, int64(9007199254740993)},
+		"prices": []interface{}{0.1, 0.2, decimal("0.3")},
+	}
+	testCases := map[string]interface{}{
+		"sum(nums)":                        int64(9007199254740996),
+		"sum([])":                          int64(0),
+		"count(nums, x => x > 1)":          int64(2),
+		"avg([1, 2])":                      1.5,
+		"sum(nums, x => x % 2)":            int64(2),
+		"count(distinct([[1], [1]]))":      int64(1),
+		"reduce(nums, 0, acc, x, acc + x)": int64(9007199254740996),
+		"first(nums, x => x > 9007199254740992) == 9007199254740993": true,
+	}
+	for input, expected := range testCases {
+		actual, err := parseIntegers(t, input).Eval(EvalParams{Variables: variables, Operators: IntegerOperators()})
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+	}
+
+	params := EvalParams{Variables: variables, Operators: DecimalOperators(2, big.ToNearestEven)}
+	actual, err := parseDecimals(t, "sum(prices)").Eval(params)
+	require.NoError(t, err)
+	assert.Equal(t, "3/5", actual.(*big.Rat).String())
+
+	actual, err = parseDecimals(t, "avg(prices, p => p * 10)").Eval(params)
+	require.NoError(t, err)
+	assert.Equal(t, "2", actual.(*big.Rat).RatString())
+
+	actual, err = parseDecimals(t, "sum(prices) == 0.6").Eval(params)
+	require.NoError(t, err)
+	assert.Equal(t, true, actual)
+}
+
+func TestEvalCollectionsError(t *testing.T) {
+	testCases := map[string]string{
+		"filter(nums, x => x)":     "argument #2 of filter returned non-boolean: 1 [pos=13; len=6]",
+		"any(nums)":                "argument #1 of any has non-boolean item: 1 [pos=4; len=4]",
+		"filter(nums)":             "wrong number of arguments: 1, expected: 2 to 3 [op=filter; pos=0; len=12]",
+		"count()":                  "wrong number of arguments: 0, expected: 1 to 3 [op=count; pos=0; len=7]",
+		"map(5, x => x)":           "argument #1 of map is not array: 5 [pos=4; len=1]",
+		"map(nums, 5)":             "argument #2 of map is not a lambda: 5 [pos=10; len=1]",
+		"map(nums, 5, x)":          "argument #2 of map is not an identifier [pos=10; len=1]",
+		"map(nums, x => x.y)":      "float64 has no field or method y [op=.; pos=15; len=3]",
+		"sum(['a', 1])":            "rhs of + is not numeric: a [pos=0; len=13]",
+		"sort([1, 'a'])":           "lhs of < is not numeric: a [pos=0; len=14]",
+		"map(nums, x => y)":        "variable undefined: y [pos=15; len=1]",
+		"x":                        "variable undefined: x [pos=0; len=1]",
+		"nums.nope()":              "[]interface {} has no method nope [op=.(); pos=0; len=11]",
+		"reduce(nums, 0, x => x)":  "wrong number of arguments: 3, expected: 5 [op=reduce; pos=0; len=23]",
+		"reduce(nums, 0, 1, x, x)": "argument #3 of reduce is not an identifier [pos=16; len=1]",
+		"reduce(nums, 0, a, 1, a)": "argument #4 of reduce is not an identifier [pos=19; len=1]",
+		"reduce(5, 0, a, x, a)":    "argument #1 of reduce is not array: 5 [pos=7; len=1]",
+	}
+	params := NewEvalParams(map[string]interface{}{"nums": []interface{}{1.0, 2.0}})
+	for input, expected := range testCases {
+		_, err := MustParse(input).Eval(params)
+		assert.EqualError(t, err, expected, "input=%s", input)
+	}
+}
+
+func TestEvalLambdaLimits(t *testing.T) {
+	params := NewEvalParams(map[string]interface{}{"nums": []interface{}{1.0, 2.0, 3.0}})
+	params.Limits = EvalLimits{MaxSteps: 10}
+	_, err := MustParse("map(nums, x => x * 2)").Eval(params)
+	var budgetErr BudgetExceededError
+	assert.True(t, errors.As(err, &budgetErr))
+
+	params.Limits = EvalLimits{MaxSteps: 20}
+	_, err = MustParse("map(nums, x => x * 2)").Eval(params)
+	assert.NoError(t, err)
+}
+
+func TestEvalLambdaArg(t *testing.T) {
+	var lambda Lambda
+	params := NewEvalParams(map[string]interface{}{"k": 3.0, "nums": []interface{}{1.0}})
+	params.Operators["apply"] = func(ctx EvalContext) (interface{}, error) {
+		var err error
+		if lambda, err = ctx.LambdaArg(1); err != nil {
+			return nil, err
+		}
+		arg, err := ctx.Arg(0)
+		if err != nil {
+			return nil, err
+		}
+		return lambda.Call(arg)
+	}
+	value, err := MustParse("apply(2, x => x * k)").Eval(params)
+	require.NoError(t, err)
+	assert.Equal(t, 6.0, value)
+	assert.Equal(t, "x", lambda.Param())
+	assert.Equal(t, "*", lambda.Body().Name)
+
+	// lambdas are not values
+	errorCases := map[string]string{
+		"x => x * k":                             "lambda can only be an argument of a collection function [op==>; pos=0; len=10]",
+		"[x => x]":                               "array item #1 / lambda can only be an argument of a collection function [op==>; pos=1; len=6]",
+		"map(nums, x => y => y)":                 "lambda can only be an argument of a collection function [op==>; pos=15; len=6]",
+		"map(nums, k > 1 ? (x => x) : (x => 1))": "argument #2 of map / ternary then / lambda can only be an argument of a collection function [op==>; pos=18; len=8]",
+	}
+	for input, expected := range errorCases {
+		_, err := MustParse(input).Eval(params)
+		assert.EqualError(t, err, expected, "input=%s", input)
+
+		program, err := Compile(MustParse(input), CompileOptions{Operators: params.Operators})
+		require.NoError(t, err, "input=%s", input)
+		_, err = program.Run(params.Variables)
+		assert.EqualError(t, err, expected, "input=%s", input)
+	}
+}
+
+func TestReduceLambda(t *testing.T) {
+	testCases := map[string]string{
+		"filter(items, x => x > limit)":                   "filter(items, x => x > 5)",
+		"filter(items, x, x > limit + 1)":                 "filter(items, x, x > 6)",
+		"items.filter(limit => limit > 1 + 1)":            "items.filter(limit => limit > 2)",
+		"map(items, y => y * x)":                          "map(items, y => y * 100)",
+		"any(items, x => x > 0) && limit > 1":             "any(items, x => x > 0)",
+		"count([1, 2, 3], x => x > limit)":                "count([1, 2, 3], x => x > 5)",
+		"reduce(items, 0, acc, y, acc + y * (limit + 1))": "reduce(items, 0, acc, y, acc + y * 6)",
+		"reduce(items, x, acc, x, acc + x)":               "reduce(items, 100, acc, x, acc + x)",
+	}
+	params := NewEvalParams(map[string]interface{}{"limit": 5.0, "x": 100.0})
+	for input, expected := range testCases {
+		reduced, err := MustParse(input).Reduce(params, BuiltinOptimizers())
+		require.NoError(t, err, "input=%s", input)
+		actual, err := reduced.Print(PrintConfig{})
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+	}
+}
+
+func TestLambdaVars(t *testing.T) {
+	testCases := map[string]map[string]int{
+		"filter(items, x => x > limit)":                    {"items": 1, "limit": 1},
+		"filter(items, x, x > limit) + [x]":                {"items": 1, "limit": 1, "x": 1},
+		"items.map(i => i.price * rate)":                   {"items": 1, "rate": 1},
+		"any(orders, o => any(o.items, i => i > o.limit))": {"orders": 1},
+		"(y => y + z)": {"z": 1},
+		"reduce(items, acc, acc, x, acc + x * rate)": {"items": 1, "acc": 1, "rate": 1},
+	}
+	for input, expected := range testCases {
+		assert.Equal(t, expected, MustParse(input).VarsCount(), "input=%s", input)
+	}
+}
+
+func TestTypeCheckLambda(t *testing.T) {
+	env := NewTypeEnv(map[string]Type{
+		"nums":   TypeArrayOf(TypeNumber),
+		"names":  TypeArrayOf(TypeString),
+		"flags":  TypeArrayOf(TypeBool),
+		"delays": TypeArrayOf(TypeDuration),
+		"data":   TypeAny,
+		"x":      TypeString,
+	})
+	testCases := map[string]Type{
+		"filter(nums, x => x > 1)":                    TypeArrayOf(TypeNumber),
+		"filter(names, x, len(x) > 1)":                TypeArrayOf(TypeString),
+		"map(nums, x => x > 1)":                       TypeArrayOf(TypeBool),
+		"map(names, n => map(nums, x => x + len(n)))": TypeArrayOf(TypeArrayOf(TypeNumber)),
+		"any(flags)":                                  TypeBool,
+		"all(names, x => x == 'a')":                   TypeBool,
+		"count(names)":                                TypeNumber,
+		"sum(nums)":                                   TypeNumber,
+		"sum(delays)":                                 TypeDuration,
+		"avg(names, x => len(x))":                     TypeNumber,
+		"sort(names)":                                 TypeArrayOf(TypeString),
+		"first(names, x => x != '')":                  TypeString,
+		"last(data)":                                  TypeAny,
+		"nums.filter(x => x > 1)":                     TypeAny,
+		"reduce(nums, 0, acc, x, acc + x)":            TypeNumber,
+		"reduce(names, 0, n, x, n + len(x))":          TypeNumber,
+		"reduce(nums, '', acc, x, acc)":               TypeString,
+		"reduce(nums, 0, acc, x, x > 1)":              TypeAny,
+	}
+	for input, expected := range testCases {
+		result, errors := TypeCheck(MustParse(input), env)
+		assert.Empty(t, errors, "input=%s", input)
+		assert.True(t, expected.Equal(result), "input=%s, result=%v", input, result)
+	}
+
+	errorCases := map[string]string{
+		"filter(nums, x => x)":              "argument #2 of filter is number, expected bool [pos=13; len=6]",
+		"map(nums, x => x + 'a')":           "rhs of + is string, expected number [pos=19; len=3]",
+		"any(nums)":                         "argument #1 of any is array<number>, expected array<bool> [pos=4; len=4]",
+		"map(nums)":                         "wrong number of arguments: 1, expected: 2 to 3 [op=map; pos=0; len=9]",
+		"count(5)":                          "argument #1 of count is number, expected array [pos=6; len=1]",
+		"reduce(names, 0, acc, x, acc + x)": "rhs of + is string, expected number [pos=31; len=1]",
+		"reduce(nums, 0, acc => acc)":       "wrong number of arguments: 3, expected: 5 [op=reduce; pos=0; len=27]",
+	}
+	for input, expected := range errorCases {
+		_, errors := TypeCheck(MustParse(input), env)
+		require.Len(t, errors, 1, "input=%s", input)
+		assert.EqualError(t, errors[0], expected, "input=%s", input)
+	}
+}
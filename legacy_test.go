@@ -0,0 +1,185 @@
+package govaluate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLegacyStruct struct {
+	Name string
+}
+
+func (s testLegacyStruct) Greet(greeting string) string {
+	return greeting + ", " + s.Name
+}
+
+func TestToExprNode(t *testing.T) {
+	functions := map[string]ExpressionFunction{
+		"double": func(args ...interface{}) (interface{}, error) {
+			return args[0].(float64) * 2, nil
+		},
+		"sum": func(args ...interface{}) (interface{}, error) {
+			total := 0.0
+			for _, arg := range args {
+				total += arg.(float64)
+			}
+			return total, nil
+		},
+	}
+	params := map[string]interface{}{
+		"x":    3.0,
+		"y":    4.0,
+		"name": "foo",
+		"s":    testLegacyStruct{Name: "bar"},
+		"none": nil,
+		"i":    3,
+		"ids":  []interface{}{1, 3},
+		"u":    paramsUser{Name: "bob", Home: &paramsAddress{City: "Paris"}},
+	}
+
+	type testCase struct {
+		input  string
+		output string
+	}
+	testCases := [...]testCase{
+		{"x + y * 2 - 1", "x + y * 2 - 1"},
+		{"(x + y) * 2", "(x + y) * 2"},
+		{"x - y - 1", "x - y - 1"},
+		{"x / y / 2", "x / y / 2"},
+		{"-x ** 2", "(-x) ** 2"},
+		{"!(x > y) && y >= 4 || false", "!(x > y) && y >= 4 || false"},
+		{"x > 0 ? 'pos' : 'neg'", "x > 0 ? \"pos\" : \"neg\""},
+		{"none ?? x", "none ?? x"},
+		{"x in (1, 2, 3)", "x in [1, 2, 3]"},
+		{"name =~ '^f'", "name =~ \"^f\""},
+		{"name !~ 'o$'", "name !~ \"o$\""},
+		{"double(x) + sum(x, y, 1) + sum()", "double(x) + sum(x, y, 1) + sum()"},
+		{"[name] == 'foo'", "name == \"foo\""},
+		{"s.Name", "s.Name"},
+		{"s.Greet('hi')", "s.Greet(\"hi\")"},
+		{"(x & 6) | 1 << 2", "x & 6 | (1 << 2)"},
+		{"name + name", "name + name"},
+		{"name + x", "name + x"},
+		{"1.5 + name + i", "1.5 + name + i"},
+		{"name < 'zoo' && name >= 'foo' && !(name > 'g') && name <= 'g'", "name < \"zoo\" && name >= \"foo\" && !(name > \"g\") && name <= \"g\""},
+		{"x < y && i > 2", "x < y && i > 2"},
+		{"i == 3 && i != 4 && name == 'foo' && x != name", "i == 3 && i != 4 && name == \"foo\" && x != name"},
+		{"i in (1, 3) && !(name in (1, 2))", "i in [1, 3] && !(name in [1, 2])"},
+		{"u.Name == 'bob' && u.Home.City == 'Paris'", "u.Name == \"bob\" && u.Home.City == \"Paris\""},
+	}
+	for _, testCase := range testCases {
+		legacy, err := NewEvaluableExpressionWithFunctions(testCase.input, functions)
+		require.NoError(t, err, testCase.input)
+
+		expr, err := legacy.ToExprNode()
+		require.NoError(t, err, testCase.input)
+
+		output, err := expr.Print(PrintConfig{})
+		require.NoError(t, err, testCase.input)
+		assert.Equal(t, testCase.output, output, testCase.input)
+
+		expected, err := legacy.Evaluate(params)
+		require.NoError(t, err, testCase.input)
+		evalParams := NewEvalParams(params)
+		evalParams.Operators = LegacyOperators(functions)
+		actual, err := expr.Eval(evalParams)
+		require.NoError(t, err, testCase.input)
+		assert.Equal(t, expected, actual, testCase.input)
+
+		program, err := Compile(expr, CompileOptions{Operators: evalParams.Operators})
+		require.NoError(t, err, testCase.input)
+		actual, err = program.Run(params)
+		require.NoError(t, err, testCase.input)
+		assert.Equal(t, expected, actual, testCase.input)
+	}
+
+	// builtin operators are numeric
+	legacy, err := NewEvaluableExpression("name + 1")
+	require.NoError(t, err)
+	expr, err := legacy.ToExprNode()
+	require.NoError(t, err)
+	_, err = expr.Eval(NewEvalParams(params))
+	assert.EqualError(t, err, "lhs of + is not numeric: foo [pos=0; len=4]")
+
+	evalParams := NewEvalParams(params)
+	evalParams.Operators = LegacyOperators(nil)
+	actual, err := expr.Eval(evalParams)
+	require.NoError(t, err)
+	assert.Equal(t, "foo1", actual)
+
+	legacy, err = NewEvaluableExpression("x + true")
+	require.NoError(t, err)
+	expr, err = legacy.ToExprNode()
+	require.NoError(t, err)
+	_, err = expr.Eval(evalParams)
+	assert.EqualError(t, err, "rhs of + is not numeric: true [pos=4; len=4]")
+
+	// errors point to the legacy source
+	legacy, err = NewEvaluableExpression("x > 1 && (u.Missing == 'é' || [my name] == name)")
+	require.NoError(t, err)
+	expr, err = legacy.ToExprNode()
+	require.NoError(t, err)
+	_, err = expr.Eval(evalParams)
+	assert.EqualError(t, err, "rhs of && / lhs of || / lhs of == / govaluate.paramsUser has no field or method Missing [op=.; pos=10; len=9]")
+	assert.Equal(t, 9, expr.Args[1].SourcePos)
+	assert.Equal(t, 40, expr.Args[1].SourceLen)
+	assert.Equal(t, ExprNode{Type: NodeTypeVariable, Name: "my name", SourcePos: 31, SourceLen: 9}, expr.Args[1].Args[1].Args[0])
+}
+
+func TestToExprNodeDate(t *testing.T) {
+	legacy, err := NewEvaluableExpression("x > '2014-01-02'")
+	require.NoError(t, err)
+	expr, err := legacy.ToExprNode()
+	require.NoError(t, err)
+	value, ok := expr.Args[1].GetValue()
+	require.True(t, ok)
+	assert.IsType(t, 0.0, value)
+
+	expected, err := legacy.Evaluate(map[string]interface{}{"x": value.(float64) + 1})
+	require.NoError(t, err)
+	actual, err := expr.Eval(NewEvalParams(map[string]interface{}{"x": value.(float64) + 1}))
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestTokensToExprNode(t *testing.T) {
+	double := ExpressionFunction(func(args ...interface{}) (interface{}, error) {
+		return args[0].(float64) * 2, nil
+	})
+	tokens := []ExpressionToken{
+		{Kind: FUNCTION, Value: double},
+		{Kind: CLAUSE, Value: '('},
+		{Kind: VARIABLE, Value: "x"},
+		{Kind: CLAUSE_CLOSE, Value: ')'},
+		{Kind: COMPARATOR, Value: "in"},
+		{Kind: CLAUSE, Value: '('},
+		{Kind: NUMERIC, Value: 2.0},
+		{Kind: SEPARATOR, Value: ","},
+		{Kind: NUMERIC, Value: 4.0},
+		{Kind: CLAUSE_CLOSE, Value: ')'},
+	}
+	expr, err := TokensToExprNode(tokens, map[string]ExpressionFunction{"double": double})
+	require.NoError(t, err)
+	output, err := expr.Print(PrintConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "double(x) in [2, 4]", output)
+
+	_, err = TokensToExprNode(tokens, nil)
+	assert.EqualError(t, err, "unknown function")
+
+	_, err = TokensToExprNode(tokens[:3], map[string]ExpressionFunction{"double": double})
+	assert.EqualError(t, err, "unexpected end of tokens")
+}
+
+func TestFunctionOperator(t *testing.T) {
+	fail := FunctionOperator(func(args ...interface{}) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	params := NewEvalParams(nil)
+	params.Operators = map[string]Operator{"fail": fail}
+	_, err := MustParse("fail(1)").Eval(params)
+	assert.EqualError(t, err, "failed [op=fail; pos=0; len=7]")
+}
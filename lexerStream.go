@@ -1,5 +1,9 @@
 package govaluate
 
+import (
+	"unicode"
+)
+
 type lexerStream struct {
 	source   []rune
 	position int
@@ -37,3 +41,22 @@ func (this *lexerStream) rewind(amount int) {
 func (this lexerStream) canRead() bool {
 	return this.position < this.length
 }
+
+// span returns the span of the token read from the given position to the current one, without surrounding whitespace.
+func (this *lexerStream) span(start int) tokenSpan {
+
+	end := this.position
+	for start < end && unicode.IsSpace(this.source[start]) {
+		start++
+	}
+	for start < end && unicode.IsSpace(this.source[end-1]) {
+		end--
+	}
+	return tokenSpan{start: start, end: end}
+}
+
+// tokenSpan is the position of a token in the expression, as offsets of runes, see byteSpans.
+type tokenSpan struct {
+	start int
+	end   int
+}
@@ -0,0 +1,63 @@
+package govaluate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bsonM = map[string]interface{}
+type bsonA = []interface{}
+
+func TestExprNodeToMongoFilter(test *testing.T) {
+
+	testCases := []struct {
+		input    string
+		expected map[string]interface{}
+	}{
+		{"a == 1", bsonM{"a": bsonM{"$eq": 1.0}}},
+		{"a != 'x'", bsonM{"a": bsonM{"$ne": "x"}}},
+		{"1 < a", bsonM{"a": bsonM{"$gt": 1.0}}},
+		{"a.b.c >= 2", bsonM{"a.b.c": bsonM{"$gte": 2.0}}},
+		{"flag", bsonM{"flag": bsonM{"$eq": true}}},
+		{"a > 1 && b < 2 && c", bsonM{"$and": bsonA{bsonM{"a": bsonM{"$gt": 1.0}}, bsonM{"b": bsonM{"$lt": 2.0}}, bsonM{"c": bsonM{"$eq": true}}}}},
+		{"a == 1 || (b == 2 && c == 3)", bsonM{"$or": bsonA{bsonM{"a": bsonM{"$eq": 1.0}}, bsonM{"$and": bsonA{bsonM{"b": bsonM{"$eq": 2.0}}, bsonM{"c": bsonM{"$eq": 3.0}}}}}}},
+		{"a in [1, 'x']", bsonM{"a": bsonM{"$in": bsonA{1.0, "x"}}}},
+		{"a =~ '^f'", bsonM{"a": bsonM{"$regex": "^f"}}},
+		{"a !~ '^f'", bsonM{"a": bsonM{"$not": bsonM{"$regex": "^f"}}}},
+		{"!(a > 1)", bsonM{"a": bsonM{"$not": bsonM{"$gt": 1.0}}}},
+		{"!(a > 1 || b)", bsonM{"$nor": bsonA{bsonM{"$or": bsonA{bsonM{"a": bsonM{"$gt": 1.0}}, bsonM{"b": bsonM{"$eq": true}}}}}}},
+		{"a + b > 10", bsonM{"$expr": bsonM{"$gt": bsonA{bsonM{"$add": bsonA{"$a", "$b"}}, 10.0}}}},
+		{"a == b", bsonM{"$expr": bsonM{"$eq": bsonA{"$a", "$b"}}}},
+		{"-a < len(s)", bsonM{"$expr": bsonM{"$lt": bsonA{bsonM{"$multiply": bsonA{-1.0, "$a"}}, bsonM{"$strLenCP": bsonA{"$s"}}}}}},
+		{"(a ?? 0) == '$x'", bsonM{"$expr": bsonM{"$eq": bsonA{bsonM{"$ifNull": bsonA{"$a", 0.0}}, bsonM{"$literal": "$x"}}}}},
+		{"a in [b, 1]", bsonM{"$expr": bsonM{"$in": bsonA{"$a", bsonA{"$b", 1.0}}}}},
+		{"(a ? b : c) =~ 'x'", bsonM{"$expr": bsonM{"$regexMatch": bsonM{"input": bsonM{"$cond": bsonA{"$a", "$b", "$c"}}, "regex": "x"}}}},
+		{"true", bsonM{"$expr": true}},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := MustParse(testCase.input).ToMongoFilter()
+		if !assert.NoError(test, err, testCase.input) {
+			continue
+		}
+		assert.Equal(test, testCase.expected, actual, testCase.input)
+	}
+}
+
+func TestExprNodeToMongoFilterError(test *testing.T) {
+
+	testCases := map[string]string{
+		"foo(x) == 1":   "operator is not supported in MongoDB: foo [pos=0; len=6]",
+		"a.b() == 1":    "operator is not supported in MongoDB: .() [pos=0; len=5]",
+		"a ^ b > 1":     "operator is not supported in MongoDB: ^ [pos=0; len=5]",
+		"x == {a: 1}":   "operator is not supported in MongoDB: object [pos=5; len=6]",
+		"x[0] == 1 + 1": "operator is not supported in MongoDB: [] [pos=0; len=4]",
+	}
+	for input, expected := range testCases {
+		_, err := MustParse(input).ToMongoFilter()
+		if err == nil || err.Error() != expected {
+			test.Errorf("Test '%s' failed:\nexpected: %s\nactual:   %v", input, expected, err)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseIntegers(t *testing.T, input string) ExprNode {
+	expr, err := ParseWithOptions(input, ParseOptions{Numbers: NumberModeInteger})
+	require.NoError(t, err, "input=%s", input)
+	return expr
+}
+
+func TestParseIntegerLiterals(t *testing.T) {
+	testCases := map[string]interface{}{
+		"42":                  int64(42),
+		"0x1F":                int64(31),
+		"1.5":                 1.5,
+		"9007199254740993":    int64(9007199254740993),
+		"9223372036854775807": int64(math.MaxInt64),
+		"9223372036854775808": 9223372036854775808.0,
+		"0xFFFFFFFFFFFFFFFF":  18446744073709551615.0,
+		"007":                 int64(7),
+	}
+	for input, expected := range testCases {
+		expr := parseIntegers(t, input)
+		assert.Equal(t, NewExprNodeLiteral(expected, 0, len(input)), expr, "input=%s", input)
+	}
+
+	expr := parseIntegers(t, "[1, 2.0]")
+	assert.Equal(t, int64(1), expr.Args[0].Value)
+	assert.Equal(t, 2.0, expr.Args[1].Value)
+
+	expr = MustParse("42")
+	assert.Equal(t, 42.0, expr.Value)
+}
+
+func TestEvalIntegers(t *testing.T) {
+	testCases := map[string]interface{}{
+		"9007199254740993 + 2":             int64(9007199254740995),
+		"2 ** 62":                          int64(1 << 62),
+		"x * 100 + 1":                      int64(1234501),
+		"7 / 2":                            int64(3),
+		"-7 / 2":                           int64(-3),
+		"7 / 2.0":                          3.5,
+		"7 % 3":                            int64(1),
+		"7.5 % 2":                          1.5,
+		"1 + 0.5":                          1.5,
+		"2 ** -1":                          0.5,
+		"1 << 62":                          int64(1 << 62),
+		"-8 >> 1":                          int64(-4),
+		"6 & 3 | 8 ^ 1":                    int64(11),
+		"~0":                               int64(-1),
+		"~2.0":                             int64(-3),
+		"-x":                               int64(-12345),
+		"id == 9007199254740993":           true,
+		"id == 9007199254740992":           false,
+		"id > 9007199254740992":            true,
+		"1 == 1.0":                         true,
+		"1 != 1.5":                         true,
+		"1 < 1.5":                          true,
+		"x >= 12345":                       true,
+		"u == 7":                           true,
+		"2 in [1.0, 2.0]":                  true,
+		"small in [1, 2, 3]":               true,
+		"[1, {a: 2}] == [1.0, {a: small}]": true,
+		"[id] in [[9007199254740992]]":     false,
+		"abs(-3)":                          int64(3),
+		"abs(-3.5)":                        3.5,
+		"min(3, 2)":                        int64(2),
+		"max(3, 2.5)":                      3.0,
+		"floor(3)":                         int64(3),
+		"round(2.5)":                       3.0,
+		"len('abc')":                       int64(3),
+		"[1, 2, 3][1]":                     int64(2),
+		"substr('abcdef', 1, 3)":           "bcd",
+		"x > 0 ? x : 0":                    int64(12345),
+		"sqrt(16)":                         4.0,
+		"big + 0":                          18446744073709551615.0,
+		"big > 9223372036854775807":        true,
+	}
+	params := EvalParams{
+		Variables: map[string]interface{}{
+			"x":     12345,
+			"id":    int64(9007199254740993),
+			"u":     uint8(7),
+			"small": int32(2),
+			"big":   uint64(math.MaxUint64),
+		},
+		Operators: IntegerOperators(),
+	}
+	for input, expected := range testCases {
+		expr := parseIntegers(t, input)
+		actual, err := expr.Eval(params)
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+
+		program, err := Compile(expr, CompileOptions{Operators: params.Operators})
+		require.NoError(t, err, "input=%s", input)
+		actual, err = program.Run(params.Variables)
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+	}
+}
+
+func TestEvalIntegersWithBuiltinOperators(t *testing.T) {
+	// integer literals work with float operators too
+	expr := parseIntegers(t, "x * 2 + 1")
+	actual, err := expr.Eval(NewEvalParams(map[string]interface{}{"x": int64(3)}))
+	require.NoError(t, err)
+	assert.Equal(t, 7.0, actual)
+}
+
+func TestEvalIntegersError(t *testing.T) {
+	testCases := map[string]string{
+		"9223372036854775807 + 1":  "integer overflow: 9223372036854775807 + 1 [op=+; pos=0; len=23]",
+		"-9223372036854775807 - 2": "integer overflow: -9223372036854775807 - 2 [op=-; pos=0; len=24]",
+		"4611686018427387904 * 2":  "integer overflow: 4611686018427387904 * 2 [op=*; pos=0; len=23]",
+		"2 ** 63":                  "integer overflow: 2 ** 63 [op=**; pos=0; len=7]",
+		"1 << 63":                  "integer overflow: 1 << 63 [op=<<; pos=0; len=7]",
+		"1 << -1":                  "shift count is negative: -1 [op=<<; pos=0; len=7]",
+		"1 / 0":                    "integer division by zero [op=/; pos=0; len=5]",
+		"1 % 0":                    "integer division by zero [op=%; pos=0; len=5]",
+		"1.5 & 1":                  "lhs of & is not integer: 1.5 [pos=0; len=3]",
+		"'a' + 1":                  "lhs of + is not numeric: a [pos=0; len=3]",
+		"abs(x)":                   "integer overflow: abs-9223372036854775808 [op=abs; pos=0; len=6]",
+	}
+	params := EvalParams{
+		Variables: map[string]interface{}{"x": int64(math.MinInt64)},
+		Operators: IntegerOperators(),
+	}
+	for input, expected := range testCases {
+		_, err := parseIntegers(t, input).Eval(params)
+		assert.EqualError(t, err, expected, "input=%s", input)
+	}
+}
+
+func TestReduceIntegers(t *testing.T) {
+	testCases := map[string]string{
+		"x + 2 * 3":   "x + 6",
+		"x * 1 + 0":   "x",
+		"x * 0":       "x * 0",
+		"x * 0.0":     "0.0",
+		"2 / 4 + x":   "x",
+		"1.0 * 2 + x": "2.0 + x",
+	}
+	params := EvalParams{
+		Variables: map[string]interface{}{},
+		Operators: IntegerOperators(),
+	}
+	for input, expected := range testCases {
+		reduced, err := parseIntegers(t, input).Reduce(params, BuiltinOptimizers())
+		require.NoError(t, err, "input=%s", input)
+		actual, err := reduced.Print(PrintConfig{Numbers: NumberModeInteger})
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+	}
+}
+
+func TestPrintIntegers(t *testing.T) {
+	expr := parseIntegers(t, "9007199254740993 + 2.0 * 1.5")
+	actual, err := expr.Print(PrintConfig{Numbers: NumberModeInteger})
+	require.NoError(t, err)
+	assert.Equal(t, "9007199254740993 + 2.0 * 1.5", actual)
+	assert.Equal(t, expr, parseIntegers(t, actual))
+
+	actual, err = expr.Print(PrintConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "9007199254740993 + 2 * 1.5", actual)
+
+	actual, err = NewExprNodeLiteral(uint16(7), 0, 0).Print(PrintConfig{
+		FormatIntegerLiteral: func(value int64) string { return "int(" + string(rune('0'+value)) + ")" },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "int(7)", actual)
+}
+
+func TestIntegersJSON(t *testing.T) {
+	expr := parseIntegers(t, "9007199254740993 + 2.0")
+	data, err := json.Marshal(expr)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"valueType":"integer","value":9007199254740993`)
+
+	var actual ExprNode
+	require.NoError(t, json.Unmarshal(data, &actual))
+	assert.Equal(t, expr, actual)
+}
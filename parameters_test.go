@@ -0,0 +1,137 @@
+package govaluate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type paramsAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty" govaluate:"postcode"`
+}
+
+type paramsAudit struct {
+	Created string `json:"created"`
+	Name    string `json:"auditName"`
+}
+
+type paramsUser struct {
+	*paramsAudit
+	Name     string `json:"name"`
+	Age      int
+	Secret   string         `json:"-"`
+	Home     *paramsAddress `json:"home"`
+	Work     paramsAddress  `govaluate:"work"`
+	Tags     map[string]interface{}
+	password string
+}
+
+func TestStructParameters(test *testing.T) {
+
+	user := &paramsUser{
+		paramsAudit: &paramsAudit{Created: "today", Name: "audit"},
+		Name:        "foo",
+		Age:         42,
+		Secret:      "secret",
+		Home:        &paramsAddress{City: "Paris", Zip: "75001"},
+		Work:        paramsAddress{City: "Berlin"},
+		Tags:        map[string]interface{}{"vip": true},
+		password:    "password",
+	}
+	parameters := StructParameters(user)
+
+	found := map[string]interface{}{
+		"name":          "foo",
+		"Age":           42,
+		"created":       "today",
+		"auditName":     "audit",
+		"home.city":     "Paris",
+		"home.postcode": "75001",
+		"work.city":     "Berlin",
+		"Tags.vip":      true,
+	}
+	for name, expected := range found {
+		value, err := parameters.Get(name)
+		if assert.NoError(test, err, name) {
+			assert.Equal(test, expected, value, name)
+		}
+	}
+
+	for _, name := range []string{"Name", "Secret", "password", "Home", "home.zip", "home.city.x", "Tags.x", "paramsAudit"} {
+		_, err := parameters.Get(name)
+		assert.EqualError(test, err, "No parameter '"+name+"' found.")
+	}
+
+	// nil pointers are not followed
+	user.Home = nil
+	user.paramsAudit = nil
+	for _, name := range []string{"home.city", "created"} {
+		_, err := parameters.Get(name)
+		assert.Error(test, err, name)
+	}
+	_, err := StructParameters(nil).Get("name")
+	assert.Error(test, err)
+}
+
+func TestStructParametersEvaluate(test *testing.T) {
+
+	user := paramsUser{Name: "foo", Age: 42, Home: &paramsAddress{City: "Paris"}}
+
+	expression, err := NewEvaluableExpression("name == 'foo' && Age > 40 && [home.city] == 'Paris'")
+	require.NoError(test, err)
+	result, err := expression.Eval(StructParameters(user))
+	require.NoError(test, err)
+	assert.Equal(test, true, result)
+
+	params := EvalParams{Operators: builtinOperators, Resolver: StructResolver(user)}
+	result, err = MustParse("name == 'foo' && Age > 40").Eval(params)
+	require.NoError(test, err)
+	assert.Equal(test, true, result)
+
+	// member access uses the same field names
+	result, err = MustParse("home.city == 'Paris' && work.postcode == ''").Eval(params)
+	require.NoError(test, err)
+	assert.Equal(test, true, result)
+
+	// as well as Go field names
+	result, err = MustParse("home.City == 'Paris' && work.Zip == ''").Eval(params)
+	require.NoError(test, err)
+	assert.Equal(test, true, result)
+
+	_, err = MustParse("home.country").Eval(params)
+	assert.EqualError(test, err, "*govaluate.paramsAddress has no field or method country [op=.; pos=0; len=12]")
+}
+
+func TestMemberTaggedStruct(test *testing.T) {
+
+	user := paramsUser{paramsAudit: &paramsAudit{Name: "admin"}, Name: "bob", Secret: "s", Home: &paramsAddress{City: "Paris"}}
+	params := NewEvalParams(map[string]interface{}{"u": user, "p": &user})
+
+	// Go field names work like in EvaluableExpression, tag names like in StructParameters
+	testCases := map[string]interface{}{
+		"u.Name":          "bob",
+		"u.name":          "bob",
+		"p.Name":          "bob",
+		"u.auditName":     "admin",
+		"u.Secret":        "s",
+		"u.Home.City":     "Paris",
+		"u.home.city":     "Paris",
+		"u.Work.postcode": "",
+	}
+	for input, expected := range testCases {
+		actual, err := MustParse(input).Eval(params)
+		require.NoError(test, err, "input=%s", input)
+		assert.Equal(test, expected, actual, "input=%s", input)
+	}
+
+	expression, err := NewEvaluableExpression("u.Name == 'bob'")
+	require.NoError(test, err)
+	result, err := expression.Evaluate(map[string]interface{}{"u": user})
+	require.NoError(test, err)
+	assert.Equal(test, true, result)
+
+	_, err = MustParse("u.password").Eval(params)
+	assert.EqualError(test, err, "govaluate.paramsUser has no field or method password [op=.; pos=0; len=10]")
+}
@@ -1,9 +1,11 @@
 package govaluate
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParsePrecedence(t *testing.T) {
@@ -140,6 +142,64 @@ func TestParseArrays(t *testing.T) {
 	)
 }
 
+func TestParseObject(t *testing.T) {
+	expr, err := Parse("{\"a b\": x, c: [1], }")
+	assert.Nil(t, err)
+	assert.Equal(t,
+		NewExprNodeOperator("object", []ExprNode{
+			NewExprNodeLiteral("a b", 1, 5),
+			NewExprNodeVariable("x", 8, 1),
+			NewExprNodeLiteral("c", 11, 1),
+			NewExprNodeOperator("array", []ExprNode{
+				NewExprNodeLiteral(1.0, 15, 1),
+			}, 14, 3, OperatorTypeArray),
+		}, 0, 20, OperatorTypeObject),
+		expr,
+	)
+
+	expr, err = Parse("{}")
+	assert.Nil(t, err)
+	assert.Equal(t, NewExprNodeOperator("object", []ExprNode{}, 0, 2, OperatorTypeObject), expr)
+
+	// ':' is not combined with a prefix operator of the value
+	expr, err = Parse("{a:-1}")
+	assert.Nil(t, err)
+	assert.Equal(t,
+		NewExprNodeOperator("object", []ExprNode{
+			NewExprNodeLiteral("a", 1, 1),
+			NewExprNodeOperator("-", []ExprNode{
+				NewExprNodeLiteral(1.0, 4, 1),
+			}, 3, 2, OperatorTypePrefix),
+		}, 0, 6, OperatorTypeObject),
+		expr,
+	)
+}
+
+func TestParseMember(t *testing.T) {
+	expr, err := Parse("a.b.c(1)[0]")
+	assert.Nil(t, err)
+	assert.Equal(t,
+		NewExprNodeOperator("[]", []ExprNode{
+			NewExprNodeOperator(".()", []ExprNode{
+				NewExprNodeOperator(".", []ExprNode{
+					NewExprNodeVariable("a", 0, 1),
+					NewExprNodeLiteral("b", 2, 1),
+				}, 0, 3, OperatorTypeMember),
+				NewExprNodeLiteral("c", 4, 1),
+				NewExprNodeLiteral(1.0, 6, 1),
+			}, 0, 8, OperatorTypeMethodCall),
+			NewExprNodeLiteral(0.0, 9, 1),
+		}, 0, 11, OperatorTypeIndexer),
+		expr,
+	)
+
+	_, err = Parse("a.1")
+	assert.EqualError(t, err, "unexpected token Number{0.1}, expecting operator, pos: 1")
+
+	_, err = Parse("a.(b)")
+	assert.EqualError(t, err, "unexpected token Bracket{'('}, expecting member name, pos: 2")
+}
+
 func TestParseError(t *testing.T) {
 	_, err := Parse("(1 + 2(")
 	assert.EqualError(t, err, "unmatched bracket: '(', expecting ')', pos: 6")
@@ -161,4 +221,97 @@ func TestParseError(t *testing.T) {
 
 	_, err = Parse("2 in [a, b, c")
 	assert.EqualError(t, err, "unexpected eof, expecting ']', ','")
+
+	_, err = Parse("{a: 1 (b)}")
+	assert.EqualError(t, err, "unexpected token Bracket{'('}, expecting '}', ',', pos: 6")
+
+	_, err = Parse("{1: 2}")
+	assert.EqualError(t, err, "unexpected token Number{1}, expecting key, pos: 1")
+
+	_, err = Parse("{a 2}")
+	assert.EqualError(t, err, "unexpected token Number{2}, expecting ':', pos: 3")
+
+	_, err = Parse("{a: 1, 'b': 2, 'a': 3}")
+	assert.EqualError(t, err, "duplicate key: a, pos: 15")
+}
+
+func TestParseWithRecovery(t *testing.T) {
+	type testCase struct {
+		input  string
+		errors []string
+	}
+	testCases := [...]testCase{
+		testCase{"f(1, x + y)", nil},
+		testCase{
+			"f(1 + 2 * (), -) + g(3 4)",
+			[]string{
+				"unexpected token Bracket{')'}, expecting value, pos: 11",
+				"unexpected token Bracket{')'}, expecting value, pos: 15",
+				"unexpected token Number{4}, expecting ')', ',', pos: 23",
+			},
+		},
+		testCase{
+			"(1 + ) * [2, 3",
+			[]string{
+				"unexpected token Bracket{')'}, expecting value, pos: 5",
+				"unexpected eof, expecting ']', ','",
+			},
+		},
+		testCase{
+			"f([1) + 2",
+			[]string{"unexpected token Bracket{')'}, expecting ']', ',', pos: 4"},
+		},
+		testCase{
+			"foo(a.(b), {1: 2, c: 3})",
+			[]string{
+				"unexpected token Bracket{'('}, expecting member name, pos: 6",
+				"unexpected token Number{1}, expecting key, pos: 12",
+			},
+		},
+		testCase{
+			"{a: 1, b: 2, a: x +, b:!y}",
+			[]string{
+				"duplicate key: a, pos: 13",
+				"duplicate key: b, pos: 21",
+			},
+		},
+		testCase{
+			"1 + 'abc",
+			[]string{"unable to parse input at pos=4"},
+		},
+	}
+	for _, testCase := range testCases {
+		_, parseErrors := ParseWithRecovery(testCase.input)
+		var errors []string
+		for _, err := range parseErrors {
+			errors = append(errors, err.Error())
+		}
+		assert.Equal(t, testCase.errors, errors, testCase.input)
+	}
+}
+
+func TestParseWithRecoveryNodes(t *testing.T) {
+	expr, parseErrors := ParseWithRecovery("[x.(1), y]")
+	require.Len(t, parseErrors, 1)
+	assert.Equal(t, "array", expr.Name)
+	require.Len(t, expr.Args, 2)
+	assert.Equal(t, NodeTypeError, expr.Args[0].Type)
+	assert.Equal(t, parseErrors[0], expr.Args[0].Value)
+	assert.Equal(t, 3, expr.Args[0].SourcePos)
+	assert.Equal(t, 3, expr.Args[0].SourceLen)
+	assert.Equal(t, NewExprNodeVariable("y", 8, 1), expr.Args[1])
+
+	_, err := expr.Eval(NewEvalParams(map[string]interface{}{"x": 1, "y": 2}))
+	assert.EqualError(t, err, "array item #1 / unexpected token Bracket{'('}, expecting member name, pos: 3")
+}
+
+func TestParseWithRecoveryOptions(t *testing.T) {
+	expr, parseErrors := ParseWithRecoveryOptions("[9007199254740993, 0.1, )]", ParseOptions{Numbers: NumberModeInteger})
+	require.Len(t, parseErrors, 1)
+	assert.Equal(t, int64(9007199254740993), expr.Args[0].Value)
+	assert.Equal(t, 0.1, expr.Args[1].Value)
+
+	expr, parseErrors = ParseWithRecoveryOptions("f(0.1, +)", ParseOptions{Numbers: NumberModeDecimal})
+	require.Len(t, parseErrors, 1)
+	assert.Equal(t, "1/10", expr.Args[0].Value.(*big.Rat).String())
 }
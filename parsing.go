@@ -11,24 +11,36 @@ import (
 	"unicode"
 )
 
-func parseTokens(expression string, functions map[string]ExpressionFunction) ([]ExpressionToken, error) {
+func parseTokens(expression string, functions map[string]ExpressionFunction, clockFunctions map[string]ClockExpressionFunction) ([]ExpressionToken, error) {
+
+	ret, _, err := parseTokenSpans(expression, functions, clockFunctions)
+	return ret, err
+}
+
+/*
+	Same as parseTokens, but also returns where each token is in the expression.
+*/
+func parseTokenSpans(expression string, functions map[string]ExpressionFunction, clockFunctions map[string]ClockExpressionFunction) ([]ExpressionToken, []tokenSpan, error) {
 
 	var ret []ExpressionToken
+	var spans []tokenSpan
 	var token ExpressionToken
 	var stream *lexerStream
 	var state lexerState
 	var err error
 	var found bool
+	var start int
 
 	stream = newLexerStream(expression)
 	state = validLexerStates[0]
 
 	for stream.canRead() {
 
-		token, err, found = readToken(stream, state, functions)
+		start = stream.position
+		token, err, found = readToken(stream, state, functions, clockFunctions)
 
 		if err != nil {
-			return ret, err
+			return ret, spans, err
 		}
 
 		if !found {
@@ -37,22 +49,23 @@ func parseTokens(expression string, functions map[string]ExpressionFunction) ([]
 
 		state, err = getLexerStateForToken(token.Kind)
 		if err != nil {
-			return ret, err
+			return ret, spans, err
 		}
 
 		// append this valid token
 		ret = append(ret, token)
+		spans = append(spans, stream.span(start))
 	}
 
 	err = checkBalance(ret)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return ret, nil
+	return ret, spans, nil
 }
 
-func readToken(stream *lexerStream, state lexerState, functions map[string]ExpressionFunction) (ExpressionToken, error, bool) {
+func readToken(stream *lexerStream, state lexerState, functions map[string]ExpressionFunction, clockFunctions map[string]ClockExpressionFunction) (ExpressionToken, error, bool) {
 
 	var function ExpressionFunction
 	var ret ExpressionToken
@@ -79,6 +92,17 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			continue
 		}
 
+		// comments are skipped like whitespace
+		if character == '/' {
+			completed, err = skipComment(stream)
+			if err != nil {
+				return ExpressionToken{}, err, false
+			}
+			if completed {
+				continue
+			}
+		}
+
 		kind = UNKNOWN
 
 		// numeric constant
@@ -175,6 +199,13 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 				tokenValue = function
 			}
 
+			clockFunction, clockFound := clockFunctions[tokenString]
+			if clockFound {
+				found = true
+				kind = FUNCTION
+				tokenValue = clockFunction
+			}
+
 			// accessor?
 			accessorIndex := strings.Index(tokenString, ".")
 			if accessorIndex > 0 {
@@ -288,6 +319,41 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 	return ret, nil, (kind != UNKNOWN)
 }
 
+/*
+	Skips the rest of a "//" line comment or a "/* block *\/" comment, if the stream is right after the first '/'.
+	Returns false if there's no comment, or an error if a block comment is not closed.
+*/
+func skipComment(stream *lexerStream) (bool, error) {
+
+	var character rune
+
+	if !stream.canRead() {
+		return false, nil
+	}
+
+	switch stream.source[stream.position] {
+	case '/':
+		for stream.canRead() {
+			character = stream.readCharacter()
+			if character == '\n' {
+				break
+			}
+		}
+		return true, nil
+	case '*':
+		stream.readCharacter()
+		for stream.canRead() {
+			character = stream.readCharacter()
+			if character == '*' && stream.canRead() && stream.source[stream.position] == '/' {
+				stream.readCharacter()
+				return true, nil
+			}
+		}
+		return false, errors.New("Unterminated block comment")
+	}
+	return false, nil
+}
+
 func readTokenUntilFalse(stream *lexerStream, condition func(rune) bool) string {
 
 	var ret string
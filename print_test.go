@@ -72,6 +72,24 @@ func TestPrintOverrideOperators(t *testing.T) {
 	assert.Equal(t, "IF(GT(n, 0), POW(2, n), SUB(NEGATE(n), 1))", output)
 }
 
+func TestPrintBrackets(t *testing.T) {
+	inputs := []string{
+		"[1, x, [\"a\"]]",
+		"{\"a\": x, \"b c\": {}}",
+		"x[1] + (x + y)[0]",
+		"{\"a\": 1}[\"a\"][k ? 1 : 0]",
+		"a.b.c(1, x.y)[0] * (a + b).c",
+		"{\"a\": 1}.a",
+	}
+	for _, input := range inputs {
+		expr, err := Parse(input)
+		assert.Nil(t, err)
+		output, err := expr.Print(PrintConfig{})
+		assert.Nil(t, err)
+		assert.Equal(t, input, output)
+	}
+}
+
 func TestPrintOverrideInfix(t *testing.T) {
 	expr, err := Parse("2 ** n")
 	assert.Nil(t, err)
@@ -87,3 +105,74 @@ func TestPrintOverrideInfix(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "2 pow n", output)
 }
+
+func TestPrintFormat(t *testing.T) {
+	expr := MustParse(`status == "active" && (age >= 18 || guardian != nil) && country in ["US", "CA", "GB"] && ` +
+		`score(history, weights, "v2") > threshold ? "approve" : "review"`)
+
+	output, err := expr.Print(PrintConfig{Width: 40})
+	assert.Nil(t, err)
+	assert.Equal(t, `status == "active" &&
+(age >= 18 || guardian != nil) &&
+country in ["US", "CA", "GB"] &&
+score(
+  history,
+  weights,
+  "v2"
+) > threshold
+  ? "approve"
+  : "review"`, output)
+
+	output, err = expr.Print(PrintConfig{Width: 30, Indent: "\t"})
+	assert.Nil(t, err)
+	assert.Equal(t, `status == "active" &&
+(
+	age >= 18 || guardian != nil
+) &&
+country in [
+	"US",
+	"CA",
+	"GB"
+] &&
+score(
+	history,
+	weights,
+	"v2"
+) > threshold
+	? "approve"
+	: "review"`, output)
+
+	// fits into a single line
+	output, err = MustParse("a && b || c").Print(PrintConfig{Width: 80})
+	assert.Nil(t, err)
+	assert.Equal(t, "a && b || c", output)
+}
+
+func TestPrintFormatIdempotent(t *testing.T) {
+	inputs := []string{
+		"aaaa && bbbb && (cccc || dddd || eeee && ffff) && !(gggg || hhhh)",
+		"xxxxxxxx && yyyyyyyy || zzzzzzzz && wwwwwwww",
+		"f(aaaa, g(bbbb, cccc), [dddd, eeee], {\"k\": ffff}).method(gggg, hhhh)[iiii] ?? jjjj",
+		"cond1 ? (cond2 ? aaaaaaaa : bbbbbbbb) : cond3 ? cccccccc : dddddddd",
+		"(aaaaaaaa + bbbbbbbb) * (cccccccc - dddddddd) / -(eeeeeeee % ffffffff) > 1",
+	}
+	for _, input := range inputs {
+		expr := MustParse(input)
+		flat, err := expr.Print(PrintConfig{})
+		assert.Nil(t, err)
+		for _, width := range []int{1, 10, 20, 40} {
+			config := PrintConfig{Width: width}
+			formatted, err := expr.Print(config)
+			assert.Nil(t, err)
+
+			// formatted output parses back to the same expression, and formatting it again changes nothing
+			reparsed := MustParse(formatted)
+			reparsedFlat, err := reparsed.Print(PrintConfig{})
+			assert.Nil(t, err)
+			assert.Equal(t, flat, reparsedFlat, "width %d:\n%s", width, formatted)
+			reformatted, err := reparsed.Print(config)
+			assert.Nil(t, err)
+			assert.Equal(t, formatted, reformatted, "width %d", width)
+		}
+	}
+}
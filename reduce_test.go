@@ -1,6 +1,7 @@
 package govaluate
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -110,6 +111,49 @@ func TestReduce(test *testing.T) {
 	}, "1 + (2 - d + h) * 3")
 }
 
+func TestReduceObject(test *testing.T) {
+	runTest(test, "{a: x, b: [y, 1]}", map[string]interface{}{
+		"x": 1.0,
+	}, "{\"a\": 1, \"b\": [y, 1]}")
+
+	runTest(test, "{a: x, b: [y, 1]}", map[string]interface{}{
+		"x": 1.0,
+		"y": "s",
+	}, "{\"a\": 1, \"b\": [\"s\", 1]}")
+
+	runTest(test, "{b: x, a: 2}[k] > 1", map[string]interface{}{
+		"k": "a",
+	}, "{\"b\": x, \"a\": 2}[\"a\"] > 1")
+
+	runTest(test, "{b: x, a: 2}[k] > 1", map[string]interface{}{
+		"k": "a",
+		"x": 0.0,
+	}, "true")
+
+	runTest(test, "{a: 1}[k]", map[string]interface{}{}, "{\"a\": 1}[k]")
+}
+
+func TestReduceRegexp(t *testing.T) {
+	expr, err := Parse("name =~ '^a+' || name !~ other")
+	assert.Nil(t, err)
+
+	reduced, err := expr.Reduce(NewEvalParams(map[string]interface{}{}), BuiltinOptimizers())
+	assert.Nil(t, err)
+	assert.IsType(t, &regexp.Regexp{}, reduced.Args[0].Args[1].Value)
+	assert.Equal(t, NodeTypeVariable, reduced.Args[1].Args[1].Type)
+
+	output, err := reduced.Print(PrintConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, "name =~ \"^a+\" || name !~ other", output)
+
+	val, err := reduced.Eval(NewEvalParams(map[string]interface{}{"name": "aab", "other": "b"}))
+	assert.Nil(t, err)
+	assert.Equal(t, true, val)
+
+	_, err = MustParse("'abc' =~ '(a'").Reduce(NewEvalParams(map[string]interface{}{}), BuiltinOptimizers())
+	assert.EqualError(t, err, "rhs of =~ is not a valid regular expression: error parsing regexp: missing closing ): `(a` [pos=9; len=4]")
+}
+
 func runTest(test *testing.T, input string, parameters map[string]interface{}, expectedOutput string) {
 	expr, err := Parse(input)
 	if err != nil {
@@ -146,3 +190,17 @@ func TestReduceVars(t *testing.T) {
 
 	assert.Equal(t, map[string]int{"y": 1, "z": 1}, reduced.VarsCount())
 }
+
+func TestReduceResolver(t *testing.T) {
+	expr, err := Parse("x + y * z")
+	assert.Nil(t, err)
+
+	params := NewEvalParams(map[string]interface{}{"x": 1.0})
+	params.Resolver = MapResolver{"z": 7.0}
+	reduced, err := expr.Reduce(params, BuiltinOptimizers())
+	assert.Nil(t, err)
+
+	output, err := reduced.Print(PrintConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, "1 + y * 7", output)
+}
@@ -1,7 +1,13 @@
 package govaluate
 
 import (
+	"math"
+	"math/big"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 /*
@@ -245,3 +251,169 @@ func runQueryTests(testCases []QueryTest, test *testing.T) {
 		}
 	}
 }
+
+func TestExprNodeToSQL(test *testing.T) {
+
+	type testCase struct {
+		input    string
+		dialect  SQLDialect
+		expected string
+	}
+	testCases := []testCase{
+		{"x > 1 && y != 'a' || !z", SQLDialectPostgreSQL, `"x" > 1 AND "y" <> 'a' OR NOT "z"`},
+		{"!(x && y) == false", SQLDialectMySQL, "(NOT (`x` AND `y`)) = FALSE"},
+		{"!(x == 1)", SQLDialectSQLite, `NOT "x" = 1`},
+		{"(a + b) * c - -(d - e)", SQLDialectPostgreSQL, `("a" + "b") * "c" - -("d" - "e")`},
+		{"a - (b - c)", SQLDialectPostgreSQL, `"a" - ("b" - "c")`},
+		{"x ? 'yes' : y ?? 'no'", SQLDialectPostgreSQL, `CASE WHEN "x" THEN 'yes' ELSE COALESCE("y", 'no') END`},
+		{"x in [1, 2, y]", SQLDialectMySQL, "`x` IN (1, 2, `y`)"},
+		{"x in []", SQLDialectSQLite, "0"},
+		{"name =~ '^a' && name !~ 'b$'", SQLDialectPostgreSQL, `"name" ~ '^a' AND "name" !~ 'b$'`},
+		{"name =~ '^a'", SQLDialectMySQL, "`name` REGEXP '^a'"},
+		{"a & 1 | b ^ 2", SQLDialectPostgreSQL, `(("a" & 1) | "b") # 2`},
+		{"x ** 2 + len(lower(s))", SQLDialectMySQL, "POWER(`x`, 2) + CHAR_LENGTH(LOWER(`s`))"},
+		{"min(a, b) < max(a, b)", SQLDialectSQLite, `MIN("a", "b") < MAX("a", "b")`},
+		{`s == "it's \\"`, SQLDialectMySQL, "`s` = 'it''s \\\\'"},
+		{`s == "it's \\"`, SQLDialectPostgreSQL, `"s" = 'it''s \'`},
+		{"true && false", SQLDialectSQLite, "1 AND 0"},
+	}
+
+	for _, testCase := range testCases {
+		expr, err := Parse(testCase.input)
+		if err != nil {
+			test.Errorf("Failed to parse '%s': %v", testCase.input, err)
+			continue
+		}
+		actual, err := expr.ToSQL(testCase.dialect)
+		if err != nil {
+			test.Errorf("Failed to convert '%s': %v", testCase.input, err)
+			continue
+		}
+		if actual != testCase.expected {
+			test.Errorf("Test '%s' failed:\nexpected: %s\nactual:   %s", testCase.input, testCase.expected, actual)
+		}
+	}
+}
+
+func TestExprNodeToSQLNegation(test *testing.T) {
+
+	negate := func(arg ExprNode) ExprNode {
+		return NewExprNodeOperator("-", []ExprNode{arg}, 0, 0, OperatorTypePrefix)
+	}
+	testCases := map[string]ExprNode{
+		"-(-1)":         negate(NewExprNodeLiteral(-1.0, 0, 0)),
+		"-(-0)":         negate(NewExprNodeLiteral(math.Copysign(0, -1), 0, 0)),
+		"-(-2)":         negate(NewExprNodeLiteral(int64(-2), 0, 0)),
+		"-(-0.5)":       negate(NewExprNodeLiteral(big.NewRat(-1, 2), 0, 0)),
+		"-(-\"x\")":     negate(negate(NewExprNodeVariable("x", 0, 0))),
+		"-1":            negate(NewExprNodeLiteral(1.0, 0, 0)),
+		"-(-1 * \"x\")": negate(MustParse("-1 * x")),
+		"~-1":           NewExprNodeOperator("~", []ExprNode{NewExprNodeLiteral(-1.0, 0, 0)}, 0, 0, OperatorTypePrefix),
+	}
+	for expected, expr := range testCases {
+		actual, err := expr.ToSQL(SQLDialectPostgreSQL)
+		if err != nil || actual != expected {
+			test.Errorf("Test '%s' failed:\nactual:   %s, %v", expected, actual, err)
+		}
+	}
+
+	actual, args, err := negate(NewExprNodeLiteral(-1.0, 0, 0)).ToSQLWithArgs(SQLDialectPostgreSQL)
+	if err != nil || actual != "-($1)" || len(args) != 1 || args[0] != -1.0 {
+		test.Errorf("Test with args failed: %s, %v, %v", actual, args, err)
+	}
+}
+
+func TestExprNodeToSQLError(test *testing.T) {
+
+	testCases := map[string]string{
+		"a.b == 1":      "operator is not supported in SQL: . [pos=0; len=3]",
+		"foo(x)":        "operator is not supported in SQL: foo [pos=0; len=6]",
+		"a ^ 1":         "operator is not supported in SQL: ^ [pos=0; len=5]",
+		"x in y":        "rhs of in must be an array in SQL [pos=5; len=1]",
+		"x == {a: 1}":   "operator is not supported in SQL: object [pos=5; len=6]",
+		"x[0] == 1 + 1": "operator is not supported in SQL: [] [pos=0; len=4]",
+	}
+	for input, expected := range testCases {
+		_, err := MustParse(input).ToSQL(SQLDialectSQLite)
+		if err == nil || err.Error() != expected {
+			test.Errorf("Test '%s' failed:\nexpected: %s\nactual:   %v", input, expected, err)
+		}
+	}
+}
+
+func TestExprNodeToSQLWithArgs(test *testing.T) {
+
+	testCases := []struct {
+		input        string
+		dialect      SQLDialect
+		expected     string
+		expectedArgs []interface{}
+	}{
+		{"a > 1 && b == 'x'", SQLDialectPostgreSQL, `"a" > $1 AND "b" = $2`, []interface{}{1.0, "x"}},
+		{"a > 1 && b == 'x'", SQLDialectMySQL, "`a` > ? AND `b` = ?", []interface{}{1.0, "x"}},
+		{"a in [1, 2, 3]", SQLDialectSQLite, `"a" IN (?, ?, ?)`, []interface{}{1.0, 2.0, 3.0}},
+		{"a == b || !c", SQLDialectPostgreSQL, `"a" = "b" OR NOT "c"`, []interface{}{}},
+		{"a ? true : 'no'", SQLDialectPostgreSQL, `CASE WHEN "a" THEN $1 ELSE $2 END`, []interface{}{true, "no"}},
+	}
+
+	for _, testCase := range testCases {
+		actual, args, err := MustParse(testCase.input).ToSQLWithArgs(testCase.dialect)
+		if err != nil {
+			test.Errorf("Failed to convert '%s': %v", testCase.input, err)
+			continue
+		}
+		if actual != testCase.expected {
+			test.Errorf("Test '%s' failed:\nexpected: %s\nactual:   %s", testCase.input, testCase.expected, actual)
+		}
+		assert.Equal(test, testCase.expectedArgs, args, testCase.input)
+	}
+}
+
+func TestExprNodeToSQLTime(test *testing.T) {
+
+	expr, err := MustParse("d > date('2020-01-01T10:30:00.5+02:00') && d < date('2020-01-02')").Reduce(NewEvalParams(nil), BuiltinOptimizers())
+	require.NoError(test, err)
+
+	testCases := []struct {
+		dialect  SQLDialect
+		expected string
+	}{
+		{SQLDialectPostgreSQL, `"d" > TIMESTAMP WITH TIME ZONE '2020-01-01 10:30:00.5+02:00' AND "d" < TIMESTAMP WITH TIME ZONE '2020-01-02 00:00:00Z'`},
+		{SQLDialectMySQL, "`d` > TIMESTAMP '2020-01-01 08:30:00.5' AND `d` < TIMESTAMP '2020-01-02 00:00:00'"},
+		{SQLDialectSQLite, `"d" > '2020-01-01 08:30:00.5' AND "d" < '2020-01-02 00:00:00'`},
+		{SQLDialect{QuoteIdentifier: SQLDialectSQLite.QuoteIdentifier, QuoteString: SQLDialectSQLite.QuoteString, InfixOperators: SQLDialectSQLite.InfixOperators},
+			`"d" > '2020-01-01T10:30:00.5+02:00' AND "d" < '2020-01-02T00:00:00Z'`},
+	}
+	for _, testCase := range testCases {
+		actual, err := expr.ToSQL(testCase.dialect)
+		require.NoError(test, err)
+		assert.Equal(test, testCase.expected, actual)
+	}
+
+	actual, args, err := expr.ToSQLWithArgs(SQLDialectPostgreSQL)
+	require.NoError(test, err)
+	assert.Equal(test, `"d" > $1 AND "d" < $2`, actual)
+	assert.Equal(test, []interface{}{expr.Args[0].Args[1].Value, expr.Args[1].Args[1].Value}, args)
+	assert.IsType(test, time.Time{}, args[0])
+
+	_, err = MustParse("d - start > 1h").ToSQL(SQLDialectPostgreSQL)
+	assert.EqualError(test, err, "durations are not supported in SQL: 1h0m0s")
+	_, _, err = MustParse("d - start > 1h").ToSQLWithArgs(SQLDialectPostgreSQL)
+	assert.EqualError(test, err, "durations are not supported in SQL: 1h0m0s")
+}
+
+func TestSQLQueryWithArgs(test *testing.T) {
+
+	expression, err := NewEvaluableExpression("(foo > 1 || bar == 'baz') && qux =~ '^q' && flag != true")
+	require.NoError(test, err)
+
+	query, args, err := expression.ToSQLQueryWithArgs()
+	require.NoError(test, err)
+	assert.Equal(test, "( [foo] > ? OR [bar] = ? ) AND [qux] RLIKE ? AND [flag] <> ?", query)
+	assert.Equal(test, []interface{}{1.0, "baz", "^q", true}, args)
+
+	// inlined query is not affected
+	query, err = expression.ToSQLQuery()
+	require.NoError(test, err)
+	assert.Equal(test, "( [foo] > 1 OR [bar] = 'baz' ) AND [qux] RLIKE '^q' AND [flag] <> 1", query)
+}
@@ -305,6 +305,11 @@ func planFunction(stream *tokenStream) (*evaluationStage, error) {
 		return planAccessor(stream)
 	}
 
+	operator, err := makeFunctionStage(token.Value)
+	if err != nil {
+		return nil, err
+	}
+
 	rightStage, err = planAccessor(stream)
 	if err != nil {
 		return nil, err
@@ -314,7 +319,7 @@ func planFunction(stream *tokenStream) (*evaluationStage, error) {
 
 		symbol:          FUNCTIONAL,
 		rightStage:      rightStage,
-		operator:        makeFunctionStage(token.Value.(ExpressionFunction)),
+		operator:        operator,
 		typeErrorFormat: "Unable to run function '%v': %v",
 	}, nil
 }
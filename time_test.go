@@ -0,0 +1,237 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDurationLiterals(t *testing.T) {
+	testCases := map[string]time.Duration{
+		"5m":     5 * time.Minute,
+		"2h30m":  2*time.Hour + 30*time.Minute,
+		"1.5s":   1500 * time.Millisecond,
+		"500ms":  500 * time.Millisecond,
+		"10us":   10 * time.Microsecond,
+		"3ns":    3,
+		"1h0m1s": time.Hour + time.Second,
+	}
+	for input, expected := range testCases {
+		assert.Equal(t, NewExprNodeLiteral(expected, 0, len(input)), MustParse(input), "input=%s", input)
+	}
+
+	expr := MustParse("t + 5m")
+	assert.Equal(t, NewExprNodeLiteral(5*time.Minute, 4, 2), expr.Args[1])
+
+	_, err := Parse("5min")
+	assert.Error(t, err)
+	_, err = Parse("5h_")
+	assert.Error(t, err)
+}
+
+func TestEvalTime(t *testing.T) {
+	start := time.Date(2024, time.March, 9, 12, 30, 0, 0, time.UTC)
+	testCases := map[string]interface{}{
+		"start + 2h30m":                       start.Add(2*time.Hour + 30*time.Minute),
+		"90m + start":                         start.Add(90 * time.Minute),
+		"start - 1h":                          start.Add(-time.Hour),
+		"end - start":                         36 * time.Hour,
+		"1h - 90m":                            -30 * time.Minute,
+		"-5m":                                 -5 * time.Minute,
+		"start < end":                         true,
+		"end - start >= 1h":                   true,
+		"end - start > 2h ? 'long' : 'short'": "long",
+		"start == date('2024-03-09T13:30:00+01:00')": true,
+		"start != end":                           true,
+		"start in [end, start]":                  true,
+		"date('2024-03-09')":                     time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC),
+		"date('2024-03-09 08:15')":               time.Date(2024, time.March, 9, 8, 15, 0, 0, time.UTC),
+		"date('09/03/2024', '02/01/2006')":       time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC),
+		"year(start)":                            2024.0,
+		"month(start)":                           3.0,
+		"day(start)":                             9.0,
+		"hour(start)":                            12.0,
+		"hour(start, 'Asia/Tokyo')":              21.0,
+		"minute(start)":                          30.0,
+		"second(start)":                          0.0,
+		"dayOfWeek(start)":                       6.0,
+		"dayOfWeek(start, 'Asia/Tokyo')":         6.0,
+		"dayOfWeek(end, 'America/Los_Angeles')":  0.0,
+		"truncate(start, 'day')":                 time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC),
+		"truncate(start, 'week')":                time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC),
+		"truncate(start, 'month')":               time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		"truncate(start, 'year')":                time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		"truncate(start + 20m, 15m)":             start.Add(15 * time.Minute),
+		"truncate(start, 'hour') == start - 30m": true,
+		"addDays(start, 2) - start":              48 * time.Hour,
+		"addDays(start, -9)":                     time.Date(2024, time.February, 29, 12, 30, 0, 0, time.UTC),
+	}
+	params := NewEvalParams(map[string]interface{}{
+		"start": start,
+		"end":   start.Add(36 * time.Hour),
+	})
+	for input, expected := range testCases {
+		expr := MustParse(input)
+		actual, err := expr.Eval(params)
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+
+		program, err := Compile(expr, CompileOptions{})
+		require.NoError(t, err, "input=%s", input)
+		actual, err = program.Run(params.Variables)
+		require.NoError(t, err, "input=%s", input)
+		assert.Equal(t, expected, actual, "input=%s", input)
+	}
+}
+
+func TestEvalTimeZones(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// daylight saving time starts on 2024-03-10 in New York, the day has 23 hours
+	start := time.Date(2024, time.March, 9, 12, 0, 0, 0, newYork)
+	params := NewEvalParams(map[string]interface{}{"start": start})
+
+	actual, err := MustParse("addDays(start, 1)").Eval(params)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.March, 10, 12, 0, 0, 0, newYork), actual)
+
+	actual, err = MustParse("addDays(start, 1) - start").Eval(params)
+	require.NoError(t, err)
+	assert.Equal(t, 23*time.Hour, actual)
+
+	actual, err = MustParse("start + 24h == addDays(start, 1)").Eval(params)
+	require.NoError(t, err)
+	assert.Equal(t, false, actual)
+
+	actual, err = MustParse("date('2024-03-10 12:00', '2006-01-02 15:04', 'America/New_York') == addDays(start, 1)").Eval(params)
+	require.NoError(t, err)
+	assert.Equal(t, true, actual)
+
+	actual, err = MustParse("truncate(start, 'day', 'Europe/Paris')").Eval(params)
+	require.NoError(t, err)
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.March, 9, 0, 0, 0, 0, paris), actual)
+}
+
+func TestEvalTimeWithNumberModes(t *testing.T) {
+	start := time.Date(2024, time.March, 9, 12, 30, 0, 0, time.UTC)
+	variables := map[string]interface{}{"start": start}
+
+	expr := parseIntegers(t, "year(start) * 100 + month(start)")
+	actual, err := expr.Eval(EvalParams{Variables: variables, Operators: IntegerOperators()})
+	require.NoError(t, err)
+	assert.Equal(t, int64(202403), actual)
+
+	expr = parseIntegers(t, "start + 1h - start > 30m")
+	actual, err = expr.Eval(EvalParams{Variables: variables, Operators: IntegerOperators()})
+	require.NoError(t, err)
+	assert.Equal(t, true, actual)
+
+	expr = parseDecimals(t, "addDays(start, 1) - 1h == start + 23h")
+	actual, err = expr.Eval(EvalParams{Variables: variables, Operators: DecimalOperators(2, big.ToNearestEven)})
+	require.NoError(t, err)
+	assert.Equal(t, true, actual)
+
+	expr = parseDecimals(t, "-(start - date('2024-03-09'))")
+	actual, err = expr.Eval(EvalParams{Variables: variables, Operators: DecimalOperators(2, big.ToNearestEven)})
+	require.NoError(t, err)
+	assert.Equal(t, -12*time.Hour-30*time.Minute, actual)
+}
+
+func TestEvalTimeError(t *testing.T) {
+	testCases := map[string]string{
+		"date('yesterday')":                   `argument #1 of date is not a valid date: "yesterday" [pos=5; len=11]`,
+		"date('2024', '2006-01')":             `argument #1 of date is not a valid date: parsing time "2024" as "2006-01": cannot parse "" as "-" [pos=5; len=6]`,
+		"date('2024-03-09', '2006-01-02', 1)": "argument #3 of date is not string: 1 [pos=33; len=1]",
+		"year(start, 'Mars/Olympus')":         "argument #2 of year is not a valid time zone: unknown time zone Mars/Olympus [pos=12; len=14]",
+		"year('2024')":                        "argument #1 of year is not time: 2024 [pos=5; len=6]",
+		"truncate(start, 'decade')":           "argument #2 of truncate is not a valid time unit: decade [pos=16; len=8]",
+		"addDays(start, 1.5)":                 "argument #2 of addDays is not integer: 1.5 [pos=15; len=3]",
+		"now(1)":                              "wrong number of arguments: 1, expected: 0 [op=now; pos=0; len=6]",
+		"start + 1":                           "lhs of + is not numeric: 2024-03-09 12:30:00 +0000 UTC [pos=0; len=5]",
+	}
+	params := NewEvalParams(map[string]interface{}{
+		"start": time.Date(2024, time.March, 9, 12, 30, 0, 0, time.UTC),
+	})
+	for input, expected := range testCases {
+		_, err := MustParse(input).Eval(params)
+		assert.EqualError(t, err, expected, "input=%s", input)
+	}
+}
+
+func TestEvalNow(t *testing.T) {
+	before := time.Now()
+	actual, err := MustParse("now()").Eval(NewEvalParams(nil))
+	require.NoError(t, err)
+	assert.False(t, actual.(time.Time).Before(before))
+	assert.False(t, actual.(time.Time).After(time.Now()))
+}
+
+func TestTypeCheckTime(t *testing.T) {
+	env := NewTypeEnv(map[string]Type{
+		"start": TypeTime,
+		"delay": TypeDuration,
+		"data":  TypeAny,
+	})
+	testCases := map[string]Type{
+		"start + delay":               TypeTime,
+		"delay + start":               TypeTime,
+		"start - start":               TypeDuration,
+		"start - 5m":                  TypeTime,
+		"delay > 1m || start < now()": TypeBool,
+		"-delay":                      TypeDuration,
+		"date('2024-03-09')":          TypeTime,
+		"year(start) + 1":             TypeNumber,
+		"truncate(start, 'day')":      TypeTime,
+		"addDays(start, 1, 'UTC')":    TypeTime,
+		"data + delay":                TypeAny,
+		"start - data":                TypeAny,
+	}
+	for input, expected := range testCases {
+		result, errors := TypeCheck(MustParse(input), env)
+		assert.Empty(t, errors, "input=%s", input)
+		assert.True(t, expected.Equal(result), "input=%s, result=%v", input, result)
+	}
+
+	_, errors := TypeCheck(MustParse("start + 1"), env)
+	assert.NotEmpty(t, errors)
+	_, errors = TypeCheck(MustParse("start < 1h"), env)
+	assert.NotEmpty(t, errors)
+	_, errors = TypeCheck(MustParse("year('2024')"), env)
+	assert.NotEmpty(t, errors)
+}
+
+func TestPrintTime(t *testing.T) {
+	expr, err := MustParse("x < date('2024-03-09T12:30:00+01:00') + (1h + 30m) && d > 1.5s").Reduce(NewEvalParams(nil), BuiltinOptimizers())
+	require.NoError(t, err)
+	actual, err := expr.Print(PrintConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, `x < date("2024-03-09T14:00:00+01:00") && d > 1.5s`, actual)
+
+	value, err := MustParse(actual).Eval(NewEvalParams(map[string]interface{}{
+		"x": time.Date(2024, time.March, 9, 12, 59, 0, 0, time.UTC),
+		"d": 2 * time.Second,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, true, value)
+}
+
+func TestTimeJSON(t *testing.T) {
+	expr := NewExprNodeOperator("+", []ExprNode{
+		NewExprNodeLiteral(time.Date(2024, time.March, 9, 12, 30, 0, 5, time.UTC), 0, 0),
+		NewExprNodeLiteral(90*time.Minute, 0, 0),
+	}, 0, 0, OperatorTypeInfix)
+	data, err := json.Marshal(expr)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"valueType":"time","value":"2024-03-09T12:30:00.000000005Z"`)
+	assert.Contains(t, string(data), `"valueType":"duration","value":"1h30m0s"`)
+
+	var actual ExprNode
+	require.NoError(t, json.Unmarshal(data, &actual))
+	assert.Equal(t, expr, actual)
+}
@@ -47,11 +47,15 @@ func TestTokenizeString(t *testing.T) {
 }
 
 func TestTokenizeOperator(t *testing.T) {
-	valid := []string{"+", "-", "<=", "**", "|>", "&&", "||"}
-	values := []interface{}{"+", "-", "<=", "**", "|>", "&&", "||"}
+	valid := []string{"+", "-", "<=", "**", "|>", "&&", "||", "=>"}
+	values := []interface{}{"+", "-", "<=", "**", "|>", "&&", "||", "=>"}
 	suffix := []string{"", "abc", "7", "\"str\"", " x", ")", "("}
 	testTokenizerSuccess(t, tokenizeOperator, TokenKindOperator, valid, suffix, values)
 	testTokenizerFail(t, tokenizeOperator, suffix)
+
+	// lambda arrow is not combined with prefix operators of the body
+	assert.Equal(t, NewExprToken(TokenKindOperator, "=>", 2), tokenizeOperator("=>-x"))
+	assert.Equal(t, NewExprToken(TokenKindOperator, "=>", 2), tokenizeOperator("=>!x"))
 }
 
 func TestTokenizeBracket(t *testing.T) {
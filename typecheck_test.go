@@ -0,0 +1,113 @@
+package govaluate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeCheck(t *testing.T) {
+	type testCase struct {
+		input  string
+		result Type
+	}
+	env := NewTypeEnv(map[string]Type{
+		"x":     TypeNumber,
+		"name":  TypeString,
+		"flag":  TypeBool,
+		"items": TypeArrayOf(TypeNumber),
+		"obj":   TypeObject,
+		"data":  TypeAny,
+	})
+	testCases := [...]testCase{
+		testCase{"x + 1 * 2", TypeNumber},
+		testCase{"x > 0 && flag", TypeBool},
+		testCase{"flag ? x : 1", TypeNumber},
+		testCase{"flag ? x : name", TypeAny},
+		testCase{"[1, x]", TypeArrayOf(TypeNumber)},
+		testCase{"[1, 'a']", TypeArrayOf(TypeAny)},
+		testCase{"[[1], []]", TypeArrayOf(TypeArrayOf(TypeAny))},
+		testCase{"items[0]", TypeNumber},
+		testCase{"obj['a']", TypeAny},
+		testCase{"x in items", TypeBool},
+		testCase{"{a: x}", TypeObject},
+		testCase{"data.foo.bar(1)", TypeAny},
+		testCase{"data + 1", TypeNumber},
+		testCase{"split(name, ',')", TypeArrayOf(TypeString)},
+		testCase{"substr(name, 1) =~ '^a'", TypeBool},
+		testCase{"len(items) - 1", TypeNumber},
+		testCase{"-x", TypeNumber},
+	}
+	for _, testCase := range testCases {
+		result, errors := TypeCheck(MustParse(testCase.input), env)
+		assert.Empty(t, errors, "input=%s", testCase.input)
+		assert.True(t, testCase.result.Equal(result), "input=%s, result=%v", testCase.input, result)
+	}
+}
+
+func TestTypeCheckErrors(t *testing.T) {
+	type testCase struct {
+		input  string
+		errors []string
+	}
+	env := NewTypeEnv(map[string]Type{
+		"x":     TypeNumber,
+		"name":  TypeString,
+		"flag":  TypeBool,
+		"items": TypeArrayOf(TypeNumber),
+	})
+	testCases := [...]testCase{
+		testCase{
+			"x + name * 2",
+			[]string{"lhs of * is string, expected number [pos=4; len=4]"},
+		},
+		testCase{
+			"x ? name : flag + 1",
+			[]string{
+				"lhs of + is bool, expected number [pos=11; len=4]",
+				"ternary condition is number, expected bool [pos=0; len=1]",
+			},
+		},
+		testCase{
+			"name in items || y",
+			[]string{
+				"lhs of in is string, expected number [pos=0; len=4]",
+				"variable undefined: y [pos=17; len=1]",
+			},
+		},
+		testCase{
+			"floor(x, 2) + unknown(x)",
+			[]string{
+				"wrong number of arguments: 2, expected: 1 [op=floor; pos=0; len=11]",
+				"operator undefined: unknown [pos=14; len=10]",
+			},
+		},
+		testCase{
+			"name[0] + items['a']",
+			[]string{
+				"indexer receiver is string, expected array or object [pos=0; len=4]",
+				"index is string, expected number [pos=16; len=3]",
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		_, errors := TypeCheck(MustParse(testCase.input), env)
+		messages := make([]string, len(errors))
+		for idx, err := range errors {
+			messages[idx] = err.Error()
+		}
+		assert.Equal(t, testCase.errors, messages, "input=%s", testCase.input)
+	}
+}
+
+func TestTypeCheckCustomOperator(t *testing.T) {
+	env := NewTypeEnv(map[string]Type{"x": TypeNumber})
+	env.Operators = BuiltinSignatures()
+	env.Operators["isEven"] = Signature(TypeBool, TypeNumber)
+
+	result, errors := TypeCheck(MustParse("isEven(x) && isEven('a')"), env)
+	assert.Equal(t, TypeBool, result)
+	assert.Equal(t, []TypeError{
+		TypeError{Message: "argument #1 of isEven is string, expected number", SourcePos: 20, SourceLen: 3},
+	}, errors)
+}
@@ -0,0 +1,102 @@
+package govaluate
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalk(test *testing.T) {
+
+	var events []string
+	Walk(MustParse("f(a + 1, [b])"), VisitorFuncs{
+		Pre: func(node ExprNode, path ExprPath) bool {
+			parent, idx, ok := path.Parent()
+			if ok {
+				events = append(events, fmt.Sprintf("enter %s (#%d of %s)", printExpr(node), idx, parent.Name))
+			} else {
+				events = append(events, fmt.Sprintf("enter %s", printExpr(node)))
+			}
+			// skip array items
+			return !node.IsOperator("array")
+		},
+		Post: func(node ExprNode, path ExprPath) {
+			events = append(events, fmt.Sprintf("leave %s (depth %d)", printExpr(node), len(path)))
+		},
+	})
+
+	assert.Equal(test, []string{
+		"enter f(a + 1, [b])",
+		"enter a + 1 (#0 of f)",
+		"enter a (#0 of +)",
+		"leave a (depth 2)",
+		"enter 1 (#1 of +)",
+		"leave 1 (depth 2)",
+		"leave a + 1 (depth 1)",
+		"enter [b] (#1 of f)",
+		"leave f(a + 1, [b]) (depth 0)",
+	}, events)
+}
+
+func TestRewrite(test *testing.T) {
+
+	// renaming variables
+	expr := MustParse("a + f(b, a)")
+	renamed := Rewrite(expr, func(node ExprNode) (ExprNode, bool) {
+		if node.Type == NodeTypeVariable && node.Name == "a" {
+			node.Name = "x"
+			return node, true
+		}
+		return node, false
+	})
+	assert.Equal(test, "x + f(b, x)", printExpr(renamed))
+	assert.Equal(test, "a + f(b, a)", printExpr(expr))
+
+	// injecting defaults, replacements are not rewritten again
+	defaults := Rewrite(MustParse("a > b"), func(node ExprNode) (ExprNode, bool) {
+		if node.Type == NodeTypeVariable {
+			zero := NewExprNodeLiteral(0.0, node.SourcePos, node.SourceLen)
+			return NewExprNodeOperator("??", []ExprNode{node, zero}, node.SourcePos, node.SourceLen, OperatorTypeInfix), true
+		}
+		return node, false
+	})
+	assert.Equal(test, "(a ?? 0) > (b ?? 0)", printExpr(defaults))
+
+	// masking with path, only the compared values are replaced
+	masked := RewriteWithPath(MustParse("secret == 'x' && f('y')"), func(node ExprNode, path ExprPath) (ExprNode, bool) {
+		parent, _, ok := path.Parent()
+		if node.Type == NodeTypeLiteral && ok && parent.IsOperator("==") {
+			return NewExprNodeLiteral("***", node.SourcePos, node.SourceLen), true
+		}
+		return node, false
+	})
+	assert.Equal(test, `secret == "***" && f("y")`, printExpr(masked))
+}
+
+func TestRewriteSharesUnchanged(test *testing.T) {
+
+	expr := MustParse("(a + 1) * (b + 2)")
+	rewritten := Rewrite(expr, func(node ExprNode) (ExprNode, bool) {
+		if node.IsLiteral(2.0) {
+			return NewExprNodeLiteral(3.0, node.SourcePos, node.SourceLen), true
+		}
+		return node, false
+	})
+	assert.Equal(test, "(a + 1) * (b + 3)", printExpr(rewritten))
+	assert.True(test, &expr.Args[0].Args[0] == &rewritten.Args[0].Args[0], "unchanged subtree is shared")
+	assert.False(test, &expr.Args[1].Args[0] == &rewritten.Args[1].Args[0], "changed subtree is rebuilt")
+
+	unchanged := Rewrite(expr, func(node ExprNode) (ExprNode, bool) {
+		return node, false
+	})
+	assert.True(test, &expr.Args[0] == &unchanged.Args[0])
+}
+
+func printExpr(expr ExprNode) string {
+	output, err := expr.Print(PrintConfig{})
+	if err != nil {
+		return err.Error()
+	}
+	return output
+}